@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
 	"github.com/khaleelsyed/codaVirtuale/internal/api"
+	"github.com/khaleelsyed/codaVirtuale/internal/events"
+	"github.com/khaleelsyed/codaVirtuale/internal/noshow"
+	"github.com/khaleelsyed/codaVirtuale/internal/notify"
 	"github.com/khaleelsyed/codaVirtuale/internal/storage"
 	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"github.com/khaleelsyed/codaVirtuale/internal/webhooks"
 )
 
 func main() {
@@ -26,8 +31,28 @@ func main() {
 	}
 	logger.Info("database connection is stable")
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go noshow.NewWorker(storage, logger, noshow.DefaultInterval).Run(ctx)
+
+	notifiers := notify.FromEnv()
+	go notify.NewDispatcher(storage, notifiers, logger, notify.DefaultInterval).Run(ctx)
+
+	go webhooks.NewDispatcher(storage, logger, webhooks.DefaultInterval).Run(ctx)
+
+	hub := events.NewHub(logger)
+	go storage.ListenTicketEvents(ctx, hub.Publish)
+
 	listenAddress := os.Getenv("LISTEN_ADDRESS")
 
-	server := api.NewAPIServer(listenAddress, storage, logger)
+	forLocation := func(locationID int) api.Storage { return storage.ForLocation(locationID) }
+
+	server := api.NewAPIServer(listenAddress, storage, forLocation, hub, notifiers, logger)
+
+	if grpcListenAddress := os.Getenv("GRPC_LISTEN_ADDRESS"); grpcListenAddress != "" {
+		go server.RunGRPC(grpcListenAddress)
+	}
+
 	server.Run()
 }
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/khaleelsyed/codaVirtuale/pkg/client"
+)
+
+func runCategories(ctx context.Context, c *cli, args []string) error {
+	action, args, err := subcommand("categories", args, map[string]command{
+		"list":   listCategories,
+		"get":    getCategory,
+		"create": createCategory,
+		"rename": renameCategory,
+		"delete": deleteCategory,
+	})
+	if err != nil {
+		return err
+	}
+	return action(ctx, c, args)
+}
+
+func listCategories(ctx context.Context, c *cli, args []string) error {
+	if err := parseFlags(flag.NewFlagSet("categories list", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+
+	categories, err := c.client.ListCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.printCategories(categories)
+}
+
+func getCategory(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("categories get", flag.ContinueOnError)
+	id := flags.Int("id", 0, "ID of the category")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "id"); err != nil {
+		return err
+	}
+
+	category, err := c.client.GetCategory(ctx, *id)
+	if err != nil {
+		return err
+	}
+
+	return c.print(category, []string{"ID", "NAME", "VERSION", "WAITING", "OPEN DESKS", "WAIT (S)"}, [][]string{{
+		strconv.Itoa(category.ID),
+		category.Name,
+		strconv.Itoa(category.Version),
+		strconv.Itoa(category.Waiting),
+		strconv.Itoa(category.OpenDesks),
+		optionalInt(category.EstimatedWaitSeconds),
+	}})
+}
+
+func createCategory(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("categories create", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the category")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "name"); err != nil {
+		return err
+	}
+
+	category, err := c.client.CreateCategory(ctx, *name)
+	if err != nil {
+		return err
+	}
+
+	return c.printCategories([]client.Category{category})
+}
+
+func renameCategory(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("categories rename", flag.ContinueOnError)
+	id := flags.Int("id", 0, "ID of the category")
+	name := flags.String("name", "", "new name of the category")
+	version := flags.Int("version", 0, "only rename the category if it is still at this version, as shown by get")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "id", "name"); err != nil {
+		return err
+	}
+
+	category, err := c.client.RenameCategory(ctx, *id, *name, *version)
+	if err != nil {
+		return err
+	}
+
+	return c.printCategories([]client.Category{category})
+}
+
+func deleteCategory(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("categories delete", flag.ContinueOnError)
+	id := flags.Int("id", 0, "ID of the category")
+	version := flags.Int("version", 0, "only delete the category if it is still at this version, as shown by get")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "id"); err != nil {
+		return err
+	}
+
+	if err := c.client.DeleteCategory(ctx, *id, *version); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "deleted category %d\n", *id)
+	return nil
+}
+
+func (c *cli) printCategories(categories []client.Category) error {
+	rows := make([][]string, len(categories))
+	for i, category := range categories {
+		rows[i] = []string{strconv.Itoa(category.ID), category.Name, strconv.Itoa(category.Version)}
+	}
+
+	return c.print(categories, []string{"ID", "NAME", "VERSION"}, rows)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runConfig exports and applies the YAML config describing a location's
+// categories, desks, queue policies and opening hours.
+func runConfig(ctx context.Context, c *cli, args []string) error {
+	action, args, err := subcommand("config", args, map[string]command{
+		"export": exportConfig,
+		"apply":  applyConfig,
+	})
+	if err != nil {
+		return err
+	}
+	return action(ctx, c, args)
+}
+
+func exportConfig(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("config export", flag.ContinueOnError)
+	file := flags.String("file", "", "file to write the config to, standard output when left out")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	config, err := c.client.ExportConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		_, err = c.stdout.Write(config)
+		return err
+	}
+	return os.WriteFile(*file, config, 0o644)
+}
+
+// applyConfig brings the location in line with a config, creating the
+// categories and desks that are missing and updating those that differ.
+func applyConfig(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("config apply", flag.ContinueOnError)
+	file := flags.String("file", "", "file to read the config from, - for standard input")
+	prune := flags.Bool("prune", false, "delete the categories and desks the config leaves out")
+	dryRun := flags.Bool("dry-run", false, "list the changes without making them")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "file"); err != nil {
+		return err
+	}
+
+	config, err := readFile(*file)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.client.ApplyConfig(ctx, config, *prune, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if c.output == "table" && len(plan.Changes) == 0 {
+		fmt.Fprintln(c.stdout, "no changes")
+		return nil
+	}
+
+	rows := make([][]string, len(plan.Changes))
+	for i, change := range plan.Changes {
+		rows[i] = []string{string(change.Action), string(change.Kind), change.Name, strings.Join(change.Fields, ", ")}
+	}
+
+	if err = c.print(plan, []string{"ACTION", "KIND", "NAME", "CHANGED"}, rows); err != nil {
+		return err
+	}
+
+	if c.output == "table" && plan.DryRun {
+		fmt.Fprintln(c.stdout, "dry run, nothing was changed")
+	}
+	return nil
+}
+
+func readFile(file string) ([]byte, error) {
+	if file == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(file)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/khaleelsyed/codaVirtuale/pkg/client"
+)
+
+func runDesks(ctx context.Context, c *cli, args []string) error {
+	action, args, err := subcommand("desks", args, map[string]command{
+		"list":   listDesks,
+		"get":    getDesk,
+		"create": createDesk,
+		"update": updateDesk,
+		"delete": deleteDesk,
+	})
+	if err != nil {
+		return err
+	}
+	return action(ctx, c, args)
+}
+
+func listDesks(ctx context.Context, c *cli, args []string) error {
+	if err := parseFlags(flag.NewFlagSet("desks list", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+
+	desks, err := c.client.ListDesks(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.printDesks(desks)
+}
+
+func getDesk(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("desks get", flag.ContinueOnError)
+	id := flags.Int("id", 0, "ID of the desk")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "id"); err != nil {
+		return err
+	}
+
+	desk, err := c.client.GetDesk(ctx, *id)
+	if err != nil {
+		return err
+	}
+
+	return c.printDesks([]client.Desk{desk})
+}
+
+func createDesk(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("desks create", flag.ContinueOnError)
+	label := flags.String("label", "", "label of the desk")
+	categoryID := flags.Int("category", 0, "ID of the category the desk serves")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "label", "category"); err != nil {
+		return err
+	}
+
+	desk, err := c.client.CreateDesk(ctx, *label, *categoryID)
+	if err != nil {
+		return err
+	}
+
+	return c.printDesks([]client.Desk{desk})
+}
+
+func updateDesk(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("desks update", flag.ContinueOnError)
+	id := flags.Int("id", 0, "ID of the desk")
+	label := flags.String("label", "", "new label of the desk")
+	categoryID := flags.Int("category", 0, "ID of the category the desk serves instead")
+	version := flags.Int("version", 0, "only update the desk if it is still at this version, as shown by get")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "id"); err != nil {
+		return err
+	}
+
+	desk, err := c.client.UpdateDesk(ctx, *id, client.DeskUpdate{Label: *label, CategoryID: *categoryID}, *version)
+	if err != nil {
+		return err
+	}
+
+	return c.printDesks([]client.Desk{desk})
+}
+
+func deleteDesk(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("desks delete", flag.ContinueOnError)
+	id := flags.Int("id", 0, "ID of the desk")
+	version := flags.Int("version", 0, "only delete the desk if it is still at this version, as shown by get")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "id"); err != nil {
+		return err
+	}
+
+	if err := c.client.DeleteDesk(ctx, *id, *version); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "deleted desk %d\n", *id)
+	return nil
+}
+
+func (c *cli) printDesks(desks []client.Desk) error {
+	rows := make([][]string, len(desks))
+	for i, desk := range desks {
+		rows[i] = []string{strconv.Itoa(desk.ID), desk.Label, strconv.Itoa(desk.CategoryID), strconv.Itoa(desk.Version)}
+	}
+
+	return c.print(desks, []string{"ID", "LABEL", "CATEGORY", "VERSION"}, rows)
+}
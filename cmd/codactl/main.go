@@ -0,0 +1,192 @@
+// Command codactl manages the categories, desks and queues of a Coda Virtuale
+// server through its API.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/khaleelsyed/codaVirtuale/pkg/client"
+)
+
+const usage = `usage: codactl [flags] <command> [arguments]
+
+Commands:
+  categories list|get|create|rename|delete
+  desks      list|get|create|update|delete
+  queue      list the waiting tickets
+  next       call the next ticket to a desk
+  intake     close|open the intake of a category
+  config     export|apply the YAML config of the categories and desks
+
+Flags:
+`
+
+// cli holds the settings shared by every command.
+type cli struct {
+	client *client.Client
+	output string
+	stdout io.Writer
+}
+
+// command runs a subcommand with the arguments following its name.
+type command func(ctx context.Context, c *cli, args []string) error
+
+var commands = map[string]command{
+	"categories": runCategories,
+	"desks":      runDesks,
+	"queue":      runQueue,
+	"next":       runNext,
+	"intake":     runIntake,
+	"config":     runConfig,
+}
+
+// errUsage is returned by commands given the wrong arguments, once they have
+// explained why.
+var errUsage = errors.New("usage")
+
+func main() {
+	flags := flag.NewFlagSet("codactl", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+
+	server := flags.String("server", envOr("CODA_SERVER", "http://localhost:3000"), "base URL of the server, or $CODA_SERVER")
+	location := flags.String("location", os.Getenv("CODA_LOCATION"), "slug of the location, the default location when empty, or $CODA_LOCATION")
+	staff := flags.String("staff", os.Getenv("CODA_STAFF_ID"), "staff ID recorded against queue actions, or $CODA_STAFF_ID")
+	output := flags.String("output", "table", "output format, table or json")
+	flags.Parse(os.Args[1:])
+
+	if *output != "table" && *output != "json" {
+		fmt.Fprintln(os.Stderr, "codactl: -output must be table or json")
+		os.Exit(2)
+	}
+
+	args := flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	run, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "codactl: unknown command %q\n", args[0])
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	apiClient := client.New(*server)
+	apiClient.Location = *location
+	apiClient.StaffID = *staff
+
+	c := &cli{client: apiClient, output: *output, stdout: os.Stdout}
+
+	if err := run(context.Background(), c, args[1:]); err != nil {
+		if err == errUsage {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "codactl:", err)
+		os.Exit(1)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// subcommand picks the action of a command such as 'categories list'.
+func subcommand(name string, args []string, actions map[string]command) (command, []string, error) {
+	names := slices.Sorted(maps.Keys(actions))
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: codactl %s %s\n", name, strings.Join(names, "|"))
+		return nil, nil, errUsage
+	}
+
+	action, ok := actions[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "codactl: unknown %s action %q, expected one of %s\n", name, args[0], strings.Join(names, ", "))
+		return nil, nil, errUsage
+	}
+
+	return action, args[1:], nil
+}
+
+// parseFlags parses the flags of an action, reporting a usage error rather
+// than exiting.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	flags.SetOutput(os.Stderr)
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// required checks that an action was given its required flags.
+func required(flags *flag.FlagSet, names ...string) error {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for _, name := range names {
+		if !set[name] {
+			fmt.Fprintf(os.Stderr, "codactl: -%s is required\n", name)
+			flags.Usage()
+			return errUsage
+		}
+	}
+	return nil
+}
+
+// print writes v as JSON, or as a table of rows under header.
+func (c *cli) print(v any, header []string, rows [][]string) error {
+	if c.output == "json" {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// parseIDs reads a comma separated list of IDs.
+func parseIDs(value string) ([]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var ids []int
+	for _, idStr := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			return nil, fmt.Errorf("bad ID %q", idStr)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func optionalInt(n *int) string {
+	if n == nil {
+		return "-"
+	}
+	return strconv.Itoa(*n)
+}
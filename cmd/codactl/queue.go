@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/pkg/client"
+)
+
+func runQueue(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("queue", flag.ContinueOnError)
+	categories := flags.String("category", "", "comma separated IDs of the categories to list, all of them when left out")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	categoryIDs, err := parseIDs(*categories)
+	if err != nil {
+		return err
+	}
+
+	queue, err := c.client.Queue(ctx, categoryIDs...)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(queue))
+	for i, entry := range queue {
+		priority := ""
+		if entry.Priority {
+			priority = "yes"
+		}
+		rows[i] = []string{
+			strconv.Itoa(i + 1),
+			entry.DisplayNumber,
+			strconv.Itoa(entry.ID),
+			strconv.Itoa(entry.CategoryID),
+			entry.QueuedAt.Local().Format(time.DateTime),
+			priority,
+		}
+	}
+
+	return c.print(queue, []string{"#", "NUMBER", "TICKET", "CATEGORY", "QUEUED", "PRIORITY"}, rows)
+}
+
+// runNext calls the next ticket to a desk, as its staff would.
+func runNext(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("next", flag.ContinueOnError)
+	deskID := flags.Int("desk", 0, "ID of the desk to call the ticket to")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "desk"); err != nil {
+		return err
+	}
+
+	ticket, err := c.client.CallNext(ctx, *deskID)
+	if err != nil {
+		return err
+	}
+
+	return c.printTickets([]client.Ticket{ticket})
+}
+
+func runIntake(ctx context.Context, c *cli, args []string) error {
+	action, args, err := subcommand("intake", args, map[string]command{
+		"close": func(ctx context.Context, c *cli, args []string) error { return setIntake(ctx, c, args, true) },
+		"open":  func(ctx context.Context, c *cli, args []string) error { return setIntake(ctx, c, args, false) },
+	})
+	if err != nil {
+		return err
+	}
+	return action(ctx, c, args)
+}
+
+// setIntake stops or resumes the issuing of tickets for a category. Reopening
+// intake does not override the category's opening hours.
+func setIntake(ctx context.Context, c *cli, args []string, closed bool) error {
+	flags := flag.NewFlagSet("intake", flag.ContinueOnError)
+	categoryID := flags.Int("category", 0, "ID of the category")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := required(flags, "category"); err != nil {
+		return err
+	}
+
+	settings, err := c.client.SetIntakeClosed(ctx, *categoryID, closed)
+	if err != nil {
+		return err
+	}
+
+	state := "open"
+	if settings.IntakeClosed {
+		state = "closed"
+	}
+
+	return c.print(settings, []string{"CATEGORY", "INTAKE"}, [][]string{{strconv.Itoa(settings.CategoryID), state}})
+}
+
+func (c *cli) printTickets(tickets []client.Ticket) error {
+	rows := make([][]string, len(tickets))
+	for i, ticket := range tickets {
+		desk := "-"
+		if ticket.DeskID > 0 {
+			desk = strconv.Itoa(ticket.DeskID)
+		}
+		rows[i] = []string{strconv.Itoa(ticket.ID), strconv.Itoa(ticket.CategoryID), desk, fmt.Sprint(ticket.Closed)}
+	}
+
+	return c.print(tickets, []string{"TICKET", "CATEGORY", "DESK", "CLOSED"}, rows)
+}
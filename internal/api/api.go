@@ -2,10 +2,17 @@ package api
 
 import (
 	"net/http"
+	"os"
 	"slices"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/events"
+	"github.com/khaleelsyed/codaVirtuale/internal/notify"
+	"github.com/khaleelsyed/codaVirtuale/internal/ratelimit"
 	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"github.com/khaleelsyed/codaVirtuale/internal/waittime"
 )
 
 type handlerFunc func(w http.ResponseWriter, r *http.Request) error
@@ -13,13 +20,58 @@ type handlerFunc func(w http.ResponseWriter, r *http.Request) error
 type APIServer struct {
 	listenAddress      string
 	storage            Storage
+	forLocation        StorageForLocation
+	events             *events.Hub
 	logger             *types.SugarWithTrace
 	rollingQueueNumber int
+	waitEstimator      waittime.Estimator
+	snoozeLimit        int
+	ticketLimiter      *ratelimit.Limiter
+	trustProxyHeaders  bool
+	trustedProxyHops   int
+	checkInWindow      types.CheckInWindow
+	publicBaseURL      string
+	notifiers          notify.Notifiers
+	idempotencyWindow  time.Duration
+	maxBodyBytes       int64
 }
 
 func (s *APIServer) Run() {
+	router := s.newRouter()
+
+	if s.publicBaseURL == "" {
+		s.logger.Warnw("PUBLIC_BASE_URL is not set, tickets and QR codes cannot be printed")
+	}
+
+	s.logger.Infow("Listening to requests", "listenAddress", s.listenAddress)
+	if err := http.ListenAndServe(s.listenAddress, router); err != nil {
+		s.logger.Errorw("Failed to run ListenAndServe", "error", err)
+	}
+}
+
+func (s *APIServer) newRouter() *mux.Router {
 	router := mux.NewRouter()
+	router.Use(s.withBodyLimit, s.withIdempotency)
+
+	s.addOpenAPIRoutes(router)
+	s.addLocationRoutes(router)
+
+	locationRouter := router.PathPrefix("/locations/{loc}").Subrouter()
+	locationRouter.Use(s.withLocation)
+	s.addLocationSettingsRoutes(locationRouter)
+	s.addScopedRoutes(locationRouter)
+
+	// The routes without a location prefix act on the default location.
+	defaultRouter := router.NewRoute().Subrouter()
+	defaultRouter.Use(s.withLocation)
+	s.addScopedRoutes(defaultRouter)
 
+	return router
+}
+
+// addScopedRoutes adds the routes that act on the categories, desks and
+// tickets of a single location.
+func (s *APIServer) addScopedRoutes(router *mux.Router) {
 	staffRouter := router.PathPrefix("/internal").Subrouter()
 	s.addStaffRoutes(staffRouter)
 
@@ -32,10 +84,20 @@ func (s *APIServer) Run() {
 	deskRouter := router.PathPrefix("/desk").Subrouter()
 	s.addDeskRoutes(deskRouter)
 
-	s.logger.Infow("Listening to requests", "listenAddress", s.listenAddress)
-	if err := http.ListenAndServe(s.listenAddress, router); err != nil {
-		s.logger.Errorw("Failed to run ListenAndServe", "error", err)
-	}
+	customerRouter := router.PathPrefix("/t").Subrouter()
+	s.addCustomerRoutes(customerRouter)
+
+	appointmentRouter := router.PathPrefix("/appointments").Subrouter()
+	s.addAppointmentRoutes(appointmentRouter)
+
+	eventRouter := router.PathPrefix("/events").Subrouter()
+	s.addEventRoutes(eventRouter)
+
+	displayRouter := router.PathPrefix("/display").Subrouter()
+	s.addDisplayRoutes(displayRouter)
+
+	consoleRouter := router.PathPrefix("/console").Subrouter()
+	s.addConsoleRoutes(consoleRouter)
 }
 
 func makeHTTPHandler(f handlerFunc, allowedMethods []string, logger *types.SugarWithTrace) http.HandlerFunc {
@@ -53,11 +115,49 @@ func makeHTTPHandler(f handlerFunc, allowedMethods []string, logger *types.Sugar
 	}
 }
 
-func NewAPIServer(listenAddress string, storage Storage, logger *types.SugarWithTrace) *APIServer {
+func NewAPIServer(listenAddress string, storage Storage, forLocation StorageForLocation, events *events.Hub, notifiers notify.Notifiers, logger *types.SugarWithTrace) *APIServer {
+	strategy, err := waittime.NewStrategy(os.Getenv("WAIT_ESTIMATE_STRATEGY"))
+	if err != nil {
+		logger.Warnw("falling back to the default wait time strategy", "error", err)
+		strategy, _ = waittime.NewStrategy("")
+	}
+
 	return &APIServer{
 		listenAddress:      listenAddress,
 		storage:            storage,
+		forLocation:        forLocation,
+		events:             events,
 		logger:             logger,
 		rollingQueueNumber: 1,
+		waitEstimator:      waittime.NewEstimator(strategy),
+		snoozeLimit:        envInt("SNOOZE_LIMIT", 2, logger),
+		ticketLimiter:      ratelimit.NewLimiter(),
+		trustProxyHeaders:  os.Getenv("TRUST_PROXY_HEADERS") == "true",
+		trustedProxyHops:   max(envInt("TRUSTED_PROXY_HOPS", 1, logger), 1),
+		checkInWindow: types.CheckInWindow{
+			Early: time.Duration(envInt("APPOINTMENT_EARLY_MINUTES", 30, logger)) * time.Minute,
+			Grace: time.Duration(envInt("APPOINTMENT_GRACE_MINUTES", 15, logger)) * time.Minute,
+		},
+		publicBaseURL:     os.Getenv("PUBLIC_BASE_URL"),
+		notifiers:         notifiers,
+		idempotencyWindow: time.Duration(envInt("IDEMPOTENCY_WINDOW_HOURS", 24, logger)) * time.Hour,
+		maxBodyBytes:      int64(envInt("MAX_REQUEST_BODY_KB", 1024, logger)) << 10,
+	}
+}
+
+// envInt reads an integer setting from the environment, falling back to
+// fallback when it is unset or invalid.
+func envInt(key string, fallback int, logger *types.SugarWithTrace) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
 	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		logger.Warnw("invalid integer setting, using default", "key", key, "value", value, "default", fallback)
+		return fallback
+	}
+
+	return n
 }
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/events"
+	"github.com/khaleelsyed/codaVirtuale/internal/notify"
+	"github.com/khaleelsyed/codaVirtuale/internal/storage"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"go.uber.org/zap"
+)
+
+// newTestServer returns an API server backed by an in-memory storage, whose
+// ticket events are published to the server's hub as main does.
+func newTestServer(t *testing.T) (*APIServer, *storage.MockStorage) {
+	t.Helper()
+
+	logger := &types.SugarWithTrace{SugaredLogger: zap.NewNop().Sugar()}
+	store := storage.NewMockStorage()
+	hub := events.NewHub(logger)
+	store.OnTicketEvent(hub.Publish)
+
+	forLocation := func(locationID int) Storage { return store.ForLocation(locationID) }
+	return NewAPIServer("", store, forLocation, hub, notify.Notifiers{}, logger), store
+}
+
+// openDesk creates a desk in a category of the default location and signs
+// staff in to it, so that it can call tickets.
+func openDesk(t *testing.T, store *storage.MockStorage, categoryID int) types.Desk {
+	t.Helper()
+
+	desk, err := store.ForLocation(1).CreateDesk("Desk 1", categoryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.OpenDeskSession(desk.ID, "alice", "token"); err != nil {
+		t.Fatal(err)
+	}
+	return desk
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+func (s *APIServer) addAppointmentRoutes(router *mux.Router) {
+	router.HandleFunc("/slots", makeHTTPHandler(s.getAppointmentSlots, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/{sub_url}/check-in", makeHTTPHandler(s.checkInAppointment, []string{http.MethodPost}, s.logger))
+	s.addAppointmentQRRoutes(router)
+	router.HandleFunc("/{sub_url}", makeHTTPHandler(s.handleAppointment, []string{http.MethodGet, http.MethodDelete}, s.logger))
+	router.HandleFunc("", makeHTTPHandler(s.bookAppointment, []string{http.MethodPost}, s.logger))
+}
+
+func (s *APIServer) addAppointmentSlotRoutes(router *mux.Router) {
+	router.HandleFunc("/appointments/slots/{id}", makeHTTPHandler(s.deleteAppointmentSlot, []string{http.MethodDelete}, s.logger))
+	router.HandleFunc("/appointments/slots", makeHTTPHandler(s.createAppointmentSlot, []string{http.MethodPost}, s.logger))
+}
+
+type appointmentSlotRequest struct {
+	CategoryID int       `json:"category_id" validate:"required,exists=category"`
+	StartsAt   time.Time `json:"starts_at" validate:"required"`
+	EndsAt     time.Time `json:"ends_at" validate:"required"`
+	Capacity   int       `json:"capacity" validate:"min=1,max=1000"`
+}
+
+func (rB appointmentSlotRequest) validate() []error {
+	if !rB.StartsAt.IsZero() && !rB.EndsAt.IsZero() && !rB.EndsAt.After(rB.StartsAt) {
+		return []error{apiError{"'ends_at' must be after 'starts_at'"}}
+	}
+	return nil
+}
+
+type bookAppointmentRequest struct {
+	SlotID int    `json:"slot_id" validate:"required"`
+	Name   string `json:"name" validate:"required,max=100"`
+}
+
+// getAppointmentSlots lists the slots of a category between the optional
+// RFC 3339 'from' and 'to' query parameters, defaulting to the next week.
+func (s *APIServer) getAppointmentSlots(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+
+	categoryID, err := strconv.Atoi(query.Get("category_id"))
+	if err != nil {
+		errBody := "bad category ID"
+		return writeJSON(w, http.StatusBadRequest, errors.New(errBody), s.logger)
+	}
+
+	from := time.Now()
+	to := from.AddDate(0, 0, 7)
+
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return writeJSON(w, http.StatusBadRequest, apiError{"'from' must be an RFC 3339 time"}, s.logger)
+		}
+	}
+
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return writeJSON(w, http.StatusBadRequest, apiError{"'to' must be an RFC 3339 time"}, s.logger)
+		}
+	}
+
+	slots, err := s.store(r).ListAppointmentSlots(categoryID, from, to)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, slots, s.logger)
+}
+
+func (s *APIServer) createAppointmentSlot(w http.ResponseWriter, r *http.Request) error {
+	var requestBody appointmentSlotRequest
+
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
+	}
+
+	slot, err := s.store(r).CreateAppointmentSlot(types.AppointmentSlot{
+		CategoryID: requestBody.CategoryID,
+		StartsAt:   requestBody.StartsAt,
+		EndsAt:     requestBody.EndsAt,
+		Capacity:   requestBody.Capacity,
+	})
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, errors.New("error creating appointment slot"), s.logger)
+	}
+
+	return writeJSON(w, http.StatusCreated, slot, s.logger)
+}
+
+func (s *APIServer) deleteAppointmentSlot(w http.ResponseWriter, r *http.Request) error {
+	idStr := mux.Vars(r)["id"]
+	slotID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+
+	if err := s.store(r).DeleteAppointmentSlot(slotID); err != nil {
+		errBody := badValidationString("appointment slot")
+		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+
+	return writeJSON(w, http.StatusNoContent, nil, s.logger)
+}
+
+func (s *APIServer) bookAppointment(w http.ResponseWriter, r *http.Request) error {
+	var requestBody bookAppointmentRequest
+
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
+	}
+
+	slot, err := s.store(r).GetAppointmentSlot(requestBody.SlotID)
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, errors.New("appointment slot not found"), s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	if !slot.StartsAt.After(time.Now()) {
+		return writeJSON(w, http.StatusConflict, apiError{"appointment slot has already started"}, s.logger)
+	}
+
+	appointment, err := s.store(r).BookAppointment(slot.ID, requestBody.Name, randomSubURL())
+	if err != nil {
+		if err == types.ErrSlotFull {
+			return writeJSON(w, http.StatusConflict, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, errors.New("error booking appointment"), s.logger)
+	}
+
+	return writeJSON(w, http.StatusCreated, appointment, s.logger)
+}
+
+func (s *APIServer) getAppointment(w http.ResponseWriter, r *http.Request) error {
+	appointment, err := s.store(r).GetAppointmentBySubURL(mux.Vars(r)["sub_url"])
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, appointment, s.logger)
+}
+
+func (s *APIServer) cancelAppointment(w http.ResponseWriter, r *http.Request) error {
+	appointment, err := s.store(r).GetAppointmentBySubURL(mux.Vars(r)["sub_url"])
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	appointment, err = s.store(r).CancelAppointment(appointment.ID)
+	if err != nil {
+		if err == types.ErrAppointmentNotBooked {
+			return writeJSON(w, http.StatusConflict, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, appointment, s.logger)
+}
+
+// checkInAppointment converts an appointment into a ticket in the live queue.
+func (s *APIServer) checkInAppointment(w http.ResponseWriter, r *http.Request) error {
+	appointment, err := s.store(r).GetAppointmentBySubURL(mux.Vars(r)["sub_url"])
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	ticket, err := s.checkInTicket(r.Context(), appointment.ID)
+	if err != nil {
+		switch err {
+		case types.ErrAppointmentNotBooked:
+			return writeJSON(w, http.StatusConflict, err, s.logger)
+		case types.ErrTooEarly:
+			opens := appointment.StartsAt.Add(-s.checkInWindow.Early)
+			return writeJSON(w, http.StatusConflict, fmt.Errorf("%w, check in opens at %s", err, opens.Format(time.RFC3339)), s.logger)
+		default:
+			return writeJSON(w, http.StatusInternalServerError, errors.New("error checking in appointment"), s.logger)
+		}
+	}
+
+	response, err := s.newTicketResponse(r.Context(), ticket)
+	if err != nil {
+		s.logger.Warnw("failed to estimate wait for checked in ticket", "id", ticket.ID, "error", err)
+		return writeJSON(w, http.StatusCreated, ticketResponse{Ticket: ticket}, s.logger)
+	}
+
+	return writeJSON(w, http.StatusCreated, response, s.logger)
+}
+
+// checkInTicket checks in an appointment under a new ticket SubURL, retrying
+// when the SubURL is already taken.
+func (s *APIServer) checkInTicket(ctx context.Context, appointmentID int) (types.Ticket, error) {
+	for range 3 {
+		_, ticket, err := s.storeFor(ctx).CheckInAppointment(appointmentID, randomSubURL(), s.checkInWindow)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), pqUniqueConstraintViolation) {
+				s.logger.Tracew("Failed to create a unique ticket url, retrying", "error", err, "appointment_id", appointmentID)
+				continue
+			}
+			return types.Ticket{}, err
+		}
+
+		return ticket, nil
+	}
+
+	return types.Ticket{}, errors.New("could not generate a unique ticket SubURL")
+}
+
+func (s *APIServer) handleAppointment(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.getAppointment(w, r)
+	case http.MethodDelete:
+		return s.cancelAppointment(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -13,8 +12,28 @@ import (
 )
 
 func (s *APIServer) addCategoryRoutes(router *mux.Router) {
-	router.HandleFunc("/{id}", makeHTTPHandler(s.handleCategory, []string{http.MethodGet, http.MethodPut, http.MethodDelete}, s.logger))
-	router.HandleFunc("", makeHTTPHandler(s.createCategory, []string{http.MethodPost}, s.logger))
+	router.HandleFunc("/{id}", makeHTTPHandler(s.handleCategory, []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete}, s.logger))
+	router.HandleFunc("", makeHTTPHandler(s.handleCategories, []string{http.MethodGet, http.MethodPost}, s.logger))
+	s.addCategorySettingsRoutes(router)
+	s.addCategoryHoursRoutes(router)
+}
+
+// categoryRequest is the body of both creating and replacing a category.
+type categoryRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+// categoryPatch is a merge patch of a category, changing the members it
+// includes.
+type categoryPatch struct {
+	Name *string `json:"name" validate:"notblank,max=50"`
+}
+
+func (p categoryPatch) validate() []error {
+	if p.Name == nil {
+		return []error{apiError{"the patch must change 'name'"}}
+	}
+	return nil
 }
 
 func (s *APIServer) getCategory(w http.ResponseWriter, r *http.Request) error {
@@ -25,7 +44,7 @@ func (s *APIServer) getCategory(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
-	category, err := s.storage.GetCategory(categoryID)
+	category, err := s.store(r).GetCategory(categoryID)
 	if err != nil {
 		if err == types.ErrnotFound {
 			return writeJSON(w, http.StatusNotFound, err, s.logger)
@@ -33,16 +52,44 @@ func (s *APIServer) getCategory(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, badValidationString("category"), s.logger)
 	}
 
-	return writeJSON(w, http.StatusOK, category, s.logger)
+	response, err := s.newCategoryResponse(r.Context(), category)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	setETag(w, category.Version)
+	return writeJSON(w, http.StatusOK, response, s.logger)
 }
 
 func (s *APIServer) putCategory(w http.ResponseWriter, r *http.Request) error {
 	var err error
 
-	var requestBody struct {
-		Name string
+	var requestBody categoryRequest
+
+	idStr := mux.Vars(r)["id"]
+	categoryID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
+	version, err := ifMatch(r)
+	if err != nil {
+		return writeJSON(w, ifMatchStatus(err), err, s.logger)
+	}
+
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
+	}
+
+	return s.renameCategory(w, r, categoryID, requestBody.Name, version)
+}
+
+func (s *APIServer) patchCategory(w http.ResponseWriter, r *http.Request) error {
+	var err error
+
+	var patch categoryPatch
+
 	idStr := mux.Vars(r)["id"]
 	categoryID, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -50,12 +97,24 @@ func (s *APIServer) putCategory(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		return writeJSON(w, http.StatusBadRequest, errBadRequestBody, s.logger)
+	version, err := ifMatch(r)
+	if err != nil {
+		return writeJSON(w, ifMatchStatus(err), err, s.logger)
+	}
+
+	if ok, err := s.readPatch(w, r, &patch); !ok {
+		return err
 	}
 
-	category, err := s.storage.UpdateCategory(categoryID, requestBody.Name)
+	return s.renameCategory(w, r, categoryID, *patch.Name, version)
+}
+
+func (s *APIServer) renameCategory(w http.ResponseWriter, r *http.Request, categoryID int, name string, version int) error {
+	category, err := s.store(r).UpdateCategory(categoryID, name, version)
 	if err != nil {
+		if status, err := versionedWriteStatus(err); status != 0 {
+			return writeJSON(w, status, err, s.logger)
+		}
 		if strings.HasPrefix(err.Error(), pqUniqueConstraintViolation) {
 			return writeJSON(w, http.StatusBadRequest, "'name' must be unique", s.logger)
 		}
@@ -63,6 +122,7 @@ func (s *APIServer) putCategory(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
+	setETag(w, category.Version)
 	return writeJSON(w, http.StatusOK, category, s.logger)
 }
 
@@ -74,7 +134,15 @@ func (s *APIServer) deleteCategory(w http.ResponseWriter, r *http.Request) error
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
-	if err := s.storage.DeleteCategory(categoryID); err != nil {
+	version, err := ifMatch(r)
+	if err != nil {
+		return writeJSON(w, ifMatchStatus(err), err, s.logger)
+	}
+
+	if err := s.store(r).DeleteCategory(categoryID, version); err != nil {
+		if status, err := versionedWriteStatus(err); status != 0 {
+			return writeJSON(w, status, err, s.logger)
+		}
 		errBody := badValidationString("category")
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
@@ -85,29 +153,54 @@ func (s *APIServer) deleteCategory(w http.ResponseWriter, r *http.Request) error
 func (s *APIServer) createCategory(w http.ResponseWriter, r *http.Request) error {
 	var err error
 
-	var requestBody struct {
-		Name string `json:"name"`
-	}
+	var requestBody categoryRequest
 
-	if err = json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		return writeJSON(w, http.StatusBadRequest, errBadRequestBody, s.logger)
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
 	}
 
-	category, err := s.storage.CreateCategory(requestBody.Name)
+	category, err := s.store(r).CreateCategory(requestBody.Name)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), pqUniqueConstraintViolation) {
+			return writeJSON(w, http.StatusBadRequest, "'name' must be unique", s.logger)
+		}
 		errBody := "error creating category"
 		return writeJSON(w, http.StatusInternalServerError, errors.New(errBody), s.logger)
 	}
 
+	setETag(w, category.Version)
 	return writeJSON(w, http.StatusCreated, category, s.logger)
 }
 
+func (s *APIServer) listCategories(w http.ResponseWriter, r *http.Request) error {
+	categories, err := s.store(r).ListCategories()
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, categories, s.logger)
+}
+
+func (s *APIServer) handleCategories(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.listCategories(w, r)
+	case http.MethodPost:
+		return s.createCategory(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
+
 func (s *APIServer) handleCategory(w http.ResponseWriter, r *http.Request) error {
 	switch r.Method {
 	case http.MethodGet:
 		return s.getCategory(w, r)
 	case http.MethodPut:
 		return s.putCategory(w, r)
+	case http.MethodPatch:
+		return s.patchCategory(w, r)
 	case http.MethodDelete:
 		return s.deleteCategory(w, r)
 	default:
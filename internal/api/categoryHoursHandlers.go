@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/schedule"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+func (s *APIServer) addCategoryHoursRoutes(router *mux.Router) {
+	router.HandleFunc("/{id}/hours", makeHTTPHandler(s.handleCategoryHours, []string{http.MethodGet, http.MethodPut}, s.logger))
+	router.HandleFunc("/{id}/intake", makeHTTPHandler(s.putCategoryIntake, []string{http.MethodPut}, s.logger))
+}
+
+type categoryIntakeRequest struct {
+	Closed bool `json:"closed"`
+}
+
+func (s *APIServer) getCategoryHours(w http.ResponseWriter, r *http.Request) error {
+	categoryID, err := s.categoryIDFromVars(w, r)
+	if err != nil || categoryID == 0 {
+		return err
+	}
+
+	hours, err := s.store(r).GetCategoryHours(categoryID)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, hours, s.logger)
+}
+
+func (s *APIServer) putCategoryHours(w http.ResponseWriter, r *http.Request) error {
+	categoryID, err := s.categoryIDFromVars(w, r)
+	if err != nil || categoryID == 0 {
+		return err
+	}
+
+	var requestBody types.CategoryHours
+
+	if err = readJSON(r, &requestBody); err != nil {
+		return writeJSON(w, bodyErrorStatus(err), err, s.logger)
+	}
+
+	requestBody.CategoryID = categoryID
+
+	if err = validateOpeningSchedule("UTC", requestBody.OpeningSchedule); err != nil {
+		return writeJSON(w, http.StatusBadRequest, err, s.logger)
+	}
+
+	hours, err := s.store(r).SetCategoryHours(requestBody)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, hours, s.logger)
+}
+
+// validateOpeningSchedule checks the hours of a category or location, whose
+// holidays are checked against the calendar of timezone.
+func validateOpeningSchedule(timezone string, openingSchedule types.OpeningSchedule) error {
+	for _, h := range openingSchedule.Weekly {
+		if h.Weekday < time.Sunday || h.Weekday > time.Saturday {
+			return apiError{"'weekday' must be between 0 (Sunday) and 6 (Saturday)"}
+		}
+	}
+
+	if err := schedule.Validate(timezone, openingSchedule); err != nil {
+		return apiError{err.Error()}
+	}
+	return nil
+}
+
+// putCategoryIntake manually stops or resumes the issuing of tickets for a
+// category.
+func (s *APIServer) putCategoryIntake(w http.ResponseWriter, r *http.Request) error {
+	var requestBody categoryIntakeRequest
+
+	categoryID, err := s.categoryIDFromVars(w, r)
+	if err != nil || categoryID == 0 {
+		return err
+	}
+
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
+	}
+
+	settings, err := s.store(r).SetIntakeClosed(categoryID, requestBody.Closed)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, settings, s.logger)
+}
+
+// checkIntake returns an error wrapping types.ErrIntakeClosed when a category
+// is not issuing tickets: intake has been closed by staff, it is outside the
+// opening hours, or a new ticket would not be served before closing time.
+func (s *APIServer) checkIntake(ctx context.Context, categoryID int) error {
+	settings, err := s.storeFor(ctx).GetCategorySettings(categoryID)
+	if err != nil {
+		return err
+	}
+
+	if settings.IntakeClosed {
+		return fmt.Errorf("%w: intake has been closed", types.ErrIntakeClosed)
+	}
+
+	hours, err := s.storeFor(ctx).GetCategoryHours(categoryID)
+	if err != nil {
+		return err
+	}
+
+	// A category without hours of its own follows those of its location.
+	timezone, openingSchedule := settings.Timezone, hours.OpeningSchedule
+	if openingSchedule.IsEmpty() {
+		location := locationFromContext(ctx)
+
+		locationHours, err := s.storage.GetLocationHours(location.ID)
+		if err != nil {
+			return err
+		}
+		timezone, openingSchedule = location.Timezone, locationHours.OpeningSchedule
+	}
+
+	categorySchedule, err := schedule.New(timezone, openingSchedule)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	closesAt, open := categorySchedule.ClosesAt(now)
+	if !open {
+		return fmt.Errorf("%w: outside of opening hours", types.ErrIntakeClosed)
+	}
+
+	if closesAt.IsZero() {
+		return nil
+	}
+
+	waiting, err := s.storeFor(ctx).CountWaiting(categoryID)
+	if err != nil {
+		return err
+	}
+
+	wait, _, err := s.estimateWait(ctx, categoryID, waiting)
+	if err != nil {
+		return err
+	}
+
+	if wait != nil && now.Add(time.Duration(*wait)*time.Second).After(closesAt) {
+		return fmt.Errorf("%w: the queue closes before a new ticket would be served", types.ErrIntakeClosed)
+	}
+
+	return nil
+}
+
+func (s *APIServer) handleCategoryHours(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.getCategoryHours(w, r)
+	case http.MethodPut:
+		return s.putCategoryHours(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
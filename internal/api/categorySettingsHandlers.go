@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+func (s *APIServer) addCategorySettingsRoutes(router *mux.Router) {
+	router.HandleFunc("/{id}/settings", makeHTTPHandler(s.handleCategorySettings, []string{http.MethodGet, http.MethodPut}, s.logger))
+}
+
+func (s *APIServer) getCategorySettings(w http.ResponseWriter, r *http.Request) error {
+	categoryID, err := s.categoryIDFromVars(w, r)
+	if err != nil || categoryID == 0 {
+		return err
+	}
+
+	settings, err := s.store(r).GetCategorySettings(categoryID)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, settings, s.logger)
+}
+
+func (s *APIServer) putCategorySettings(w http.ResponseWriter, r *http.Request) error {
+	categoryID, err := s.categoryIDFromVars(w, r)
+	if err != nil || categoryID == 0 {
+		return err
+	}
+
+	var requestBody types.CategorySettings
+
+	if err = readJSON(r, &requestBody); err != nil {
+		return writeJSON(w, bodyErrorStatus(err), err, s.logger)
+	}
+
+	if requestBody.Timezone == "" {
+		requestBody.Timezone = locationFromRequest(r).Timezone
+	}
+
+	if errs := validateCategorySettings(requestBody); len(errs) > 0 {
+		return writeJSON(w, http.StatusBadRequest, errs, s.logger)
+	}
+
+	// Intake is opened and closed through its own endpoint, so that changing
+	// a timeout does not reopen a queue staff have closed.
+	requestBody.CategoryID, requestBody.IntakeClosed = categoryID, false
+
+	settings, err := s.store(r).UpdateCategorySettings(requestBody)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, settings, s.logger)
+}
+
+func validateCategorySettings(settings types.CategorySettings) []error {
+	errs := validateStruct(settings, nil)
+	if settings.MaxTicketsPerClient > 0 && settings.ClientWindowSeconds <= 0 {
+		errs = append(errs, apiError{"'client_window_seconds' must be positive when 'max_tickets_per_client' is set"})
+	}
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		errs = append(errs, apiError{"'timezone' must be an IANA timezone name"})
+	}
+	return errs
+}
+
+// categoryIDFromVars parses the category ID from the route and checks that the
+// category exists. A zero ID is returned when a response has already been
+// written.
+func (s *APIServer) categoryIDFromVars(w http.ResponseWriter, r *http.Request) (int, error) {
+	idStr := mux.Vars(r)["id"]
+	categoryID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+		return 0, writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+
+	if _, err := s.store(r).GetCategory(categoryID); err != nil {
+		if err == types.ErrnotFound {
+			return 0, writeJSON(w, http.StatusNotFound, apiError{"category not found"}, s.logger)
+		}
+		return 0, writeJSON(w, http.StatusBadRequest, apiError{badValidationString("category")}, s.logger)
+	}
+
+	return categoryID, nil
+}
+
+func (s *APIServer) handleCategorySettings(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.getCategorySettings(w, r)
+	case http.MethodPut:
+		return s.putCategorySettings(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/storage"
+	"github.com/khaleelsyed/codaVirtuale/pkg/client"
+)
+
+// recordingHandler records the requests passed to the API, and lets a test
+// fail them before, or after, the API has handled them.
+type recordingHandler struct {
+	next http.Handler
+
+	mu       sync.Mutex
+	requests []*http.Request
+	// intercept, when set, is called with each request and the number made
+	// so far, including it. It returns whether it wrote the response itself.
+	intercept func(w http.ResponseWriter, r *http.Request, n int) bool
+}
+
+func (h *recordingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requests = append(h.requests, r)
+	n, intercept := len(h.requests), h.intercept
+	h.mu.Unlock()
+
+	if intercept != nil && intercept(w, r, n) {
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+func (h *recordingHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.requests)
+}
+
+func (h *recordingHandler) headers(name string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	values := make([]string, len(h.requests))
+	for i, r := range h.requests {
+		values[i] = r.Header.Get(name)
+	}
+	return values
+}
+
+func (h *recordingHandler) reset(intercept func(w http.ResponseWriter, r *http.Request, n int) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.requests, h.intercept = nil, intercept
+}
+
+// newTestClient returns a client of a test server's REST API, and the handler
+// its requests go through.
+func newTestClient(t *testing.T) (*client.Client, *recordingHandler, *storage.MockStorage) {
+	t.Helper()
+
+	s, store := newTestServer(t)
+	handler := &recordingHandler{next: s.newRouter()}
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := client.New(server.URL)
+	c.RetryWait = time.Millisecond
+	return c, handler, store
+}
+
+func TestClientErrorEnvelope(t *testing.T) {
+	c, _, _ := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := c.GetDesk(ctx, 99)
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("GetDesk() = %v, want a not found error", err)
+	}
+	if !slices.Equal(apiErr.Messages, []string{"not found"}) {
+		t.Errorf("messages = %q, want the single message of the response", apiErr.Messages)
+	}
+
+	_, err = c.CreateDesk(ctx, "", 99)
+
+	if !errors.As(err, &apiErr) || !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("CreateDesk() = %v, want a bad request error", err)
+	}
+	if len(apiErr.Messages) != 2 {
+		t.Errorf("messages = %q, want the missing label and the unknown category", apiErr.Messages)
+	}
+}
+
+func TestClientIfMatch(t *testing.T) {
+	c, handler, _ := newTestClient(t)
+	ctx := context.Background()
+
+	category, err := c.CreateCategory(ctx, "payments")
+	if err != nil {
+		t.Fatalf("CreateCategory() = %v", err)
+	}
+	desk, err := c.CreateDesk(ctx, "Desk 1", category.ID)
+	if err != nil {
+		t.Fatalf("CreateDesk() = %v", err)
+	}
+
+	handler.reset(nil)
+
+	updated, err := c.UpdateDesk(ctx, desk.ID, client.DeskUpdate{Label: "Desk A"}, desk.Version)
+	if err != nil {
+		t.Fatalf("UpdateDesk() at the current version = %v", err)
+	}
+	if updated.Label != "Desk A" || updated.CategoryID != category.ID || updated.Version != desk.Version+1 {
+		t.Errorf("UpdateDesk() = %+v", updated)
+	}
+
+	if _, err = c.UpdateDesk(ctx, desk.ID, client.DeskUpdate{Label: "Desk B"}, desk.Version); !errors.Is(err, client.ErrPreconditionFailed) {
+		t.Errorf("UpdateDesk() at a stale version = %v, want a failed precondition", err)
+	}
+	if err = c.DeleteDesk(ctx, desk.ID, desk.Version); !errors.Is(err, client.ErrPreconditionFailed) {
+		t.Errorf("DeleteDesk() at a stale version = %v, want a failed precondition", err)
+	}
+	if _, err = c.UpdateDesk(ctx, desk.ID, client.DeskUpdate{Label: "Desk C"}, 0); err != nil {
+		t.Errorf("UpdateDesk() whatever the version = %v", err)
+	}
+
+	want := []string{`"1"`, `"1"`, `"1"`, "*"}
+	if got := handler.headers("If-Match"); !slices.Equal(got, want) {
+		t.Errorf("If-Match headers = %q, want %q", got, want)
+	}
+}
+
+func TestClientRetriesAfterRetryAfter(t *testing.T) {
+	c, handler, _ := newTestClient(t)
+	ctx := context.Background()
+
+	handler.reset(func(w http.ResponseWriter, r *http.Request, n int) bool {
+		if n > 1 {
+			return false
+		}
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+
+	started := time.Now()
+	if _, err := c.ListCategories(ctx); err != nil {
+		t.Fatalf("ListCategories() = %v", err)
+	}
+
+	if requests := handler.count(); requests != 2 {
+		t.Errorf("made %d requests, want 2", requests)
+	}
+	if waited := time.Since(started); waited < time.Second {
+		t.Errorf("retried after %v, want the second asked for by Retry-After", waited)
+	}
+}
+
+func TestClientGivesUpRetrying(t *testing.T) {
+	c, handler, _ := newTestClient(t)
+	ctx := context.Background()
+
+	handler.reset(func(w http.ResponseWriter, r *http.Request, n int) bool {
+		w.WriteHeader(http.StatusBadGateway)
+		return true
+	})
+
+	_, err := c.ListCategories(ctx)
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("ListCategories() = %v, want the last bad gateway", err)
+	}
+	if requests := handler.count(); requests != client.DefaultMaxRetries+1 {
+		t.Errorf("made %d requests, want %d", requests, client.DefaultMaxRetries+1)
+	}
+}
+
+func TestClientDoesNotRetryConflicts(t *testing.T) {
+	c, handler, store := newTestClient(t)
+	ctx := context.Background()
+
+	category := createCategory(t, store, "payments")
+	desk, err := store.ForLocation(1).CreateDesk("Desk 1", category.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.reset(nil)
+
+	if _, err = c.CallNext(ctx, desk.ID); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("CallNext() at a closed desk = %v, want a conflict", err)
+	}
+	if requests := handler.count(); requests != 1 {
+		t.Errorf("made %d requests, want 1", requests)
+	}
+}
+
+func TestClientRetryIsReplayed(t *testing.T) {
+	c, handler, store := newTestClient(t)
+	ctx := context.Background()
+
+	category := createCategory(t, store, "payments")
+
+	// The first attempt creates the ticket, but its response is lost on the
+	// way back to the client.
+	handler.reset(func(w http.ResponseWriter, r *http.Request, n int) bool {
+		if n > 1 {
+			return false
+		}
+		handler.next.ServeHTTP(httptest.NewRecorder(), r)
+		w.WriteHeader(http.StatusBadGateway)
+		return true
+	})
+
+	ticket, err := c.CreateTicket(ctx, category.ID, nil)
+	if err != nil {
+		t.Fatalf("CreateTicket() = %v", err)
+	}
+
+	keys := handler.headers("Idempotency-Key")
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("Idempotency-Key headers = %q, want the same key for both attempts", keys)
+	}
+
+	queue, err := store.SeeQueue(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue) != 1 || queue[0].ID != ticket.ID {
+		t.Errorf("queue = %+v, want only ticket %d", queue, ticket.ID)
+	}
+}
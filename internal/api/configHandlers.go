@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+func (s *APIServer) addConfigRoutes(router *mux.Router) {
+	router.HandleFunc("/config", makeHTTPHandler(s.handleConfig, []string{http.MethodGet, http.MethodPut}, s.logger))
+}
+
+// getConfig exports the categories and desks of the location as YAML, in the
+// form putConfig applies.
+func (s *APIServer) getConfig(w http.ResponseWriter, r *http.Request) error {
+	config, err := s.store(r).ExportConfig()
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", locationFromRequest(r).Slug+".yaml"))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(config); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// putConfig applies a YAML config to the location, creating and updating its
+// categories and desks to match. Those the config leaves out are deleted with
+// 'prune=true', and 'dry_run=true' lists the changes without making them.
+// JSON is accepted too, being a subset of YAML.
+func (s *APIServer) putConfig(w http.ResponseWriter, r *http.Request) error {
+	var errs []error
+
+	flag := func(name string) bool {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			return false
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, apiError{fmt.Sprintf("'%s' must be true or false", name)})
+		}
+		return b
+	}
+
+	prune, dryRun := flag("prune"), flag("dry_run")
+	if len(errs) > 0 {
+		return writeJSON(w, http.StatusBadRequest, errs, s.logger)
+	}
+
+	var config types.LocationConfig
+
+	decoder := yaml.NewDecoder(r.Body)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		if bodyReadError(err) == errBodyTooLarge {
+			return writeJSON(w, http.StatusRequestEntityTooLarge, errBodyTooLarge, s.logger)
+		}
+		message := strings.ReplaceAll(strings.TrimPrefix(err.Error(), "yaml: "), "\n  ", " ")
+		return writeJSON(w, http.StatusBadRequest, apiError{fmt.Sprintf("bad config: %s", message)}, s.logger)
+	}
+
+	if errs = validateLocationConfig(config, locationFromRequest(r).Timezone); len(errs) > 0 {
+		return writeJSON(w, http.StatusBadRequest, errs, s.logger)
+	}
+
+	changes, err := s.store(r).ApplyConfig(config, prune, dryRun)
+	if err != nil {
+		if errors.Is(err, types.ErrInUse) {
+			return writeJSON(w, http.StatusConflict, apiError{err.Error()}, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, types.ConfigPlan{DryRun: dryRun, Changes: changes}, s.logger)
+}
+
+// validateLocationConfig checks a config as the category, desk and hours
+// handlers would check each of its parts, and that it names every category
+// and desk once. Hours are checked against timezone, that of the location.
+func validateLocationConfig(config types.LocationConfig, timezone string) []error {
+	var errs []error
+
+	if config.Hours != nil {
+		if err := validateOpeningSchedule(timezone, *config.Hours); err != nil {
+			errs = append(errs, apiError{fmt.Sprintf("hours: %s", err)})
+		}
+	}
+
+	categories := map[string]bool{}
+	for i, category := range config.Categories {
+		name := strings.TrimSpace(category.Name)
+		switch {
+		case name == "" || utf8.RuneCountInString(category.Name) > 50:
+			errs = append(errs, apiError{fmt.Sprintf("categories[%d]: 'name' must be between 1 and 50 characters", i)})
+			continue
+		case categories[category.Name]:
+			errs = append(errs, apiError{fmt.Sprintf("category '%s' is given more than once", category.Name)})
+		}
+		categories[category.Name] = true
+
+		if category.Settings != nil {
+			for _, err := range validateCategorySettings(*category.Settings) {
+				errs = append(errs, apiError{fmt.Sprintf("category '%s': %s", category.Name, err)})
+			}
+		}
+		if category.Hours != nil {
+			categoryTimezone := timezone
+			if category.Settings != nil && category.Settings.Timezone != "" {
+				categoryTimezone = category.Settings.Timezone
+			}
+			if err := validateOpeningSchedule(categoryTimezone, *category.Hours); err != nil {
+				errs = append(errs, apiError{fmt.Sprintf("category '%s': hours: %s", category.Name, err)})
+			}
+		}
+	}
+
+	desks := map[string]bool{}
+	for i, desk := range config.Desks {
+		switch {
+		case strings.TrimSpace(desk.Label) == "" || utf8.RuneCountInString(desk.Label) > 50:
+			errs = append(errs, apiError{fmt.Sprintf("desks[%d]: 'label' must be between 1 and 50 characters", i)})
+			continue
+		case desks[desk.Label]:
+			errs = append(errs, apiError{fmt.Sprintf("desk '%s' is given more than once", desk.Label)})
+		case !categories[desk.Category]:
+			errs = append(errs, apiError{fmt.Sprintf("desk '%s': 'category' must name one of the config's categories", desk.Label)})
+		}
+		desks[desk.Label] = true
+	}
+
+	return errs
+}
+
+func (s *APIServer) handleConfig(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.getConfig(w, r)
+	case http.MethodPut:
+		return s.putConfig(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
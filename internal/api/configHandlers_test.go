@@ -0,0 +1,29 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+func TestValidateLocationConfigCountsCharacters(t *testing.T) {
+	// 50 characters, but 100 bytes.
+	name := strings.Repeat("é", 50)
+
+	config := types.LocationConfig{
+		Categories: []types.CategoryConfig{{Name: name}},
+		Desks:      []types.DeskConfig{{Label: name, Category: name}},
+	}
+	if errs := validateLocationConfig(config, "UTC"); len(errs) != 0 {
+		t.Errorf("validateLocationConfig() = %v, want names of 50 characters accepted", errs)
+	}
+
+	config = types.LocationConfig{
+		Categories: []types.CategoryConfig{{Name: name + "é"}},
+		Desks:      []types.DeskConfig{{Label: name + "é", Category: name}},
+	}
+	if errs := validateLocationConfig(config, "UTC"); len(errs) != 2 {
+		t.Errorf("validateLocationConfig() = %v, want the name and label of 51 characters refused", errs)
+	}
+}
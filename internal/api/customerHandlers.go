@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// addCustomerRoutes registers the routes a customer reaches through their
+// ticket's SubURL.
+func (s *APIServer) addCustomerRoutes(router *mux.Router) {
+	router.HandleFunc("/{sub_url}", makeHTTPHandler(s.getCustomerTicket, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/{sub_url}/cancel", makeHTTPHandler(s.cancelCustomerTicket, []string{http.MethodPost}, s.logger))
+	router.HandleFunc("/{sub_url}/snooze", makeHTTPHandler(s.snoozeCustomerTicket, []string{http.MethodPost}, s.logger))
+	s.addPrintRoutes(router)
+}
+
+// snoozeRequest pushes a ticket back by either a number of places or a number
+// of minutes.
+type snoozeRequest struct {
+	Places  int `json:"places,omitempty" validate:"omitempty,min=1,max=20"`
+	Minutes int `json:"minutes,omitempty" validate:"omitempty,min=1,max=60"`
+}
+
+func (rB snoozeRequest) validate() []error {
+	if (rB.Places == 0) == (rB.Minutes == 0) {
+		return []error{apiError{"exactly one of 'places' or 'minutes' is required"}}
+	}
+	return nil
+}
+
+func (s *APIServer) getCustomerTicket(w http.ResponseWriter, r *http.Request) error {
+	ticket, err := s.store(r).GetTicketBySubURL(mux.Vars(r)["sub_url"])
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	response, err := s.newTicketResponse(r.Context(), ticket)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, response, s.logger)
+}
+
+func (s *APIServer) cancelCustomerTicket(w http.ResponseWriter, r *http.Request) error {
+	ticket, err := s.store(r).GetTicketBySubURL(mux.Vars(r)["sub_url"])
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	ticket, err = s.store(r).CancelTicket(ticket.ID)
+	if err != nil {
+		return writeTicketActionError(w, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, ticket, s.logger)
+}
+
+func (s *APIServer) snoozeCustomerTicket(w http.ResponseWriter, r *http.Request) error {
+	var requestBody snoozeRequest
+
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
+	}
+
+	ticket, err := s.store(r).GetTicketBySubURL(mux.Vars(r)["sub_url"])
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	ticket, err = s.store(r).SnoozeTicket(ticket.ID, types.TicketSnooze{Places: requestBody.Places, Minutes: requestBody.Minutes}, s.snoozeLimit)
+	if err != nil {
+		return writeTicketActionError(w, err, s.logger)
+	}
+
+	response, err := s.newTicketResponse(r.Context(), ticket)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, response, s.logger)
+}
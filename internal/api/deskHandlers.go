@@ -1,7 +1,6 @@
 package api
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -13,8 +12,27 @@ import (
 )
 
 func (s *APIServer) addDeskRoutes(router *mux.Router) {
-	router.HandleFunc("/{id}", makeHTTPHandler(s.handleDesk, []string{http.MethodGet, http.MethodPut, http.MethodDelete}, s.logger))
-	router.HandleFunc("", makeHTTPHandler(s.createDesk, []string{http.MethodPost}, s.logger))
+	router.HandleFunc("/{id}", makeHTTPHandler(s.handleDesk, []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete}, s.logger))
+	router.HandleFunc("", makeHTTPHandler(s.handleDesks, []string{http.MethodGet, http.MethodPost}, s.logger))
+}
+
+// deskRequest is the body of both creating and replacing a desk.
+type deskRequest struct {
+	Label      string `json:"label" validate:"required,max=50"`
+	CategoryID int    `json:"category_id" validate:"required,exists=category"`
+}
+
+// deskPatch is a merge patch of a desk, changing the members it includes.
+type deskPatch struct {
+	Label      *string `json:"label" validate:"notblank,max=50"`
+	CategoryID *int    `json:"category_id" validate:"exists=category"`
+}
+
+func (p deskPatch) validate() []error {
+	if p.Label == nil && p.CategoryID == nil {
+		return []error{apiError{"the patch must change 'label' or 'category_id'"}}
+	}
+	return nil
 }
 
 func (s *APIServer) getDesk(w http.ResponseWriter, r *http.Request) error {
@@ -25,7 +43,7 @@ func (s *APIServer) getDesk(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
-	desk, err := s.storage.GetDesk(deskID)
+	desk, err := s.store(r).GetDesk(deskID)
 	if err != nil {
 		if err == types.ErrnotFound {
 			return writeJSON(w, http.StatusNotFound, err, s.logger)
@@ -33,18 +51,16 @@ func (s *APIServer) getDesk(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, badValidationString("desk"), s.logger)
 	}
 
+	setETag(w, desk.Version)
 	return writeJSON(w, http.StatusOK, desk, s.logger)
 }
 
+// putDesk replaces the label and category of a desk, both of which must be
+// given.
 func (s *APIServer) putDesk(w http.ResponseWriter, r *http.Request) error {
 	var err error
 
-	type DeskUpdate struct {
-		CategoryID int    `json:"category_id"`
-		Label      string `json:"label"`
-	}
-
-	var requestBody DeskUpdate
+	var requestBody deskRequest
 
 	idStr := mux.Vars(r)["id"]
 	deskID, err := strconv.Atoi(idStr)
@@ -54,54 +70,64 @@ func (s *APIServer) putDesk(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		return writeJSON(w, http.StatusBadRequest, errBadRequestBody, s.logger)
+	version, err := ifMatch(r)
+	if err != nil {
+		return writeJSON(w, ifMatchStatus(err), err, s.logger)
 	}
 
-	validate := func(rB DeskUpdate) (DeskUpdate, error) {
-		if rB.Label == "" && rB.CategoryID == 0 {
-			s.logger.Debugw("deskUpdate check", "label", rB.Label, "CategoryID", rB.CategoryID, "id", deskID)
-			return DeskUpdate{}, apiError{"Request must contain either 'category_id' or a 'label'"}
-		}
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
+	}
 
-		currentRow, err := s.storage.GetDesk(deskID)
-		if err != nil {
-			return DeskUpdate{}, err
-		}
+	return s.updateDesk(w, r, deskID, types.DeskUpdate{CategoryID: requestBody.CategoryID, Label: requestBody.Label}, version)
+}
 
-		if rB.CategoryID == 0 {
-			rB.CategoryID = currentRow.CategoryID
-		} else if rB.Label == "" {
-			rB.Label = currentRow.Label
-
-			if _, err = s.storage.GetCategory(rB.CategoryID); err != nil {
-				if err == types.ErrnotFound {
-					return DeskUpdate{}, errors.New("category not found")
-				}
-				return DeskUpdate{}, err
-			}
-		}
-		return rB, nil
+// patchDesk changes the label or category of a desk, keeping whichever of
+// them the patch leaves out.
+func (s *APIServer) patchDesk(w http.ResponseWriter, r *http.Request) error {
+	var err error
+
+	var patch deskPatch
+
+	idStr := mux.Vars(r)["id"]
+	deskID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+
+		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
-	requestBody, err = validate(requestBody)
+	version, err := ifMatch(r)
 	if err != nil {
-		if err == types.ErrnotFound || err.Error() == "category not found" {
-			return writeJSON(w, http.StatusNotFound, err, s.logger)
-		}
+		return writeJSON(w, ifMatchStatus(err), err, s.logger)
+	}
+
+	if ok, err := s.readPatch(w, r, &patch); !ok {
+		return err
+	}
 
-		s.logger.Tracew("failed to validate desk", "id", deskID, "error", err)
-		return writeJSON(w, http.StatusBadRequest, err, s.logger)
+	var update types.DeskUpdate
+	if patch.Label != nil {
+		update.Label = *patch.Label
 	}
+	if patch.CategoryID != nil {
+		update.CategoryID = *patch.CategoryID
+	}
+
+	return s.updateDesk(w, r, deskID, update, version)
+}
 
-	desk, err := s.storage.UpdateDesk(deskID, struct {
-		CategoryID int
-		Label      string
-	}(requestBody))
+func (s *APIServer) updateDesk(w http.ResponseWriter, r *http.Request, deskID int, update types.DeskUpdate, version int) error {
+	desk, err := s.store(r).UpdateDesk(deskID, update, version)
 	if err != nil {
+		if status, err := versionedWriteStatus(err); status != 0 {
+			return writeJSON(w, status, err, s.logger)
+		}
+		s.logger.Tracew("failed to update desk", "id", deskID, "error", err)
 		return writeJSON(w, http.StatusBadRequest, errors.New("failed to update desk"), s.logger)
 	}
 
+	setETag(w, desk.Version)
 	return writeJSON(w, http.StatusOK, desk, s.logger)
 }
 
@@ -114,7 +140,15 @@ func (s *APIServer) deleteDesk(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
-	if err := s.storage.DeleteDesk(deskID); err != nil {
+	version, err := ifMatch(r)
+	if err != nil {
+		return writeJSON(w, ifMatchStatus(err), err, s.logger)
+	}
+
+	if err := s.store(r).DeleteDesk(deskID, version); err != nil {
+		if status, err := versionedWriteStatus(err); status != 0 {
+			return writeJSON(w, status, err, s.logger)
+		}
 		errBody := badValidationString("desk")
 
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
@@ -126,50 +160,54 @@ func (s *APIServer) deleteDesk(w http.ResponseWriter, r *http.Request) error {
 func (s *APIServer) createDesk(w http.ResponseWriter, r *http.Request) error {
 	var err error
 
-	type DeskCreate struct {
-		Label      string `json:"label"`
-		CategoryID int    `json:"category_id"`
-	}
-
-	var requestBody DeskCreate
+	var requestBody deskRequest
 
-	if err = json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		return writeJSON(w, http.StatusBadRequest, errBadRequestBody, s.logger)
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
 	}
 
-	validate := func(rB DeskCreate) []error {
-		var errs []error
-		if rB.Label == "" {
-			errs = append(errs, apiError{"label is required"})
-		}
-		if rB.CategoryID == 0 {
-			errs = append(errs, apiError{"category_id is required"})
-		}
-		return errs
-	}
-
-	if errs := validate(requestBody); len(errs) > 0 {
-		return writeJSON(w, http.StatusBadRequest, errs, s.logger)
-	}
-
-	desk, err := s.storage.CreateDesk(requestBody.Label, requestBody.CategoryID)
+	desk, err := s.store(r).CreateDesk(requestBody.Label, requestBody.CategoryID)
 	if err != nil {
-		if strings.Contains(err.Error(), pqForeignKeyConstraintViolation) {
+		if err == types.ErrnotFound || strings.Contains(err.Error(), pqForeignKeyConstraintViolation) {
 			return writeJSON(w, http.StatusInternalServerError, errors.New("category_id does not exist"), s.logger)
 		}
 		errBody := "error creating desk"
 		return writeJSON(w, http.StatusInternalServerError, errors.New(errBody), s.logger)
 	}
 
+	setETag(w, desk.Version)
 	return writeJSON(w, http.StatusCreated, desk, s.logger)
 }
 
+func (s *APIServer) listDesks(w http.ResponseWriter, r *http.Request) error {
+	desks, err := s.store(r).ListDesks()
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, desks, s.logger)
+}
+
+func (s *APIServer) handleDesks(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.listDesks(w, r)
+	case http.MethodPost:
+		return s.createDesk(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
+
 func (s *APIServer) handleDesk(w http.ResponseWriter, r *http.Request) error {
 	switch r.Method {
 	case http.MethodGet:
 		return s.getDesk(w, r)
 	case http.MethodPut:
 		return s.putDesk(w, r)
+	case http.MethodPatch:
+		return s.patchDesk(w, r)
 	case http.MethodDelete:
 		return s.deleteDesk(w, r)
 	default:
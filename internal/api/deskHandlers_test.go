@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPatchDesk(t *testing.T) {
+	s, store := newTestServer(t)
+	router := s.newRouter()
+
+	category := createCategory(t, store, "payments")
+	desk, err := store.ForLocation(1).CreateDesk("Desk 1", category.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPatch, "/desk/"+strconv.Itoa(desk.ID), strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set(ifMatchHeader, "*")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"plain JSON", "application/json", `{"label":"Desk A"}`, http.StatusUnsupportedMediaType},
+		{"no content type", "", `{"label":"Desk A"}`, http.StatusUnsupportedMediaType},
+		{"empty label", mergePatchContentType, `{"label":""}`, http.StatusBadRequest},
+		{"blank label", mergePatchContentType, `{"label":"   "}`, http.StatusBadRequest},
+		{"label", mergePatchContentType + "; charset=utf-8", `{"label":"Desk A"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := patch(tt.contentType, tt.body); w.Code != tt.want {
+				t.Errorf("PATCH got %d %q, want %d", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+func (s *APIServer) addDeskSessionRoutes(router *mux.Router) {
+	router.HandleFunc("/desks/{id}/session", makeHTTPHandler(s.handleDeskSession, []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}, s.logger))
+}
+
+type deskSessionStateRequest struct {
+	State types.DeskSessionState `json:"state" validate:"required,oneof=open|paused"`
+}
+
+func (s *APIServer) getDeskSession(w http.ResponseWriter, r *http.Request) error {
+	deskID, err := s.deskIDFromVars(w, r)
+	if err != nil || deskID == 0 {
+		return err
+	}
+
+	session, err := s.store(r).GetDeskSession(deskID)
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusOK, types.DeskSession{DeskID: deskID, State: types.DeskClosed}, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, session, s.logger)
+}
+
+func (s *APIServer) openDeskSession(w http.ResponseWriter, r *http.Request) error {
+	deskID, err := s.deskIDFromVars(w, r)
+	if err != nil || deskID == 0 {
+		return err
+	}
+
+	staff := staffFromRequest(r)
+	if staff == "" {
+		return writeJSON(w, http.StatusBadRequest, apiError{"X-Staff-ID header is required to open a desk"}, s.logger)
+	}
+
+	// The token authenticates the desk's WebSocket connection, and is only
+	// shown in this response.
+	b := make([]byte, 32)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	session, err := s.store(r).OpenDeskSession(deskID, staff, token)
+	if err != nil {
+		if err == types.ErrDeskSessionOpen {
+			return writeJSON(w, http.StatusConflict, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	session.Token = token
+
+	return writeJSON(w, http.StatusCreated, session, s.logger)
+}
+
+func (s *APIServer) putDeskSession(w http.ResponseWriter, r *http.Request) error {
+	var requestBody deskSessionStateRequest
+
+	deskID, err := s.deskIDFromVars(w, r)
+	if err != nil || deskID == 0 {
+		return err
+	}
+
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
+	}
+
+	session, err := s.store(r).SetDeskSessionState(deskID, requestBody.State)
+	if err != nil {
+		if err == types.ErrDeskClosed {
+			return writeJSON(w, http.StatusConflict, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, session, s.logger)
+}
+
+func (s *APIServer) closeDeskSession(w http.ResponseWriter, r *http.Request) error {
+	deskID, err := s.deskIDFromVars(w, r)
+	if err != nil || deskID == 0 {
+		return err
+	}
+
+	session, err := s.store(r).CloseDeskSession(deskID)
+	if err != nil {
+		if err == types.ErrDeskClosed {
+			return writeJSON(w, http.StatusConflict, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, session, s.logger)
+}
+
+// deskIDFromVars parses the desk ID from the route and checks that the desk
+// exists. A zero ID is returned when a response has already been written.
+func (s *APIServer) deskIDFromVars(w http.ResponseWriter, r *http.Request) (int, error) {
+	idStr := mux.Vars(r)["id"]
+	deskID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+		return 0, writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+
+	if _, err := s.store(r).GetDesk(deskID); err != nil {
+		if err == types.ErrnotFound {
+			return 0, writeJSON(w, http.StatusNotFound, errors.New("desk not found"), s.logger)
+		}
+		return 0, writeJSON(w, http.StatusBadRequest, errors.New(badValidationString("desk")), s.logger)
+	}
+
+	return deskID, nil
+}
+
+func (s *APIServer) handleDeskSession(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.getDeskSession(w, r)
+	case http.MethodPost:
+		return s.openDeskSession(w, r)
+	case http.MethodPut:
+		return s.putDeskSession(w, r)
+	case http.MethodDelete:
+		return s.closeDeskSession(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const (
+	// deskSocketPingInterval is how often the server pings a desk's socket
+	// and checks that its session is still open.
+	deskSocketPingInterval = 15 * time.Second
+	// deskSocketPongWait is how long a desk's socket may stay silent before
+	// it is assumed to be gone.
+	deskSocketPongWait   = 2 * deskSocketPingInterval
+	deskSocketWriteWait  = 10 * time.Second
+	deskSocketMaxMessage = 4096
+)
+
+// Commands a desk can send over its socket.
+const (
+	deskCommandCallNext = "call_next"
+	deskCommandRecall   = "recall"
+	deskCommandServe    = "serve"
+	deskCommandComplete = "complete"
+	deskCommandTransfer = "transfer"
+	deskCommandPing     = "ping"
+)
+
+// Messages the server sends to a desk.
+const (
+	deskMessageHello  = "hello"
+	deskMessageEvent  = "event"
+	deskMessageResync = "resync"
+	deskMessageResult = "result"
+	deskMessageError  = "error"
+	deskMessagePong   = "pong"
+)
+
+var deskSocketUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// deskCommand is a message from a desk. ID is chosen by the desk and echoed in
+// the reply so that it can match replies to the commands it sent.
+type deskCommand struct {
+	ID         string `json:"id,omitempty"`
+	Type       string `json:"type"`
+	TicketID   int    `json:"ticket_id,omitempty"`
+	CategoryID int    `json:"category_id,omitempty"`
+}
+
+// deskMessage is a message to a desk. Replies to commands carry the HTTP
+// status the same action would have been given by the REST API.
+type deskMessage struct {
+	Type    string             `json:"type"`
+	ID      string             `json:"id,omitempty"`
+	Session *types.DeskSession `json:"session,omitempty"`
+	Event   *types.TicketEvent `json:"event,omitempty"`
+	Ticket  *types.Ticket      `json:"ticket,omitempty"`
+	Status  int                `json:"status,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+func (s *APIServer) addDeskSocketRoutes(router *mux.Router) {
+	router.HandleFunc("/ws", makeHTTPHandler(s.serveDeskSocket, []string{http.MethodGet}, s.logger))
+}
+
+// deskSessionToken reads the token given when the desk session was opened,
+// from the Authorization header or, for browsers which cannot set headers on
+// a WebSocket, the 'token' query parameter.
+func deskSessionToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
+
+// serveDeskSocket connects a signed in desk to a WebSocket on which it is sent
+// the events of its category's queue and can send commands acting on its
+// tickets. A desk reconnecting with 'last_event_id' is first sent the events
+// it missed.
+func (s *APIServer) serveDeskSocket(w http.ResponseWriter, r *http.Request) error {
+	store := s.store(r)
+
+	token := deskSessionToken(r)
+	if token == "" {
+		return writeJSON(w, http.StatusUnauthorized, apiError{"a desk session token is required"}, s.logger)
+	}
+
+	session, err := store.GetDeskSessionByToken(token)
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusUnauthorized, apiError{"the desk session is not open"}, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	desk, err := store.GetDesk(session.DeskID)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	lastEventID := 0
+	if lastEventIDStr := r.URL.Query().Get("last_event_id"); lastEventIDStr != "" {
+		lastEventID, err = strconv.Atoi(lastEventIDStr)
+		if err != nil || lastEventID < 0 {
+			return writeJSON(w, http.StatusBadRequest, apiError{"'last_event_id' must be an event ID"}, s.logger)
+		}
+	}
+
+	conn, err := deskSocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already responded.
+		s.logger.Tracew("failed to upgrade desk socket", "desk_id", desk.ID, "error", err)
+		return nil
+	}
+	defer conn.Close()
+
+	// Events are buffered from before the missed ones are looked up, so that
+	// none are lost in between.
+	subscription := s.events.Subscribe([]int{desk.CategoryID})
+	defer subscription.Close()
+
+	socket := &deskSocket{
+		server:  s,
+		store:   store,
+		conn:    conn,
+		token:   token,
+		session: session,
+		desk:    desk,
+		replies: make(chan deskMessage, 16),
+	}
+
+	if err = socket.write(deskMessage{Type: deskMessageHello, Session: &session}); err != nil {
+		return nil
+	}
+
+	var replayed map[int]bool
+	if lastEventID > 0 {
+		if replayed, err = socket.resume(lastEventID); err != nil {
+			return nil
+		}
+	}
+
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	defer close(quit)
+	go socket.readCommands(done, quit)
+
+	ping := time.NewTicker(deskSocketPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case reply := <-socket.replies:
+			if err = socket.write(reply); err != nil {
+				return nil
+			}
+		case event, open := <-subscription.C:
+			if !open {
+				return nil
+			}
+			// Events published while the missed ones were replayed may be
+			// among them. Events are not published in ID order, so only
+			// those are skipped.
+			if replayed[event.ID] {
+				delete(replayed, event.ID)
+				continue
+			}
+
+			if err = socket.write(deskMessage{Type: deskMessageEvent, Event: &event}); err != nil {
+				return nil
+			}
+		case <-ping.C:
+			if !socket.sessionOpen() {
+				socket.close(websocket.ClosePolicyViolation, "the desk session has been closed")
+				return nil
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(deskSocketWriteWait))
+			if err = conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// deskSocket is the connection of a desk. Only the goroutine serving it
+// writes to conn; commands are read and carried out on another, which hands
+// its replies over through replies.
+type deskSocket struct {
+	server  *APIServer
+	store   Storage
+	conn    *websocket.Conn
+	token   string
+	session types.DeskSession
+	desk    types.Desk
+	replies chan deskMessage
+}
+
+func (d *deskSocket) write(message deskMessage) error {
+	d.conn.SetWriteDeadline(time.Now().Add(deskSocketWriteWait))
+	return d.conn.WriteJSON(message)
+}
+
+func (d *deskSocket) close(code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+	d.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(deskSocketWriteWait))
+}
+
+// resume sends the events recorded after lastEventID, returning the IDs of
+// those sent.
+func (d *deskSocket) resume(lastEventID int) (map[int]bool, error) {
+	missed, err := d.store.TicketEventsSince([]int{d.desk.CategoryID}, lastEventID, eventResumeLimit+1)
+	if err != nil {
+		d.server.logger.Warnw("failed to look up missed events for desk socket", "desk_id", d.desk.ID, "error", err)
+		return nil, d.write(deskMessage{Type: deskMessageResync})
+	}
+
+	if len(missed) > eventResumeLimit {
+		return nil, d.write(deskMessage{Type: deskMessageResync})
+	}
+
+	replayed := make(map[int]bool, len(missed))
+	for _, event := range missed {
+		if err = d.write(deskMessage{Type: deskMessageEvent, Event: &event}); err != nil {
+			return nil, err
+		}
+		replayed[event.ID] = true
+	}
+
+	return replayed, nil
+}
+
+func (d *deskSocket) sessionOpen() bool {
+	session, err := d.store.GetDeskSessionByToken(d.token)
+	return err == nil && session.ID == d.session.ID
+}
+
+// readCommands carries out the desk's commands until its connection fails,
+// then closes done. Replies are dropped once quit is closed.
+func (d *deskSocket) readCommands(done chan<- struct{}, quit <-chan struct{}) {
+	defer close(done)
+
+	d.conn.SetReadLimit(deskSocketMaxMessage)
+	d.conn.SetReadDeadline(time.Now().Add(deskSocketPongWait))
+	d.conn.SetPongHandler(func(string) error {
+		return d.conn.SetReadDeadline(time.Now().Add(deskSocketPongWait))
+	})
+
+	for {
+		var command deskCommand
+		if err := d.conn.ReadJSON(&command); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				d.server.logger.Tracew("desk socket closed", "desk_id", d.desk.ID, "error", err)
+			}
+			return
+		}
+		d.conn.SetReadDeadline(time.Now().Add(deskSocketPongWait))
+
+		reply := d.handleCommand(command)
+		reply.ID = command.ID
+
+		select {
+		case d.replies <- reply:
+		case <-quit:
+			return
+		}
+	}
+}
+
+func (d *deskSocket) handleCommand(command deskCommand) deskMessage {
+	if command.Type == deskCommandPing {
+		return deskMessage{Type: deskMessagePong}
+	}
+
+	if !d.sessionOpen() {
+		return deskError(http.StatusUnauthorized, errors.New("the desk session has been closed"))
+	}
+
+	staff := d.session.Staff
+
+	switch command.Type {
+	case deskCommandCallNext:
+		ticket, err := d.store.CallNextTicket(d.desk.ID, staff)
+		switch err {
+		case nil:
+			return deskResult(ticket)
+		case types.ErrnotFound:
+			return deskError(http.StatusNotFound, errors.New("no tickets waiting"))
+		case types.ErrDeskClosed, types.ErrDeskPaused:
+			return deskError(http.StatusConflict, err)
+		default:
+			return deskError(http.StatusInternalServerError, err)
+		}
+	case deskCommandRecall:
+		if reply, ok := d.checkTicketAtDesk(command.TicketID); !ok {
+			return reply
+		}
+		return deskTicketAction(d.store.RecallTicket(command.TicketID, staff))
+	case deskCommandServe:
+		if reply, ok := d.checkTicketAtDesk(command.TicketID); !ok {
+			return reply
+		}
+		return deskTicketAction(d.store.ServeTicket(command.TicketID, staff))
+	case deskCommandComplete:
+		if reply, ok := d.checkTicketAtDesk(command.TicketID); !ok {
+			return reply
+		}
+		return deskTicketAction(d.store.CloseTicket(command.TicketID, staff))
+	case deskCommandTransfer:
+		if reply, ok := d.checkTicketAtDesk(command.TicketID); !ok {
+			return reply
+		}
+		if _, err := d.store.GetCategory(command.CategoryID); err != nil {
+			if err == types.ErrnotFound {
+				return deskError(http.StatusNotFound, errors.New("category not found"))
+			}
+			return deskError(http.StatusInternalServerError, err)
+		}
+		return deskTicketAction(d.store.TransferTicket(command.TicketID, command.CategoryID, staff))
+	default:
+		return deskError(http.StatusBadRequest, apiError{"'type' must be one of call_next, recall, serve, complete, transfer or ping"})
+	}
+}
+
+// checkTicketAtDesk makes sure that a desk only acts on the tickets called to
+// it.
+func (d *deskSocket) checkTicketAtDesk(ticketID int) (deskMessage, bool) {
+	ticket, err := d.store.GetTicket(ticketID)
+	if err != nil {
+		return deskError(ticketActionStatus(err), err), false
+	}
+
+	if ticket.DeskID != d.desk.ID {
+		return deskError(http.StatusConflict, apiError{"the ticket has not been called to this desk"}), false
+	}
+
+	return deskMessage{}, true
+}
+
+func deskTicketAction(ticket types.Ticket, err error) deskMessage {
+	if err != nil {
+		return deskError(ticketActionStatus(err), err)
+	}
+	return deskResult(ticket)
+}
+
+func deskResult(ticket types.Ticket) deskMessage {
+	return deskMessage{Type: deskMessageResult, Status: http.StatusOK, Ticket: &ticket}
+}
+
+func deskError(status int, err error) deskMessage {
+	return deskMessage{Type: deskMessageError, Status: status, Error: err.Error()}
+}
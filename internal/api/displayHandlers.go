@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"github.com/khaleelsyed/codaVirtuale/internal/web"
+)
+
+const defaultDisplayCalls = 8
+const maxDisplayCalls = 50
+
+type displayCall struct {
+	types.Call
+	DisplayNumber string `json:"display_number"`
+}
+
+type displayState struct {
+	Called     []displayCall      `json:"called"`
+	Categories []categoryResponse `json:"categories"`
+}
+
+func (s *APIServer) addDisplayRoutes(router *mux.Router) {
+	router.HandleFunc("/state", makeHTTPHandler(s.getDisplayState, []string{http.MethodGet}, s.logger))
+	router.PathPrefix("").Handler(serveStatic("/display", web.Display()))
+}
+
+func (s *APIServer) addConsoleRoutes(router *mux.Router) {
+	router.PathPrefix("").Handler(serveStatic("/console", web.Console()))
+}
+
+// serveStatic serves the files of a page mounted at path, under the prefix of
+// the location the request was made at.
+func serveStatic(path string, files fs.FS) http.Handler {
+	fileServer := http.FileServer(http.FS(files))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.StripPrefix(locationPrefix(r)+path, fileServer).ServeHTTP(w, r)
+	})
+}
+
+// getDisplayState returns what the lobby screens show: the most recently
+// called tickets and the queue of each category, filtered by the optional
+// 'category_id' list.
+func (s *APIServer) getDisplayState(w http.ResponseWriter, r *http.Request) error {
+	categoryIDs, err := parseCategoryIDs(r)
+	if err != nil {
+		return writeJSON(w, http.StatusBadRequest, err, s.logger)
+	}
+
+	limit := defaultDisplayCalls
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxDisplayCalls {
+			return writeJSON(w, http.StatusBadRequest, errors.New("'limit' must be between 1 and 50"), s.logger)
+		}
+	}
+
+	categories, err := s.store(r).ListCategories()
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	state := displayState{Called: []displayCall{}, Categories: []categoryResponse{}}
+	categoriesByID := make(map[int]types.Category)
+
+	for _, category := range categories {
+		categoriesByID[category.ID] = category
+
+		if len(categoryIDs) > 0 && !slices.Contains(categoryIDs, category.ID) {
+			continue
+		}
+
+		response, err := s.newCategoryResponse(r.Context(), category)
+		if err != nil {
+			return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+		}
+		state.Categories = append(state.Categories, response)
+	}
+
+	calls, err := s.store(r).RecentCalls(categoryIDs, limit)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	for _, call := range calls {
+		ticket := types.Ticket{ID: call.TicketID}
+		state.Called = append(state.Called, displayCall{
+			Call:          call,
+			DisplayNumber: ticket.DisplayNumber(categoriesByID[call.CategoryID]),
+		})
+	}
+
+	return writeJSON(w, http.StatusOK, state, s.logger)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const ifMatchHeader = "If-Match"
+
+var (
+	errIfMatchMissing = apiError{"the 'If-Match' header is required, with the ETag of the version being changed or '*'"}
+	errIfMatchInvalid = apiError{"'If-Match' must be a single ETag, as given by GET, or '*'"}
+	errStaleVersion   = apiError{"the resource has been changed since it was read; get it again and retry"}
+)
+
+// etag is the entity tag of a version of a category or desk.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", etag(version))
+}
+
+// ifMatch returns the version of a category or desk that a request changing
+// it was based on, so that one supervisor does not silently overwrite the
+// edit of another. Zero stands for '*', a change whatever the version.
+func ifMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get(ifMatchHeader))
+	if value == "" {
+		return 0, errIfMatchMissing
+	}
+	if value == "*" {
+		return 0, nil
+	}
+
+	unquoted, ok := strings.CutPrefix(value, `"`)
+	if !ok {
+		return 0, errIfMatchInvalid
+	}
+	unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	if !ok {
+		return 0, errIfMatchInvalid
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, errIfMatchInvalid
+	}
+	return version, nil
+}
+
+// ifMatchStatus is the status of a request whose If-Match header could not
+// be read.
+func ifMatchStatus(err error) int {
+	if err == errIfMatchMissing {
+		return http.StatusPreconditionRequired
+	}
+	return http.StatusBadRequest
+}
+
+// versionedWriteStatus is the status of a failed change to a category or
+// desk, or zero when the error is not one of the expected ones.
+func versionedWriteStatus(err error) (int, error) {
+	switch {
+	case errors.Is(err, types.ErrVersionMismatch):
+		return http.StatusPreconditionFailed, errStaleVersion
+	case errors.Is(err, types.ErrnotFound):
+		return http.StatusNotFound, err
+	default:
+		return 0, err
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const eventStreamHeartbeat = 15 * time.Second
+
+// eventResumeLimit is the most events replayed to a live view reconnecting
+// after the last event it saw. One that missed more is told to reload instead.
+const eventResumeLimit = 500
+
+func (s *APIServer) addEventRoutes(router *mux.Router) {
+	router.HandleFunc("", makeHTTPHandler(s.streamEvents, []string{http.MethodGet}, s.logger))
+}
+
+// parseCategoryIDs reads the optional comma separated 'category_id' query
+// parameter used to filter the live views.
+func parseCategoryIDs(r *http.Request) ([]int, error) {
+	value := r.URL.Query().Get("category_id")
+	if value == "" {
+		return nil, nil
+	}
+
+	var categoryIDs []int
+
+	for _, idStr := range strings.Split(value, ",") {
+		categoryID, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			return nil, errors.New("bad category ID")
+		}
+		categoryIDs = append(categoryIDs, categoryID)
+	}
+
+	return categoryIDs, nil
+}
+
+// locationCategoryIDs returns the categories of the request's location to
+// stream events for: those asked for, which must all be in the location, or
+// otherwise every one of them.
+func (s *APIServer) locationCategoryIDs(ctx context.Context, requested []int) ([]int, error) {
+	categories, err := s.storeFor(ctx).ListCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	var categoryIDs []int
+	for _, category := range categories {
+		categoryIDs = append(categoryIDs, category.ID)
+	}
+
+	if len(requested) == 0 {
+		return categoryIDs, nil
+	}
+
+	for _, categoryID := range requested {
+		if !slices.Contains(categoryIDs, categoryID) {
+			return nil, apiError{fmt.Sprintf("no category %d at this location", categoryID)}
+		}
+	}
+
+	return requested, nil
+}
+
+// streamEvents sends ticket events as server-sent events, named after the
+// event type, until the client disconnects.
+func (s *APIServer) streamEvents(w http.ResponseWriter, r *http.Request) error {
+	categoryIDs, err := parseCategoryIDs(r)
+	if err != nil {
+		return writeJSON(w, http.StatusBadRequest, err, s.logger)
+	}
+
+	categoryIDs, err = s.locationCategoryIDs(r.Context(), categoryIDs)
+	if err != nil {
+		if _, ok := err.(apiError); ok {
+			return writeJSON(w, http.StatusBadRequest, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return writeJSON(w, http.StatusInternalServerError, errors.New("streaming is not supported"), s.logger)
+	}
+
+	// A location without categories has no events to send, but the stream is
+	// kept open like any other.
+	var ticketEvents <-chan types.TicketEvent
+	if len(categoryIDs) > 0 {
+		subscription := s.events.Subscribe(categoryIDs)
+		defer subscription.Close()
+		ticketEvents = subscription.C
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case event, open := <-ticketEvents:
+			if !open {
+				return nil
+			}
+
+			// Staff identifiers are not shown on the public stream.
+			event.Staff = ""
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				s.logger.Warnw("failed to encode ticket event", "event_id", event.ID, "error", err)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return nil
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,677 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	codav1 "github.com/khaleelsyed/codaVirtuale/internal/gen/coda/v1"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RunGRPC serves the gRPC API on listenAddress alongside the REST API.
+func (s *APIServer) RunGRPC(listenAddress string) {
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		s.logger.Errorw("Failed to listen for gRPC requests", "listenAddress", listenAddress, "error", err)
+		return
+	}
+
+	s.logger.Infow("Listening to gRPC requests", "listenAddress", listenAddress)
+	if err = s.NewGRPCServer().Serve(listener); err != nil {
+		s.logger.Errorw("Failed to serve gRPC requests", "error", err)
+	}
+}
+
+// NewGRPCServer returns a gRPC server for the QueueService, acting on the
+// same storage as the REST API, for the caller to serve on a listener.
+func (s *APIServer) NewGRPCServer(options ...grpc.ServerOption) *grpc.Server {
+	options = append(options,
+		grpc.ChainUnaryInterceptor(s.unaryLocationInterceptor),
+		grpc.ChainStreamInterceptor(s.streamLocationInterceptor),
+	)
+
+	server := grpc.NewServer(options...)
+	codav1.RegisterQueueServiceServer(server, &queueServer{api: s})
+	return server
+}
+
+// metadataValue returns the first value of a gRPC metadata key, which stands
+// in for the request header of the same name in the REST API.
+func metadataValue(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// withGRPCLocation looks up the location named by the 'x-location' metadata,
+// or the default location, as withLocation does for the REST API.
+func (s *APIServer) withGRPCLocation(ctx context.Context) (context.Context, error) {
+	slug := metadataValue(ctx, "x-location")
+	if slug == "" {
+		slug = types.DefaultLocation
+	}
+
+	location, err := s.storage.GetLocation(slug)
+	if err != nil {
+		if err == types.ErrnotFound {
+			return nil, status.Errorf(codes.NotFound, "no location '%s'", slug)
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return context.WithValue(ctx, locationContextKey{}, location), nil
+}
+
+func (s *APIServer) unaryLocationInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.withGRPCLocation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *APIServer) streamLocationInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.withGRPCLocation(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, locatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// locatedStream is a server stream whose context carries its location.
+type locatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (l locatedStream) Context() context.Context {
+	return l.ctx
+}
+
+// grpcError converts the HTTP status the REST API gives an error to the
+// matching gRPC status.
+func grpcError(httpStatus int, err error) error {
+	code := codes.Internal
+
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict, http.StatusPreconditionRequired:
+		code = codes.FailedPrecondition
+	case http.StatusPreconditionFailed:
+		code = codes.Aborted
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	}
+
+	return status.Error(code, err.Error())
+}
+
+// grpcClientKeys identifies the client asking for a ticket, as clientKeys
+// does for the REST API.
+func grpcClientKeys(ctx context.Context) []string {
+	var keys []string
+
+	if p, ok := peer.FromContext(ctx); ok {
+		ip := p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		keys = append(keys, "ip:"+ip)
+	}
+
+	if device := metadataValue(ctx, "x-device-token"); device != "" {
+		keys = append(keys, "device:"+device)
+	}
+
+	return keys
+}
+
+type queueServer struct {
+	codav1.UnimplementedQueueServiceServer
+	api *APIServer
+}
+
+func ticketMessage(ticket types.Ticket, displayNumber string) *codav1.Ticket {
+	message := &codav1.Ticket{
+		Id:            int64(ticket.ID),
+		CategoryId:    int64(ticket.CategoryID),
+		SubUrl:        ticket.SubURL,
+		Closed:        ticket.Closed,
+		CreatedAt:     timestamppb.New(ticket.CreatedAt),
+		QueuedAt:      timestamppb.New(ticket.QueuedAt),
+		Snoozes:       int32(ticket.Snoozes),
+		Recalls:       int32(ticket.Recalls),
+		Priority:      ticket.Priority,
+		DisplayNumber: displayNumber,
+	}
+
+	if ticket.DeskID != -1 {
+		message.DeskId = int64(ticket.DeskID)
+	}
+	if ticket.CalledAt != nil {
+		message.CalledAt = timestamppb.New(*ticket.CalledAt)
+	}
+	if ticket.ServedAt != nil {
+		message.ServedAt = timestamppb.New(*ticket.ServedAt)
+	}
+
+	return message
+}
+
+func categoryMessage(category types.Category) *codav1.Category {
+	return &codav1.Category{Id: int64(category.ID), Name: category.Name, Version: int64(category.Version)}
+}
+
+func deskMessageOf(desk types.Desk) *codav1.Desk {
+	return &codav1.Desk{Id: int64(desk.ID), CategoryId: int64(desk.CategoryID), Label: desk.Label, Version: int64(desk.Version)}
+}
+
+var errVersionMissing = apiError{"'version' is required, with the version being changed or 0"}
+
+// requestVersion returns the version of a category or desk that a request
+// changing it was based on, as ifMatch does for the REST API. Zero stands for
+// a change whatever the version.
+func requestVersion(version *int64) (int, error) {
+	if version == nil {
+		return 0, grpcError(http.StatusPreconditionRequired, errVersionMissing)
+	}
+	if *version < 0 {
+		return 0, grpcError(http.StatusBadRequest, apiError{"'version' must not be negative"})
+	}
+	return int(*version), nil
+}
+
+func ticketEventMessage(event types.TicketEvent) *codav1.TicketEvent {
+	message := &codav1.TicketEvent{
+		Id:         int64(event.ID),
+		TicketId:   int64(event.TicketID),
+		Type:       string(event.Type),
+		CategoryId: int64(event.CategoryID),
+		Staff:      event.Staff,
+		CreatedAt:  timestamppb.New(event.CreatedAt),
+	}
+
+	if event.DeskID != -1 {
+		message.DeskId = int64(event.DeskID)
+	}
+
+	return message
+}
+
+func optionalInt32(n *int) *int32 {
+	if n == nil {
+		return nil
+	}
+	v := int32(*n)
+	return &v
+}
+
+// ticket converts a ticket along with the display number of its category.
+func (q *queueServer) ticket(ctx context.Context, ticket types.Ticket) (*codav1.Ticket, error) {
+	category, err := q.api.storeFor(ctx).GetCategory(ticket.CategoryID)
+	if err != nil {
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	return ticketMessage(ticket, ticket.DisplayNumber(category)), nil
+}
+
+func (q *queueServer) ticketStatus(ctx context.Context, ticket types.Ticket) (*codav1.TicketStatus, error) {
+	message, err := q.ticket(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := q.api.newTicketResponse(ctx, ticket)
+	if err != nil {
+		q.api.logger.Warnw("failed to estimate wait for ticket", "id", ticket.ID, "error", err)
+		return &codav1.TicketStatus{Ticket: message}, nil
+	}
+
+	return &codav1.TicketStatus{
+		Ticket:               message,
+		Position:             optionalInt32(response.Position),
+		EstimatedWaitSeconds: optionalInt32(response.EstimatedWaitSeconds),
+	}, nil
+}
+
+func (q *queueServer) CreateTicket(ctx context.Context, req *codav1.CreateTicketRequest) (*codav1.TicketStatus, error) {
+	store := q.api.storeFor(ctx)
+	categoryID := int(req.GetCategoryId())
+
+	if categoryID == 0 {
+		return nil, grpcError(http.StatusBadRequest, apiError{"bad category ID"})
+	}
+
+	var contact *types.TicketContact
+	if c := req.GetContact(); c != nil {
+		contact = &types.TicketContact{Email: c.GetEmail(), Phone: c.GetPhone(), WebhookURL: c.GetWebhookUrl(), NotifyAhead: int(c.GetNotifyAhead())}
+		if errs := q.api.validateContact(contact); len(errs) > 0 {
+			return nil, grpcError(http.StatusBadRequest, errors.Join(errs...))
+		}
+	}
+
+	if _, err := store.GetCategory(categoryID); err != nil {
+		if err == types.ErrnotFound {
+			return nil, grpcError(http.StatusNotFound, errors.New("category not found"))
+		}
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	if err := q.api.checkIntake(ctx, categoryID); err != nil {
+		if errors.Is(err, types.ErrIntakeClosed) {
+			return nil, grpcError(http.StatusConflict, err)
+		}
+		q.api.logger.Warnw("failed to check category intake", "category_id", categoryID, "error", err)
+		return nil, grpcError(http.StatusInternalServerError, errors.New("error creating ticket"))
+	}
+
+	settings, err := store.GetCategorySettings(categoryID)
+	if err != nil {
+		return nil, grpcError(http.StatusInternalServerError, errors.New("error creating ticket"))
+	}
+
+	release, allowed, _ := q.api.reserveTicket(settings, grpcClientKeys(ctx))
+	if !allowed {
+		return nil, grpcError(http.StatusTooManyRequests, errTooManyTickets)
+	}
+
+	ticket, err := q.api.issueTicket(ctx, categoryID, contact)
+	if err != nil {
+		release()
+		if err == types.ErrQueueFull {
+			return nil, grpcError(http.StatusConflict, err)
+		}
+		return nil, grpcError(http.StatusInternalServerError, errors.New("error creating ticket"))
+	}
+
+	return q.ticketStatus(ctx, ticket)
+}
+
+func (q *queueServer) GetTicket(ctx context.Context, req *codav1.GetTicketRequest) (*codav1.TicketStatus, error) {
+	ticket, err := q.api.storeFor(ctx).GetTicket(int(req.GetId()))
+	if err != nil {
+		return nil, grpcError(ticketActionStatus(err), err)
+	}
+
+	return q.ticketStatus(ctx, ticket)
+}
+
+func (q *queueServer) DeleteTicket(ctx context.Context, req *codav1.DeleteTicketRequest) (*codav1.DeleteTicketResponse, error) {
+	store := q.api.storeFor(ctx)
+
+	if _, err := store.GetTicket(int(req.GetId())); err != nil {
+		return nil, grpcError(ticketActionStatus(err), err)
+	}
+
+	if err := store.DeleteTicket(int(req.GetId()), metadataValue(ctx, "x-staff-id")); err != nil {
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	return &codav1.DeleteTicketResponse{}, nil
+}
+
+func (q *queueServer) ListCategories(ctx context.Context, req *codav1.ListCategoriesRequest) (*codav1.ListCategoriesResponse, error) {
+	categories, err := q.api.storeFor(ctx).ListCategories()
+	if err != nil {
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	response := &codav1.ListCategoriesResponse{}
+	for _, category := range categories {
+		response.Categories = append(response.Categories, categoryMessage(category))
+	}
+
+	return response, nil
+}
+
+func (q *queueServer) GetCategory(ctx context.Context, req *codav1.GetCategoryRequest) (*codav1.CategoryStatus, error) {
+	category, err := q.api.storeFor(ctx).GetCategory(int(req.GetId()))
+	if err != nil {
+		return nil, grpcError(ticketActionStatus(err), err)
+	}
+
+	response, err := q.api.newCategoryResponse(ctx, category)
+	if err != nil {
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	return &codav1.CategoryStatus{
+		Category:             categoryMessage(category),
+		Waiting:              int32(response.Waiting),
+		OpenDesks:            int32(response.OpenDesks),
+		EstimatedWaitSeconds: optionalInt32(response.EstimatedWaitSeconds),
+	}, nil
+}
+
+func (q *queueServer) CreateCategory(ctx context.Context, req *codav1.CreateCategoryRequest) (*codav1.Category, error) {
+	category, err := q.api.storeFor(ctx).CreateCategory(req.GetName())
+	if err != nil {
+		if strings.HasPrefix(err.Error(), pqUniqueConstraintViolation) {
+			return nil, status.Error(codes.AlreadyExists, "'name' must be unique")
+		}
+		return nil, grpcError(http.StatusInternalServerError, errors.New("error creating category"))
+	}
+
+	return categoryMessage(category), nil
+}
+
+func (q *queueServer) UpdateCategory(ctx context.Context, req *codav1.UpdateCategoryRequest) (*codav1.Category, error) {
+	version, err := requestVersion(req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := q.api.storeFor(ctx).UpdateCategory(int(req.GetId()), req.GetName(), version)
+	if err != nil {
+		if status, err := versionedWriteStatus(err); status != 0 {
+			return nil, grpcError(status, err)
+		}
+		if strings.HasPrefix(err.Error(), pqUniqueConstraintViolation) {
+			return nil, status.Error(codes.AlreadyExists, "'name' must be unique")
+		}
+		return nil, grpcError(http.StatusBadRequest, errors.New(badValidationString("category")))
+	}
+
+	return categoryMessage(category), nil
+}
+
+func (q *queueServer) DeleteCategory(ctx context.Context, req *codav1.DeleteCategoryRequest) (*codav1.DeleteCategoryResponse, error) {
+	version, err := requestVersion(req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.api.storeFor(ctx).DeleteCategory(int(req.GetId()), version); err != nil {
+		if status, err := versionedWriteStatus(err); status != 0 {
+			return nil, grpcError(status, err)
+		}
+		return nil, grpcError(http.StatusBadRequest, errors.New(badValidationString("category")))
+	}
+
+	return &codav1.DeleteCategoryResponse{}, nil
+}
+
+func (q *queueServer) ListDesks(ctx context.Context, req *codav1.ListDesksRequest) (*codav1.ListDesksResponse, error) {
+	desks, err := q.api.storeFor(ctx).ListDesks()
+	if err != nil {
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	response := &codav1.ListDesksResponse{}
+	for _, desk := range desks {
+		response.Desks = append(response.Desks, deskMessageOf(desk))
+	}
+
+	return response, nil
+}
+
+func (q *queueServer) GetDesk(ctx context.Context, req *codav1.GetDeskRequest) (*codav1.Desk, error) {
+	desk, err := q.api.storeFor(ctx).GetDesk(int(req.GetId()))
+	if err != nil {
+		return nil, grpcError(ticketActionStatus(err), err)
+	}
+
+	return deskMessageOf(desk), nil
+}
+
+func (q *queueServer) CreateDesk(ctx context.Context, req *codav1.CreateDeskRequest) (*codav1.Desk, error) {
+	if req.GetLabel() == "" || req.GetCategoryId() == 0 {
+		return nil, grpcError(http.StatusBadRequest, apiError{"label and category_id are required"})
+	}
+
+	desk, err := q.api.storeFor(ctx).CreateDesk(req.GetLabel(), int(req.GetCategoryId()))
+	if err != nil {
+		if err == types.ErrnotFound {
+			return nil, grpcError(http.StatusNotFound, errors.New("category not found"))
+		}
+		return nil, grpcError(http.StatusInternalServerError, errors.New("error creating desk"))
+	}
+
+	return deskMessageOf(desk), nil
+}
+
+// UpdateDesk changes the label and category of a desk, keeping whichever of
+// them is left unset, as the REST API does. The version of the desk the change
+// was based on takes the place of the REST API's If-Match header.
+func (q *queueServer) UpdateDesk(ctx context.Context, req *codav1.UpdateDeskRequest) (*codav1.Desk, error) {
+	if req.GetLabel() == "" && req.GetCategoryId() == 0 {
+		return nil, grpcError(http.StatusBadRequest, apiError{"Request must contain either 'category_id' or a 'label'"})
+	}
+
+	version, err := requestVersion(req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	update := types.DeskUpdate{CategoryID: int(req.GetCategoryId()), Label: req.GetLabel()}
+
+	desk, err := q.api.storeFor(ctx).UpdateDesk(int(req.GetId()), update, version)
+	if err != nil {
+		if status, err := versionedWriteStatus(err); status != 0 {
+			return nil, grpcError(status, err)
+		}
+		return nil, grpcError(http.StatusBadRequest, errors.New("failed to update desk"))
+	}
+
+	return deskMessageOf(desk), nil
+}
+
+func (q *queueServer) DeleteDesk(ctx context.Context, req *codav1.DeleteDeskRequest) (*codav1.DeleteDeskResponse, error) {
+	version, err := requestVersion(req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.api.storeFor(ctx).DeleteDesk(int(req.GetId()), version); err != nil {
+		if status, err := versionedWriteStatus(err); status != 0 {
+			return nil, grpcError(status, err)
+		}
+		return nil, grpcError(http.StatusBadRequest, errors.New(badValidationString("desk")))
+	}
+
+	return &codav1.DeleteDeskResponse{}, nil
+}
+
+func (q *queueServer) CallNext(ctx context.Context, req *codav1.CallNextRequest) (*codav1.Ticket, error) {
+	store := q.api.storeFor(ctx)
+
+	if _, err := store.GetDesk(int(req.GetDeskId())); err != nil {
+		return nil, grpcError(http.StatusBadRequest, errors.New(badValidationString("desk")))
+	}
+
+	ticket, err := store.CallNextTicket(int(req.GetDeskId()), metadataValue(ctx, "x-staff-id"))
+	if err != nil {
+		switch err {
+		case types.ErrnotFound:
+			return nil, grpcError(http.StatusNotFound, errors.New("no tickets waiting"))
+		case types.ErrDeskClosed, types.ErrDeskPaused:
+			return nil, grpcError(http.StatusConflict, err)
+		}
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	return q.ticket(ctx, ticket)
+}
+
+func (q *queueServer) PeekNext(ctx context.Context, req *codav1.PeekNextRequest) (*codav1.Ticket, error) {
+	store := q.api.storeFor(ctx)
+
+	if _, err := store.GetCategory(int(req.GetCategoryId())); err != nil {
+		return nil, grpcError(http.StatusBadRequest, errors.New(badValidationString("category")))
+	}
+
+	ticket, err := store.SeeNext(int(req.GetCategoryId()))
+	if err != nil {
+		if err == types.ErrnotFound {
+			return nil, grpcError(http.StatusNotFound, errors.New("no tickets waiting"))
+		}
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	return q.ticket(ctx, ticket)
+}
+
+func (q *queueServer) ListQueue(ctx context.Context, req *codav1.ListQueueRequest) (*codav1.ListQueueResponse, error) {
+	var categoryIDs []int
+	for _, categoryID := range req.GetCategoryIds() {
+		categoryIDs = append(categoryIDs, int(categoryID))
+	}
+
+	tickets, err := q.api.storeFor(ctx).SeeQueue(categoryIDs)
+	if err != nil {
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	entries, err := q.api.newQueueEntries(ctx, tickets)
+	if err != nil {
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	response := &codav1.ListQueueResponse{}
+	for _, entry := range entries {
+		response.Tickets = append(response.Tickets, ticketMessage(entry.Ticket, entry.DisplayNumber))
+	}
+
+	return response, nil
+}
+
+// ticketAction converts the result of one of the storage's ticket state
+// transitions.
+func (q *queueServer) ticketAction(ctx context.Context, ticket types.Ticket, err error) (*codav1.Ticket, error) {
+	if err != nil {
+		return nil, grpcError(ticketActionStatus(err), err)
+	}
+	return q.ticket(ctx, ticket)
+}
+
+func (q *queueServer) RecallTicket(ctx context.Context, req *codav1.TicketActionRequest) (*codav1.Ticket, error) {
+	ticket, err := q.api.storeFor(ctx).RecallTicket(int(req.GetId()), metadataValue(ctx, "x-staff-id"))
+	return q.ticketAction(ctx, ticket, err)
+}
+
+func (q *queueServer) ServeTicket(ctx context.Context, req *codav1.TicketActionRequest) (*codav1.Ticket, error) {
+	ticket, err := q.api.storeFor(ctx).ServeTicket(int(req.GetId()), metadataValue(ctx, "x-staff-id"))
+	return q.ticketAction(ctx, ticket, err)
+}
+
+func (q *queueServer) TransferTicket(ctx context.Context, req *codav1.TransferTicketRequest) (*codav1.Ticket, error) {
+	store := q.api.storeFor(ctx)
+
+	if _, err := store.GetCategory(int(req.GetCategoryId())); err != nil {
+		if err == types.ErrnotFound {
+			return nil, grpcError(http.StatusNotFound, errors.New("category not found"))
+		}
+		return nil, grpcError(http.StatusBadRequest, errors.New(badValidationString("category")))
+	}
+
+	ticket, err := store.TransferTicket(int(req.GetId()), int(req.GetCategoryId()), metadataValue(ctx, "x-staff-id"))
+	return q.ticketAction(ctx, ticket, err)
+}
+
+func (q *queueServer) CloseTicket(ctx context.Context, req *codav1.TicketActionRequest) (*codav1.Ticket, error) {
+	ticket, err := q.api.storeFor(ctx).CloseTicket(int(req.GetId()), metadataValue(ctx, "x-staff-id"))
+	return q.ticketAction(ctx, ticket, err)
+}
+
+// MarkNoShow gives up on a called ticket, applying the category's requeue
+// setting.
+func (q *queueServer) MarkNoShow(ctx context.Context, req *codav1.TicketActionRequest) (*codav1.Ticket, error) {
+	store := q.api.storeFor(ctx)
+
+	ticket, err := store.GetTicket(int(req.GetId()))
+	if err != nil {
+		return nil, grpcError(ticketActionStatus(err), err)
+	}
+
+	settings, err := store.GetCategorySettings(ticket.CategoryID)
+	if err != nil {
+		return nil, grpcError(http.StatusInternalServerError, err)
+	}
+
+	ticket, err = store.MarkNoShow(ticket.ID, settings.RequeueNoShows, metadataValue(ctx, "x-staff-id"))
+	return q.ticketAction(ctx, ticket, err)
+}
+
+func (q *queueServer) WatchQueue(req *codav1.WatchQueueRequest, stream grpc.ServerStreamingServer[codav1.TicketEvent]) error {
+	ctx := stream.Context()
+
+	var requested []int
+	for _, categoryID := range req.GetCategoryIds() {
+		requested = append(requested, int(categoryID))
+	}
+
+	categoryIDs, err := q.api.locationCategoryIDs(ctx, requested)
+	if err != nil {
+		if _, ok := err.(apiError); ok {
+			return grpcError(http.StatusBadRequest, err)
+		}
+		return grpcError(http.StatusInternalServerError, err)
+	}
+
+	// A location without categories has no events to send, but the stream is
+	// kept open like any other.
+	var ticketEvents <-chan types.TicketEvent
+	if len(categoryIDs) > 0 {
+		// Events are buffered from before the missed ones are looked up, so
+		// that none are lost in between.
+		subscription := q.api.events.Subscribe(categoryIDs)
+		defer subscription.Close()
+		ticketEvents = subscription.C
+	}
+
+	var replayed map[int]bool
+	if lastEventID := int(req.GetLastEventId()); lastEventID > 0 && len(categoryIDs) > 0 {
+		missed, err := q.api.storeFor(ctx).TicketEventsSince(categoryIDs, lastEventID, eventResumeLimit+1)
+		if err != nil {
+			return grpcError(http.StatusInternalServerError, err)
+		}
+		if len(missed) > eventResumeLimit {
+			return status.Error(codes.OutOfRange, "too many events were missed, reload the queue and watch it again without last_event_id")
+		}
+
+		replayed = make(map[int]bool, len(missed))
+		for _, event := range missed {
+			if err = stream.Send(ticketEventMessage(event)); err != nil {
+				return err
+			}
+			replayed[event.ID] = true
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, open := <-ticketEvents:
+			if !open {
+				return nil
+			}
+			// Events published while the missed ones were replayed may be
+			// among them. Events are not published in ID order, so only
+			// those are skipped.
+			if replayed[event.ID] {
+				delete(replayed, event.ID)
+				continue
+			}
+
+			if err := stream.Send(ticketEventMessage(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package api
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	codav1 "github.com/khaleelsyed/codaVirtuale/internal/gen/coda/v1"
+	"github.com/khaleelsyed/codaVirtuale/internal/storage"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestGRPCClient serves the gRPC API of a test server in memory and returns
+// a client connected to it.
+func newTestGRPCClient(t *testing.T) (codav1.QueueServiceClient, *storage.MockStorage) {
+	t.Helper()
+
+	s, store := newTestServer(t)
+	return serveGRPC(t, s), store
+}
+
+// serveGRPC serves the gRPC API of a server in memory and returns a client
+// connected to it.
+func serveGRPC(t *testing.T, s *APIServer) codav1.QueueServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := s.NewGRPCServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return codav1.NewQueueServiceClient(conn)
+}
+
+func createCategory(t *testing.T, store *storage.MockStorage, name string) types.Category {
+	t.Helper()
+
+	category, err := store.ForLocation(1).CreateCategory(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return category
+}
+
+func assertCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+
+	if got := status.Code(err); got != want {
+		t.Errorf("got %v (%v), want %v", got, err, want)
+	}
+}
+
+func TestGRPCCreateTicket(t *testing.T) {
+	client, store := newTestGRPCClient(t)
+	ctx := context.Background()
+	category := createCategory(t, store, "payments")
+
+	first, err := client.CreateTicket(ctx, &codav1.CreateTicketRequest{CategoryId: int64(category.ID)})
+	if err != nil {
+		t.Fatalf("CreateTicket() = %v", err)
+	}
+	second, err := client.CreateTicket(ctx, &codav1.CreateTicketRequest{CategoryId: int64(category.ID)})
+	if err != nil {
+		t.Fatalf("CreateTicket() = %v", err)
+	}
+
+	if got := second.GetTicket().GetDisplayNumber(); got != "P002" {
+		t.Errorf("display number = %q, want P002", got)
+	}
+	if second.GetTicket().GetSubUrl() == "" || second.GetTicket().GetSubUrl() == first.GetTicket().GetSubUrl() {
+		t.Errorf("tickets were given sub URLs %q and %q", first.GetTicket().GetSubUrl(), second.GetTicket().GetSubUrl())
+	}
+	if second.Position == nil || *second.Position != 1 {
+		t.Errorf("position = %v, want 1", second.Position)
+	}
+
+	_, err = client.CreateTicket(ctx, &codav1.CreateTicketRequest{})
+	assertCode(t, err, codes.InvalidArgument)
+
+	_, err = client.CreateTicket(ctx, &codav1.CreateTicketRequest{CategoryId: 99})
+	assertCode(t, err, codes.NotFound)
+
+	if _, err = store.SetIntakeClosed(category.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateTicket(ctx, &codav1.CreateTicketRequest{CategoryId: int64(category.ID)})
+	assertCode(t, err, codes.FailedPrecondition)
+}
+
+func TestGRPCCallNext(t *testing.T) {
+	client, store := newTestGRPCClient(t)
+	ctx := context.Background()
+	category := createCategory(t, store, "payments")
+
+	desk, err := store.ForLocation(1).CreateDesk("Desk 1", category.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticket, err := client.CreateTicket(ctx, &codav1.CreateTicketRequest{CategoryId: int64(category.ID)})
+	if err != nil {
+		t.Fatalf("CreateTicket() = %v", err)
+	}
+
+	_, err = client.CallNext(ctx, &codav1.CallNextRequest{DeskId: int64(desk.ID)})
+	assertCode(t, err, codes.FailedPrecondition)
+
+	if _, err = store.OpenDeskSession(desk.ID, "alice", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	called, err := client.CallNext(metadata.AppendToOutgoingContext(ctx, "x-staff-id", "alice"), &codav1.CallNextRequest{DeskId: int64(desk.ID)})
+	if err != nil {
+		t.Fatalf("CallNext() = %v", err)
+	}
+	if called.GetId() != ticket.GetTicket().GetId() || called.GetDeskId() != int64(desk.ID) || called.GetCalledAt() == nil {
+		t.Errorf("CallNext() = %v, want ticket %d called to desk %d", called, ticket.GetTicket().GetId(), desk.ID)
+	}
+
+	history, err := store.GetTicketHistory(int(called.GetId()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := history[len(history)-1]; last.Type != types.TicketCalled || last.Staff != "alice" {
+		t.Errorf("last event = %+v, want a call by alice", last)
+	}
+
+	_, err = client.CallNext(ctx, &codav1.CallNextRequest{DeskId: int64(desk.ID)})
+	assertCode(t, err, codes.NotFound)
+
+	_, err = client.CallNext(ctx, &codav1.CallNextRequest{DeskId: 99})
+	assertCode(t, err, codes.InvalidArgument)
+}
+
+func TestGRPCServeTicket(t *testing.T) {
+	client, store := newTestGRPCClient(t)
+	ctx := context.Background()
+	category := createCategory(t, store, "payments")
+	desk := openDesk(t, store, category.ID)
+
+	ticket, err := client.CreateTicket(ctx, &codav1.CreateTicketRequest{CategoryId: int64(category.ID)})
+	if err != nil {
+		t.Fatalf("CreateTicket() = %v", err)
+	}
+	request := &codav1.TicketActionRequest{Id: ticket.GetTicket().GetId()}
+
+	_, err = client.ServeTicket(ctx, request)
+	assertCode(t, err, codes.FailedPrecondition)
+
+	if _, err = client.CallNext(ctx, &codav1.CallNextRequest{DeskId: int64(desk.ID)}); err != nil {
+		t.Fatalf("CallNext() = %v", err)
+	}
+
+	served, err := client.ServeTicket(ctx, request)
+	if err != nil {
+		t.Fatalf("ServeTicket() = %v", err)
+	}
+	if served.GetServedAt() == nil {
+		t.Errorf("ServeTicket() = %v, want the time service started", served)
+	}
+
+	// A customer being served is at the desk, so can be neither recalled nor
+	// given up on.
+	_, err = client.ServeTicket(ctx, request)
+	assertCode(t, err, codes.FailedPrecondition)
+	_, err = client.RecallTicket(ctx, request)
+	assertCode(t, err, codes.FailedPrecondition)
+	_, err = client.MarkNoShow(ctx, request)
+	assertCode(t, err, codes.FailedPrecondition)
+
+	if _, err = client.CloseTicket(ctx, request); err != nil {
+		t.Errorf("CloseTicket() = %v", err)
+	}
+}
+
+func TestGRPCDeskVersion(t *testing.T) {
+	client, store := newTestGRPCClient(t)
+	ctx := context.Background()
+	category := createCategory(t, store, "payments")
+
+	desk, err := client.CreateDesk(ctx, &codav1.CreateDeskRequest{Label: "Desk 1", CategoryId: int64(category.ID)})
+	if err != nil {
+		t.Fatalf("CreateDesk() = %v", err)
+	}
+
+	_, err = client.UpdateDesk(ctx, &codav1.UpdateDeskRequest{Id: desk.GetId(), Label: "Desk A"})
+	assertCode(t, err, codes.FailedPrecondition)
+
+	updated, err := client.UpdateDesk(ctx, &codav1.UpdateDeskRequest{Id: desk.GetId(), Label: "Desk A", Version: proto.Int64(desk.GetVersion())})
+	if err != nil {
+		t.Fatalf("UpdateDesk() at the current version = %v", err)
+	}
+	if updated.GetLabel() != "Desk A" || updated.GetVersion() != desk.GetVersion()+1 {
+		t.Errorf("UpdateDesk() = %v", updated)
+	}
+
+	_, err = client.UpdateDesk(ctx, &codav1.UpdateDeskRequest{Id: desk.GetId(), Label: "Desk B", Version: proto.Int64(desk.GetVersion())})
+	assertCode(t, err, codes.Aborted)
+	_, err = client.DeleteDesk(ctx, &codav1.DeleteDeskRequest{Id: desk.GetId(), Version: proto.Int64(desk.GetVersion())})
+	assertCode(t, err, codes.Aborted)
+	_, err = client.DeleteDesk(ctx, &codav1.DeleteDeskRequest{Id: desk.GetId()})
+	assertCode(t, err, codes.FailedPrecondition)
+
+	if _, err = client.DeleteDesk(ctx, &codav1.DeleteDeskRequest{Id: desk.GetId(), Version: proto.Int64(0)}); err != nil {
+		t.Errorf("DeleteDesk() whatever the version = %v", err)
+	}
+	_, err = client.DeleteDesk(ctx, &codav1.DeleteDeskRequest{Id: desk.GetId(), Version: proto.Int64(0)})
+	assertCode(t, err, codes.NotFound)
+}
+
+func TestGRPCCategoryVersion(t *testing.T) {
+	client, _ := newTestGRPCClient(t)
+	ctx := context.Background()
+
+	category, err := client.CreateCategory(ctx, &codav1.CreateCategoryRequest{Name: "payments"})
+	if err != nil {
+		t.Fatalf("CreateCategory() = %v", err)
+	}
+
+	_, err = client.UpdateCategory(ctx, &codav1.UpdateCategoryRequest{Id: category.GetId(), Name: "accounts"})
+	assertCode(t, err, codes.FailedPrecondition)
+	_, err = client.UpdateCategory(ctx, &codav1.UpdateCategoryRequest{Id: category.GetId(), Name: "accounts", Version: proto.Int64(-1)})
+	assertCode(t, err, codes.InvalidArgument)
+
+	renamed, err := client.UpdateCategory(ctx, &codav1.UpdateCategoryRequest{Id: category.GetId(), Name: "accounts", Version: proto.Int64(category.GetVersion())})
+	if err != nil {
+		t.Fatalf("UpdateCategory() at the current version = %v", err)
+	}
+
+	_, err = client.DeleteCategory(ctx, &codav1.DeleteCategoryRequest{Id: category.GetId(), Version: proto.Int64(category.GetVersion())})
+	assertCode(t, err, codes.Aborted)
+
+	if _, err = client.DeleteCategory(ctx, &codav1.DeleteCategoryRequest{Id: category.GetId(), Version: proto.Int64(renamed.GetVersion())}); err != nil {
+		t.Errorf("DeleteCategory() at the current version = %v", err)
+	}
+}
+
+func TestGRPCLocation(t *testing.T) {
+	client, store := newTestGRPCClient(t)
+	ctx := context.Background()
+
+	north, err := store.CreateLocation(types.Location{Slug: "north", Name: "North", Timezone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	category, err := store.ForLocation(north.ID).CreateCategory("north payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := &codav1.CreateTicketRequest{CategoryId: int64(category.ID)}
+
+	northCtx := metadata.AppendToOutgoingContext(ctx, "x-location", "north")
+	if _, err = client.CreateTicket(northCtx, request); err != nil {
+		t.Errorf("CreateTicket() at its own location = %v", err)
+	}
+
+	categories, err := client.ListCategories(northCtx, &codav1.ListCategoriesRequest{})
+	if err != nil || len(categories.GetCategories()) != 1 {
+		t.Errorf("ListCategories() at its own location = %v, %v", categories, err)
+	}
+
+	// Without metadata the default location is used, which cannot see the
+	// category.
+	_, err = client.CreateTicket(ctx, request)
+	assertCode(t, err, codes.NotFound)
+
+	categories, err = client.ListCategories(ctx, &codav1.ListCategoriesRequest{})
+	if err != nil || len(categories.GetCategories()) != 0 {
+		t.Errorf("ListCategories() at the default location = %v, %v", categories, err)
+	}
+
+	_, err = client.CreateTicket(metadata.AppendToOutgoingContext(ctx, "x-location", "south"), request)
+	assertCode(t, err, codes.NotFound)
+}
+
+// receiveEvents reads n events from a stream, failing the test if they take
+// too long to arrive.
+func receiveEvents(t *testing.T, stream grpc.ServerStreamingClient[codav1.TicketEvent], n int) []*codav1.TicketEvent {
+	t.Helper()
+
+	received := make(chan *codav1.TicketEvent)
+	failed := make(chan error, 1)
+	go func() {
+		for range n {
+			event, err := stream.Recv()
+			if err != nil {
+				failed <- err
+				return
+			}
+			received <- event
+		}
+	}()
+
+	var events []*codav1.TicketEvent
+	for range n {
+		select {
+		case event := <-received:
+			events = append(events, event)
+		case err := <-failed:
+			t.Fatalf("Recv() = %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d events, want %d", len(events), n)
+		}
+	}
+	return events
+}
+
+func TestGRPCWatchQueueResumes(t *testing.T) {
+	client, store := newTestGRPCClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	category := createCategory(t, store, "payments")
+	desk := openDesk(t, store, category.ID)
+
+	for range 2 {
+		if _, err := client.CreateTicket(ctx, &codav1.CreateTicketRequest{CategoryId: int64(category.ID)}); err != nil {
+			t.Fatalf("CreateTicket() = %v", err)
+		}
+	}
+	if _, err := client.CallNext(ctx, &codav1.CallNextRequest{DeskId: int64(desk.ID)}); err != nil {
+		t.Fatalf("CallNext() = %v", err)
+	}
+
+	history, err := store.TicketEventsSince([]int{category.ID}, 0, 10)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("history = %v, %v, want three events", history, err)
+	}
+
+	// Having seen the first event, the watcher missed the second ticket being
+	// created and the first being called.
+	stream, err := client.WatchQueue(ctx, &codav1.WatchQueueRequest{CategoryIds: []int64{int64(category.ID)}, LastEventId: int64(history[0].ID)})
+	if err != nil {
+		t.Fatalf("WatchQueue() = %v", err)
+	}
+
+	missed := receiveEvents(t, stream, 2)
+	if missed[0].GetId() != int64(history[1].ID) || missed[1].GetId() != int64(history[2].ID) {
+		t.Errorf("missed events = %v, want %d and %d", missed, history[1].ID, history[2].ID)
+	}
+	if missed[1].GetType() != string(types.TicketCalled) || missed[1].GetDeskId() != int64(desk.ID) {
+		t.Errorf("second missed event = %v, want a call to desk %d", missed[1], desk.ID)
+	}
+
+	// Then the stream carries on with live events.
+	closed, err := client.CloseTicket(ctx, &codav1.TicketActionRequest{Id: int64(history[0].TicketID)})
+	if err != nil {
+		t.Fatalf("CloseTicket() = %v", err)
+	}
+
+	live := receiveEvents(t, stream, 1)
+	if live[0].GetTicketId() != closed.GetId() || live[0].GetType() != string(types.TicketClosed) || live[0].GetId() <= missed[1].GetId() {
+		t.Errorf("live event = %v, want ticket %d being closed", live[0], closed.GetId())
+	}
+}
+
+func TestGRPCWatchQueueKeepsLateEvents(t *testing.T) {
+	s, store := newTestServer(t)
+	client := serveGRPC(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	category := createCategory(t, store, "payments")
+	for range 2 {
+		if _, err := client.CreateTicket(ctx, &codav1.CreateTicketRequest{CategoryId: int64(category.ID)}); err != nil {
+			t.Fatalf("CreateTicket() = %v", err)
+		}
+	}
+
+	history, err := store.TicketEventsSince([]int{category.ID}, 0, 10)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("history = %v, %v, want two events", history, err)
+	}
+
+	stream, err := client.WatchQueue(ctx, &codav1.WatchQueueRequest{CategoryIds: []int64{int64(category.ID)}, LastEventId: int64(history[0].ID)})
+	if err != nil {
+		t.Fatalf("WatchQueue() = %v", err)
+	}
+	receiveEvents(t, stream, 1)
+
+	// The replayed event is published again, then two events whose
+	// transactions committed in the opposite order to their IDs.
+	late := types.TicketEvent{TicketID: history[0].TicketID, Type: types.TicketClosed, CategoryID: category.ID, DeskID: -1}
+	s.events.Publish(history[1])
+	late.ID = history[1].ID + 2
+	s.events.Publish(late)
+	late.ID = history[1].ID + 1
+	s.events.Publish(late)
+
+	live := receiveEvents(t, stream, 2)
+	if live[0].GetId() != int64(history[1].ID+2) || live[1].GetId() != int64(history[1].ID+1) {
+		t.Errorf("live events = %v, want %d then %d", live, history[1].ID+2, history[1].ID+1)
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"slices"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+var (
+	errIdempotencyKeyReused     = apiError{"the Idempotency-Key has already been used for a different request"}
+	errIdempotencyKeyInProgress = apiError{"a request with the same Idempotency-Key is still being processed"}
+)
+
+// idempotentMethods are those a retry could otherwise repeat the effect of,
+// such as a second ticket for a kiosk retrying after a network error.
+var idempotentMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// withIdempotency replays the stored response of a request made again with
+// the same Idempotency-Key header, instead of running it twice. A key reused
+// for a different method, path or body is refused, as is a retry made while
+// the first request is still running. Server errors and 429s are not stored,
+// so that the retry is run again once they have passed.
+func (s *APIServer) withIdempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || !slices.Contains(idempotentMethods, r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > 255 {
+			writeJSON(w, http.StatusBadRequest, apiError{"'Idempotency-Key' must be at most 255 characters"}, s.logger)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			err = bodyReadError(err)
+			writeJSON(w, bodyErrorStatus(err), err, s.logger)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		stored, claimed, err := s.storage.BeginIdempotentRequest(key, fingerprint, s.idempotencyWindow)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, err, s.logger)
+			return
+		}
+
+		if !claimed {
+			switch {
+			case stored.Fingerprint != fingerprint:
+				writeJSON(w, http.StatusUnprocessableEntity, errIdempotencyKeyReused, s.logger)
+			case stored.Status == 0:
+				w.Header().Set("Retry-After", "1")
+				writeJSON(w, http.StatusConflict, errIdempotencyKeyInProgress, s.logger)
+			default:
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+			}
+			return
+		}
+
+		// A request that panics is released like one that failed, rather
+		// than holding its key until the claim's lease runs out.
+		defer func() {
+			if p := recover(); p != nil {
+				s.storage.AbandonIdempotentRequest(key)
+				panic(p)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status >= http.StatusInternalServerError || recorder.status == http.StatusTooManyRequests {
+			s.storage.AbandonIdempotentRequest(key)
+			return
+		}
+
+		stored.Status, stored.ContentType, stored.Body = recorder.status, w.Header().Get("Content-Type"), recorder.body.Bytes()
+		s.storage.CompleteIdempotentRequest(stored)
+	})
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
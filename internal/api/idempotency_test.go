@@ -0,0 +1,92 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func idempotentRequest(key string, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/ticket", strings.NewReader(body))
+	r.Header.Set(idempotencyKeyHeader, key)
+	return r
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	runs := 0
+	handler := s.withIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runs++
+		writeJSON(w, http.StatusCreated, map[string]int{"id": runs}, s.logger)
+	}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest("key", `{"category_id":1}`))
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("key", `{"category_id":1}`))
+
+	if runs != 1 {
+		t.Errorf("the request ran %d times, want once", runs)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry got %d %q, want the replayed %d %q", retry.Code, retry.Body, first.Code, first.Body)
+	}
+
+	reused := httptest.NewRecorder()
+	handler.ServeHTTP(reused, idempotentRequest("key", `{"category_id":2}`))
+	if reused.Code != http.StatusUnprocessableEntity {
+		t.Errorf("key reused for another request got %d, want %d", reused.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIdempotencyRefusesRetryWhileRunning(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	var retry *httptest.ResponseRecorder
+	var handler http.Handler
+	handler = s.withIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retry == nil {
+			retry = httptest.NewRecorder()
+			handler.ServeHTTP(retry, idempotentRequest("key", `{}`))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key", `{}`))
+
+	if retry.Code != http.StatusConflict || retry.Header().Get("Retry-After") == "" {
+		t.Errorf("retry while running got %d with Retry-After %q, want a conflict to retry later", retry.Code, retry.Header().Get("Retry-After"))
+	}
+}
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	runs := 0
+	handler := s.withIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runs++
+		if runs == 1 {
+			panic("handler failed")
+		}
+		io.WriteString(w, "done")
+	}))
+
+	func() {
+		defer func() {
+			if p := recover(); p != "handler failed" {
+				t.Errorf("recovered %v, want the handler's panic passed on", p)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key", `{}`))
+	}()
+
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("key", `{}`))
+
+	if runs != 2 || retry.Code != http.StatusOK || retry.Body.String() != "done" {
+		t.Errorf("retry got %d %q after %d runs, want the request run again", retry.Code, retry.Body, runs)
+	}
+}
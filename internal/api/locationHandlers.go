@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// StorageForLocation returns the storage of a single location, which cannot
+// see or change the categories, desks and tickets of any other.
+type StorageForLocation func(locationID int) Storage
+
+type locationContextKey struct{}
+
+var locationSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+var accentColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func (s *APIServer) addLocationRoutes(router *mux.Router) {
+	router.HandleFunc("/locations", makeHTTPHandler(s.handleLocations, []string{http.MethodGet, http.MethodPost}, s.logger))
+}
+
+// addLocationSettingsRoutes adds the routes of a location's own settings to the
+// router of its '/locations/{loc}' prefix.
+func (s *APIServer) addLocationSettingsRoutes(router *mux.Router) {
+	router.HandleFunc("", makeHTTPHandler(s.handleLocation, []string{http.MethodGet, http.MethodPut}, s.logger))
+	router.HandleFunc("/hours", makeHTTPHandler(s.handleLocationHours, []string{http.MethodGet, http.MethodPut}, s.logger))
+}
+
+// withLocation looks up the location named by the '{loc}' route variable, or
+// the default location when the route has none, for the handlers to act on.
+func (s *APIServer) withLocation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slug, ok := mux.Vars(r)["loc"]
+		if !ok {
+			slug = types.DefaultLocation
+		}
+
+		location, err := s.storage.GetLocation(slug)
+		if err != nil {
+			if err == types.ErrnotFound {
+				writeJSON(w, http.StatusNotFound, fmt.Errorf("no location '%s'", slug), s.logger)
+				return
+			}
+			writeJSON(w, http.StatusInternalServerError, err, s.logger)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), locationContextKey{}, location)))
+	})
+}
+
+// locationFromRequest returns the location that withLocation found for the
+// request.
+func locationFromRequest(r *http.Request) types.Location {
+	return locationFromContext(r.Context())
+}
+
+func locationFromContext(ctx context.Context) types.Location {
+	location, _ := ctx.Value(locationContextKey{}).(types.Location)
+	return location
+}
+
+// locationPrefix is the path prefix of the location the request was made
+// under, so that the links given out stay at the same location.
+func locationPrefix(r *http.Request) string {
+	slug, ok := mux.Vars(r)["loc"]
+	if !ok {
+		return ""
+	}
+	return "/locations/" + slug
+}
+
+// store returns the storage of the request's location.
+func (s *APIServer) store(r *http.Request) Storage {
+	return s.storeFor(r.Context())
+}
+
+func (s *APIServer) storeFor(ctx context.Context) Storage {
+	return s.forLocation(locationFromContext(ctx).ID)
+}
+
+func validateLocation(location types.Location) []error {
+	errs := validateStruct(location, nil)
+	if _, err := time.LoadLocation(location.Timezone); err != nil {
+		errs = append(errs, apiError{"'timezone' must be an IANA timezone name"})
+	}
+	if logoURL := location.Branding.LogoURL; logoURL != "" {
+		parsed, err := url.Parse(logoURL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			errs = append(errs, apiError{"'branding.logo_url' must be an http or https URL"})
+		}
+	}
+	if color := location.Branding.AccentColor; color != "" && !accentColorPattern.MatchString(color) {
+		errs = append(errs, apiError{"'branding.accent_color' must be a hex color such as #1a2b3c"})
+	}
+	return errs
+}
+
+func (s *APIServer) listLocations(w http.ResponseWriter, r *http.Request) error {
+	locations, err := s.storage.ListLocations()
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, locations, s.logger)
+}
+
+func (s *APIServer) createLocation(w http.ResponseWriter, r *http.Request) error {
+	var requestBody types.Location
+
+	if err := readJSON(r, &requestBody); err != nil {
+		return writeJSON(w, bodyErrorStatus(err), err, s.logger)
+	}
+
+	if requestBody.Timezone == "" {
+		requestBody.Timezone = "UTC"
+	}
+
+	errs := validateLocation(requestBody)
+	if !locationSlugPattern.MatchString(requestBody.Slug) {
+		errs = append(errs, apiError{"'slug' must be up to 50 lowercase letters, digits and dashes"})
+	}
+	if len(errs) > 0 {
+		return writeJSON(w, http.StatusBadRequest, errs, s.logger)
+	}
+
+	location, err := s.storage.CreateLocation(requestBody)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), pqUniqueConstraintViolation) {
+			return writeJSON(w, http.StatusConflict, apiError{"'slug' must be unique"}, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusCreated, location, s.logger)
+}
+
+func (s *APIServer) getLocation(w http.ResponseWriter, r *http.Request) error {
+	return writeJSON(w, http.StatusOK, locationFromRequest(r), s.logger)
+}
+
+func (s *APIServer) putLocation(w http.ResponseWriter, r *http.Request) error {
+	var requestBody types.Location
+
+	if err := readJSON(r, &requestBody); err != nil {
+		return writeJSON(w, bodyErrorStatus(err), err, s.logger)
+	}
+
+	current := locationFromRequest(r)
+	requestBody.ID, requestBody.Slug = current.ID, current.Slug
+
+	if requestBody.Timezone == "" {
+		requestBody.Timezone = current.Timezone
+	}
+
+	if errs := validateLocation(requestBody); len(errs) > 0 {
+		return writeJSON(w, http.StatusBadRequest, errs, s.logger)
+	}
+
+	location, err := s.storage.UpdateLocation(requestBody)
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, location, s.logger)
+}
+
+func (s *APIServer) getLocationHours(w http.ResponseWriter, r *http.Request) error {
+	hours, err := s.storage.GetLocationHours(locationFromRequest(r).ID)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, hours, s.logger)
+}
+
+func (s *APIServer) putLocationHours(w http.ResponseWriter, r *http.Request) error {
+	var requestBody types.LocationHours
+
+	if err := readJSON(r, &requestBody); err != nil {
+		return writeJSON(w, bodyErrorStatus(err), err, s.logger)
+	}
+
+	location := locationFromRequest(r)
+	requestBody.LocationID = location.ID
+
+	if err := validateOpeningSchedule(location.Timezone, requestBody.OpeningSchedule); err != nil {
+		return writeJSON(w, http.StatusBadRequest, err, s.logger)
+	}
+
+	hours, err := s.storage.SetLocationHours(requestBody)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, hours, s.logger)
+}
+
+func (s *APIServer) handleLocations(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.listLocations(w, r)
+	case http.MethodPost:
+		return s.createLocation(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
+
+func (s *APIServer) handleLocation(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.getLocation(w, r)
+	case http.MethodPut:
+		return s.putLocation(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
+
+func (s *APIServer) handleLocationHours(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.getLocationHours(w, r)
+	case http.MethodPut:
+		return s.putLocationHours(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
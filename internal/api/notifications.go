@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/mail"
+	"regexp"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/safehttp"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const maxNotifyAhead = 50
+
+// phonePattern accepts numbers in E.164 format, which is what SMS gateways
+// expect.
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// validateContact checks the contact details given with a new ticket against
+// the channels that are configured, normalising the email address.
+func (s *APIServer) validateContact(contact *types.TicketContact) []error {
+	var errs []error
+
+	if contact.Email == "" && contact.Phone == "" && contact.WebhookURL == "" {
+		errs = append(errs, apiError{"'contact' must include at least one of 'email', 'phone' or 'webhook_url'"})
+	}
+
+	if contact.Email != "" {
+		if !s.notifiers.Enabled(types.ChannelEmail) {
+			errs = append(errs, apiError{"email notifications are not available"})
+		} else if address, err := mail.ParseAddress(contact.Email); err != nil {
+			errs = append(errs, apiError{"'email' must be an email address"})
+		} else {
+			contact.Email = address.Address
+		}
+	}
+
+	if contact.Phone != "" {
+		if !s.notifiers.Enabled(types.ChannelSMS) {
+			errs = append(errs, apiError{"SMS notifications are not available"})
+		} else if !phonePattern.MatchString(contact.Phone) {
+			errs = append(errs, apiError{"'phone' must be in international format, e.g. +441234567890"})
+		}
+	}
+
+	if contact.WebhookURL != "" {
+		if !s.notifiers.Enabled(types.ChannelWebhook) {
+			errs = append(errs, apiError{"webhook notifications are not available"})
+		} else if !safehttp.ValidURL(contact.WebhookURL) {
+			errs = append(errs, apiError{"'webhook_url' must be a public https URL"})
+		}
+	}
+
+	if contact.NotifyAhead < 0 || contact.NotifyAhead > maxNotifyAhead {
+		errs = append(errs, apiError{"'notify_ahead' must be between 0 and 50"})
+	}
+
+	return errs
+}
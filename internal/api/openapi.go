@@ -0,0 +1,828 @@
+package api
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// openAPIOperation documents one method of a route. Path is the route's mux
+// template, whose variables become the path parameters. Operations are scoped
+// to a location unless root is set, and so are also served under the
+// '/locations/{loc}' prefix. The request body is sent as requestType, JSON by
+// default.
+type openAPIOperation struct {
+	method      string
+	path        string
+	tag         string
+	summary     string
+	params      []openAPIParam
+	request     any
+	requestType string
+	responses   []openAPIResponse
+	root        bool
+}
+
+type openAPIParam struct {
+	in          string
+	name        string
+	description string
+	schemaType  string
+}
+
+// openAPIResponse is a response of an operation. Body is a value of the type
+// the response is encoded from, and is sent as contentType, JSON by default.
+// Error responses leave body nil.
+type openAPIResponse struct {
+	status      int
+	description string
+	body        any
+	contentType string
+}
+
+func query(name, description, schemaType string) openAPIParam {
+	return openAPIParam{in: "query", name: name, description: description, schemaType: schemaType}
+}
+
+func header(name, description string) openAPIParam {
+	return openAPIParam{in: "header", name: name, description: description, schemaType: "string"}
+}
+
+func respond(status int, description string, body any) openAPIResponse {
+	return openAPIResponse{status: status, description: description, body: body}
+}
+
+func respondWith(status int, description, contentType string) openAPIResponse {
+	return openAPIResponse{status: status, description: description, contentType: contentType}
+}
+
+func fail(status int, description string) openAPIResponse {
+	return openAPIResponse{status: status, description: description}
+}
+
+var (
+	staffHeader    = header("X-Staff-ID", "the staff member recorded in the ticket history")
+	categoryFilter = query("category_id", "comma separated IDs of the categories to include, every category when left out", "string")
+	badID          = fail(http.StatusBadRequest, "the ID is not a number")
+	badBody        = fail(http.StatusBadRequest, "the request body is not valid")
+	ifMatchParam   = header(ifMatchHeader, "the ETag of the version being changed, as given by GET, or '*' to change whatever the version")
+	staleVersion   = fail(http.StatusPreconditionFailed, "the If-Match header does not match the current version")
+	noIfMatch      = fail(http.StatusPreconditionRequired, "the If-Match header is missing")
+	notMergePatch  = fail(http.StatusUnsupportedMediaType, "the patch is not sent as '"+mergePatchContentType+"'")
+)
+
+// apiOperations documents every route of the REST API. The tests check with
+// undocumentedRoutes that none are left out.
+func apiOperations() []openAPIOperation {
+	return []openAPIOperation{
+		{method: http.MethodGet, path: "/openapi.json", tag: "meta", summary: "This document", root: true,
+			responses: []openAPIResponse{respondWith(http.StatusOK, "the OpenAPI document of the REST API", "application/json")}},
+
+		{method: http.MethodGet, path: "/locations", tag: "locations", summary: "List the locations", root: true,
+			responses: []openAPIResponse{respond(http.StatusOK, "the locations", []types.Location{})}},
+		{method: http.MethodPost, path: "/locations", tag: "locations", summary: "Create a location", root: true,
+			request: types.Location{},
+			responses: []openAPIResponse{
+				respond(http.StatusCreated, "the new location", types.Location{}),
+				fail(http.StatusBadRequest, "the location is not valid"),
+				fail(http.StatusConflict, "the slug is already taken"),
+			}},
+		{method: http.MethodGet, path: "/locations/{loc}", tag: "locations", summary: "Get a location", root: true,
+			responses: []openAPIResponse{respond(http.StatusOK, "the location", types.Location{}), fail(http.StatusNotFound, "no such location")}},
+		{method: http.MethodPut, path: "/locations/{loc}", tag: "locations", summary: "Update a location; its slug cannot be changed", root: true,
+			request: types.Location{},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the updated location", types.Location{}),
+				fail(http.StatusBadRequest, "the location is not valid"),
+				fail(http.StatusNotFound, "no such location"),
+			}},
+		{method: http.MethodGet, path: "/locations/{loc}/hours", tag: "locations", summary: "Get the opening hours of a location", root: true,
+			responses: []openAPIResponse{respond(http.StatusOK, "the opening hours", types.LocationHours{}), fail(http.StatusNotFound, "no such location")}},
+		{method: http.MethodPut, path: "/locations/{loc}/hours", tag: "locations", summary: "Set the opening hours of a location", root: true,
+			request: types.LocationHours{},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the opening hours", types.LocationHours{}),
+				fail(http.StatusBadRequest, "the hours are not valid"),
+				fail(http.StatusNotFound, "no such location"),
+			}},
+
+		{method: http.MethodGet, path: "/internal/webhooks", tag: "webhooks", summary: "List the webhook subscriptions",
+			responses: []openAPIResponse{respond(http.StatusOK, "the subscriptions, without their secrets", []types.WebhookSubscription{})}},
+		{method: http.MethodPost, path: "/internal/webhooks", tag: "webhooks", summary: "Subscribe a URL to events",
+			request: webhookRequest{},
+			responses: []openAPIResponse{
+				respond(http.StatusCreated, "the subscription, with the only copy of its signing secret", types.WebhookSubscription{}),
+				fail(http.StatusBadRequest, "the subscription is not valid"),
+			}},
+		{method: http.MethodGet, path: "/internal/webhooks/{id}", tag: "webhooks", summary: "Get a webhook subscription",
+			responses: []openAPIResponse{respond(http.StatusOK, "the subscription", types.WebhookSubscription{}), badID, fail(http.StatusNotFound, "no such subscription")}},
+		{method: http.MethodPut, path: "/internal/webhooks/{id}", tag: "webhooks", summary: "Update a webhook subscription",
+			request: webhookRequest{},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the subscription", types.WebhookSubscription{}),
+				fail(http.StatusBadRequest, "the subscription is not valid, or tries to change its secret"),
+				fail(http.StatusNotFound, "no such subscription"),
+			}},
+		{method: http.MethodDelete, path: "/internal/webhooks/{id}", tag: "webhooks", summary: "Delete a webhook subscription",
+			responses: []openAPIResponse{fail(http.StatusNoContent, "the subscription was deleted"), badID, fail(http.StatusNotFound, "no such subscription")}},
+		{method: http.MethodGet, path: "/internal/webhooks/{id}/deliveries", tag: "webhooks", summary: "List the latest deliveries of a subscription",
+			responses: []openAPIResponse{respond(http.StatusOK, "the deliveries, newest first", []types.WebhookDelivery{}), badID, fail(http.StatusNotFound, "no such subscription")}},
+		{method: http.MethodGet, path: "/internal/webhook-deliveries/{id}", tag: "webhooks", summary: "Get a delivery with the log of its attempts",
+			responses: []openAPIResponse{respond(http.StatusOK, "the delivery", types.WebhookDelivery{}), badID, fail(http.StatusNotFound, "no such delivery")}},
+		{method: http.MethodPost, path: "/internal/webhook-deliveries/{id}/redeliver", tag: "webhooks", summary: "Send a delivery again",
+			responses: []openAPIResponse{respond(http.StatusAccepted, "the delivery, queued to be sent", types.WebhookDelivery{}), badID, fail(http.StatusNotFound, "no such delivery")}},
+
+		{method: http.MethodPost, path: "/ticket", tag: "tickets", summary: "Take a ticket",
+			params:  []openAPIParam{header("X-Device-Token", "identifies the kiosk or device for the per client ticket limit")},
+			request: createTicketRequest{},
+			responses: []openAPIResponse{
+				respond(http.StatusCreated, "the new ticket with its place in the queue", ticketResponse{}),
+				fail(http.StatusBadRequest, "the category does not exist or the contact details are not valid"),
+				fail(http.StatusConflict, "the category is not issuing tickets or its queue is full"),
+				fail(http.StatusTooManyRequests, "the client has taken too many tickets, try again after Retry-After seconds"),
+			}},
+		{method: http.MethodGet, path: "/ticket/{id}", tag: "tickets", summary: "Get a ticket",
+			responses: []openAPIResponse{respond(http.StatusOK, "the ticket with its place in the queue", ticketResponse{}), badID, fail(http.StatusNotFound, "no such ticket")}},
+		{method: http.MethodDelete, path: "/ticket/{id}", tag: "tickets", summary: "Delete a ticket",
+			params:    []openAPIParam{staffHeader},
+			responses: []openAPIResponse{fail(http.StatusNoContent, "the ticket was deleted"), badID, fail(http.StatusNotFound, "no such ticket")}},
+
+		{method: http.MethodGet, path: "/category", tag: "categories", summary: "List the categories",
+			responses: []openAPIResponse{respond(http.StatusOK, "the categories", []types.Category{})}},
+		{method: http.MethodPost, path: "/category", tag: "categories", summary: "Create a category",
+			request:   categoryRequest{},
+			responses: []openAPIResponse{respond(http.StatusCreated, "the new category", types.Category{}), badBody}},
+		{method: http.MethodGet, path: "/category/{id}", tag: "categories", summary: "Get a category with the state of its queue",
+			responses: []openAPIResponse{respond(http.StatusOK, "the category, with its version as the ETag", categoryResponse{}), badID, fail(http.StatusNotFound, "no such category")}},
+		{method: http.MethodPut, path: "/category/{id}", tag: "categories", summary: "Replace a category, renaming it",
+			params:  []openAPIParam{ifMatchParam},
+			request: categoryRequest{},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the category, with its new version as the ETag", types.Category{}),
+				fail(http.StatusBadRequest, "the name is missing or taken, or the If-Match header is not valid"),
+				fail(http.StatusNotFound, "no such category"),
+				staleVersion,
+				noIfMatch,
+			}},
+		{method: http.MethodPatch, path: "/category/{id}", tag: "categories", summary: "Rename a category with a JSON merge patch",
+			params:      []openAPIParam{ifMatchParam},
+			request:     categoryPatch{},
+			requestType: mergePatchContentType,
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the category, with its new version as the ETag", types.Category{}),
+				fail(http.StatusBadRequest, "the patch changes nothing, removes a member or is not valid, the name is taken, or the If-Match header is not valid"),
+				fail(http.StatusNotFound, "no such category"),
+				staleVersion,
+				noIfMatch,
+				notMergePatch,
+			}},
+		{method: http.MethodDelete, path: "/category/{id}", tag: "categories", summary: "Delete a category",
+			params: []openAPIParam{ifMatchParam},
+			responses: []openAPIResponse{
+				fail(http.StatusNoContent, "the category was deleted"),
+				fail(http.StatusBadRequest, "the category cannot be deleted or the If-Match header is not valid"),
+				fail(http.StatusNotFound, "no such category"),
+				staleVersion,
+				noIfMatch,
+			}},
+		{method: http.MethodGet, path: "/category/{id}/settings", tag: "categories", summary: "Get the queue policies of a category",
+			responses: []openAPIResponse{respond(http.StatusOK, "the settings", types.CategorySettings{}), badID, fail(http.StatusNotFound, "no such category")}},
+		{method: http.MethodPut, path: "/category/{id}/settings", tag: "categories", summary: "Set the queue policies of a category, leaving intake_closed to the intake endpoint",
+			request: types.CategorySettings{},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the settings", types.CategorySettings{}),
+				fail(http.StatusBadRequest, "the settings are not valid"),
+				fail(http.StatusNotFound, "no such category"),
+			}},
+		{method: http.MethodGet, path: "/category/{id}/hours", tag: "categories", summary: "Get the opening hours of a category",
+			responses: []openAPIResponse{respond(http.StatusOK, "the opening hours", types.CategoryHours{}), badID, fail(http.StatusNotFound, "no such category")}},
+		{method: http.MethodPut, path: "/category/{id}/hours", tag: "categories", summary: "Set the opening hours of a category",
+			request: types.CategoryHours{},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the opening hours", types.CategoryHours{}),
+				fail(http.StatusBadRequest, "the hours are not valid"),
+				fail(http.StatusNotFound, "no such category"),
+			}},
+		{method: http.MethodPut, path: "/category/{id}/intake", tag: "categories", summary: "Stop or resume issuing tickets",
+			request:   categoryIntakeRequest{},
+			responses: []openAPIResponse{respond(http.StatusOK, "the settings", types.CategorySettings{}), badBody, fail(http.StatusNotFound, "no such category")}},
+
+		{method: http.MethodGet, path: "/desk", tag: "desks", summary: "List the desks",
+			responses: []openAPIResponse{respond(http.StatusOK, "the desks", []types.Desk{})}},
+		{method: http.MethodPost, path: "/desk", tag: "desks", summary: "Create a desk",
+			request:   deskRequest{},
+			responses: []openAPIResponse{respond(http.StatusCreated, "the new desk", types.Desk{}), fail(http.StatusBadRequest, "the label or category is missing")}},
+		{method: http.MethodGet, path: "/desk/{id}", tag: "desks", summary: "Get a desk",
+			responses: []openAPIResponse{respond(http.StatusOK, "the desk, with its version as the ETag", types.Desk{}), badID, fail(http.StatusNotFound, "no such desk")}},
+		{method: http.MethodPut, path: "/desk/{id}", tag: "desks", summary: "Replace the label and category of a desk",
+			params:  []openAPIParam{ifMatchParam},
+			request: deskRequest{},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the desk, with its new version as the ETag", types.Desk{}),
+				fail(http.StatusBadRequest, "the label or category is missing or not valid, or the If-Match header is not valid"),
+				fail(http.StatusNotFound, "no such desk"),
+				staleVersion,
+				noIfMatch,
+			}},
+		{method: http.MethodPatch, path: "/desk/{id}", tag: "desks", summary: "Change the label or category of a desk with a JSON merge patch",
+			params:      []openAPIParam{ifMatchParam},
+			request:     deskPatch{},
+			requestType: mergePatchContentType,
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the desk, with its new version as the ETag", types.Desk{}),
+				fail(http.StatusBadRequest, "the patch changes nothing, removes a member or is not valid, or the If-Match header is not valid"),
+				fail(http.StatusNotFound, "no such desk"),
+				staleVersion,
+				noIfMatch,
+				notMergePatch,
+			}},
+		{method: http.MethodDelete, path: "/desk/{id}", tag: "desks", summary: "Delete a desk",
+			params: []openAPIParam{ifMatchParam},
+			responses: []openAPIResponse{
+				fail(http.StatusNoContent, "the desk was deleted"),
+				fail(http.StatusBadRequest, "the desk cannot be deleted or the If-Match header is not valid"),
+				fail(http.StatusNotFound, "no such desk"),
+				staleVersion,
+				noIfMatch,
+			}},
+
+		{method: http.MethodGet, path: "/internal/next", tag: "staff", summary: "See the ticket that would be called next in a category",
+			params: []openAPIParam{query("category_id", "the category", "integer")},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the next ticket", types.Ticket{}),
+				fail(http.StatusBadRequest, "the category is missing or does not exist"),
+				fail(http.StatusNotFound, "no tickets waiting"),
+			}},
+		{method: http.MethodPut, path: "/internal/next", tag: "staff", summary: "Call the next ticket to a desk",
+			params:  []openAPIParam{staffHeader},
+			request: callNextRequest{},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the called ticket", types.Ticket{}),
+				fail(http.StatusBadRequest, "the desk does not exist"),
+				fail(http.StatusNotFound, "no tickets waiting"),
+				fail(http.StatusConflict, "the desk is closed or paused"),
+			}},
+		{method: http.MethodGet, path: "/internal/queue", tag: "staff", summary: "List the waiting tickets in the order they will be called",
+			params:    []openAPIParam{categoryFilter},
+			responses: []openAPIResponse{respond(http.StatusOK, "the queue", []queueEntry{}), fail(http.StatusBadRequest, "a category ID is not a number")}},
+		{method: http.MethodGet, path: "/internal/tickets/{id}/history", tag: "staff", summary: "Get the history of a ticket",
+			responses: []openAPIResponse{respond(http.StatusOK, "the ticket's events, oldest first", []types.TicketEvent{}), badID, fail(http.StatusNotFound, "no such ticket")}},
+		{method: http.MethodPut, path: "/internal/tickets/{id}/recall", tag: "staff", summary: "Call a ticket to its desk again",
+			params:    []openAPIParam{staffHeader},
+			responses: ticketActionResponses("the recalled ticket")},
+		{method: http.MethodPut, path: "/internal/tickets/{id}/serve", tag: "staff", summary: "Start serving the customer of a called ticket",
+			params:    []openAPIParam{staffHeader},
+			responses: ticketActionResponses("the ticket being served")},
+		{method: http.MethodPut, path: "/internal/tickets/{id}/transfer", tag: "staff", summary: "Move a ticket to the queue of another category",
+			params:    []openAPIParam{staffHeader},
+			request:   transferTicketRequest{},
+			responses: ticketActionResponses("the transferred ticket")},
+		{method: http.MethodPut, path: "/internal/tickets/{id}/close", tag: "staff", summary: "Close a ticket once it has been served",
+			params:    []openAPIParam{staffHeader},
+			responses: ticketActionResponses("the closed ticket")},
+		{method: http.MethodPut, path: "/internal/tickets/{id}/no-show", tag: "staff", summary: "Give up on the customer of a called ticket",
+			params:    []openAPIParam{staffHeader},
+			responses: ticketActionResponses("the ticket, closed or back in the queue")},
+		{method: http.MethodGet, path: "/internal/desks/{id}/tickets", tag: "staff", summary: "List the open tickets called to a desk",
+			responses: []openAPIResponse{respond(http.StatusOK, "the tickets", []queueEntry{}), badID, fail(http.StatusNotFound, "no such desk")}},
+		{method: http.MethodGet, path: "/internal/desks/{id}/session", tag: "staff", summary: "Get the session of a desk",
+			responses: []openAPIResponse{respond(http.StatusOK, "the open session, or a closed one when the desk is not staffed", types.DeskSession{}), badID, fail(http.StatusNotFound, "no such desk")}},
+		{method: http.MethodPost, path: "/internal/desks/{id}/session", tag: "staff", summary: "Sign in to a desk",
+			params: []openAPIParam{staffHeader},
+			responses: []openAPIResponse{
+				respond(http.StatusCreated, "the session, with the only copy of the token for the desk's WebSocket", types.DeskSession{}),
+				fail(http.StatusBadRequest, "the X-Staff-ID header is missing"),
+				fail(http.StatusNotFound, "no such desk"),
+				fail(http.StatusConflict, "the desk already has an open session"),
+			}},
+		{method: http.MethodPut, path: "/internal/desks/{id}/session", tag: "staff", summary: "Pause or resume a desk",
+			request: deskSessionStateRequest{},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the session", types.DeskSession{}),
+				fail(http.StatusBadRequest, "the state is not valid"),
+				fail(http.StatusNotFound, "no such desk"),
+				fail(http.StatusConflict, "the desk is closed"),
+			}},
+		{method: http.MethodDelete, path: "/internal/desks/{id}/session", tag: "staff", summary: "Sign out of a desk",
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the closed session", types.DeskSession{}),
+				badID,
+				fail(http.StatusNotFound, "no such desk"),
+				fail(http.StatusConflict, "the desk is already closed"),
+			}},
+		{method: http.MethodGet, path: "/internal/ws", tag: "staff", summary: "Follow a desk's queue and send it commands over a WebSocket",
+			params: []openAPIParam{
+				header("Authorization", "'Bearer' followed by the token given when the desk session was opened"),
+				query("token", "the desk session token, for clients which cannot set headers", "string"),
+				query("last_event_id", "the last event seen before reconnecting, to be sent those missed", "integer"),
+			},
+			responses: []openAPIResponse{
+				fail(http.StatusSwitchingProtocols, "the connection is upgraded to a WebSocket"),
+				fail(http.StatusBadRequest, "last_event_id is not valid"),
+				fail(http.StatusUnauthorized, "the token is missing or its session is not open"),
+			}},
+		{method: http.MethodPost, path: "/internal/appointments/slots", tag: "appointments", summary: "Create an appointment slot",
+			request: appointmentSlotRequest{},
+			responses: []openAPIResponse{
+				respond(http.StatusCreated, "the new slot", types.AppointmentSlot{}),
+				fail(http.StatusBadRequest, "the slot is not valid or its category does not exist"),
+			}},
+		{method: http.MethodDelete, path: "/internal/appointments/slots/{id}", tag: "appointments", summary: "Delete an appointment slot",
+			responses: []openAPIResponse{fail(http.StatusNoContent, "the slot was deleted"), fail(http.StatusBadRequest, "the slot cannot be deleted")}},
+		{method: http.MethodGet, path: "/internal/reports/categories", tag: "reports", summary: "Report on the tickets of each category",
+			params:    reportParams(),
+			responses: reportResponses()},
+		{method: http.MethodGet, path: "/internal/reports/desks", tag: "reports", summary: "Report on the tickets served at each desk",
+			params:    reportParams(),
+			responses: reportResponses()},
+		{method: http.MethodGet, path: "/internal/config", tag: "config", summary: "Export the categories and desks of the location as YAML",
+			responses: []openAPIResponse{{status: http.StatusOK, description: "the config, in the form it is applied", body: types.LocationConfig{}, contentType: "application/yaml"}}},
+		{method: http.MethodPut, path: "/internal/config", tag: "config", summary: "Create, update and optionally delete categories and desks to match a YAML config",
+			params: []openAPIParam{
+				query("prune", "delete the categories and desks the config leaves out", "boolean"),
+				query("dry_run", "list the changes without making them", "boolean"),
+			},
+			request:     types.LocationConfig{},
+			requestType: "application/yaml",
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the changes, in the order they were made", types.ConfigPlan{}),
+				fail(http.StatusBadRequest, "the config is not valid"),
+				fail(http.StatusConflict, "a category or desk to be pruned still has tickets"),
+			}},
+
+		{method: http.MethodGet, path: "/t/{sub_url}", tag: "customers", summary: "Follow a ticket",
+			responses: []openAPIResponse{respond(http.StatusOK, "the ticket with its place in the queue", ticketResponse{}), fail(http.StatusNotFound, "no such ticket")}},
+		{method: http.MethodPost, path: "/t/{sub_url}/cancel", tag: "customers", summary: "Leave the queue",
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the cancelled ticket", types.Ticket{}),
+				fail(http.StatusNotFound, "no such ticket"),
+				fail(http.StatusConflict, "the ticket is not waiting"),
+			}},
+		{method: http.MethodPost, path: "/t/{sub_url}/snooze", tag: "customers", summary: "Let the people behind go first",
+			request: snoozeRequest{},
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the ticket with its new place in the queue", ticketResponse{}),
+				fail(http.StatusBadRequest, "the snooze is not valid"),
+				fail(http.StatusNotFound, "no such ticket"),
+				fail(http.StatusConflict, "the ticket is not waiting or has been snoozed too often"),
+			}},
+		{method: http.MethodGet, path: "/t/{sub_url}/qr.png", tag: "customers", summary: "QR code of a ticket's page",
+			responses: []openAPIResponse{respondWith(http.StatusOK, "the QR code", "image/png"), fail(http.StatusNotFound, "no such ticket"), fail(http.StatusServiceUnavailable, "PUBLIC_BASE_URL is not set")}},
+		{method: http.MethodGet, path: "/t/{sub_url}/qr.svg", tag: "customers", summary: "QR code of a ticket's page",
+			responses: []openAPIResponse{respondWith(http.StatusOK, "the QR code", "image/svg+xml"), fail(http.StatusNotFound, "no such ticket"), fail(http.StatusServiceUnavailable, "PUBLIC_BASE_URL is not set")}},
+		{method: http.MethodGet, path: "/t/{sub_url}/print.html", tag: "customers", summary: "Printable ticket",
+			responses: []openAPIResponse{respondWith(http.StatusOK, "the ticket as a page for receipt printers", "text/html"), fail(http.StatusNotFound, "no such ticket"), fail(http.StatusServiceUnavailable, "PUBLIC_BASE_URL is not set")}},
+		{method: http.MethodGet, path: "/t/{sub_url}/print.escpos", tag: "customers", summary: "Printable ticket",
+			responses: []openAPIResponse{respondWith(http.StatusOK, "the ticket as ESC/POS commands", "application/octet-stream"), fail(http.StatusNotFound, "no such ticket"), fail(http.StatusServiceUnavailable, "PUBLIC_BASE_URL is not set")}},
+
+		{method: http.MethodGet, path: "/appointments/slots", tag: "appointments", summary: "List the appointment slots of a category",
+			params: []openAPIParam{
+				query("category_id", "the category", "integer"),
+				query("from", "RFC 3339 start of the listing, now by default", "string"),
+				query("to", "RFC 3339 end of the listing, a week after from by default", "string"),
+			},
+			responses: []openAPIResponse{respond(http.StatusOK, "the slots", []types.AppointmentSlot{}), fail(http.StatusBadRequest, "the category, from or to is not valid")}},
+		{method: http.MethodPost, path: "/appointments", tag: "appointments", summary: "Book an appointment",
+			request: bookAppointmentRequest{},
+			responses: []openAPIResponse{
+				respond(http.StatusCreated, "the appointment", types.Appointment{}),
+				fail(http.StatusBadRequest, "the slot or name is missing"),
+				fail(http.StatusNotFound, "no such slot"),
+				fail(http.StatusConflict, "the slot has started or is full"),
+			}},
+		{method: http.MethodGet, path: "/appointments/{sub_url}", tag: "appointments", summary: "Get an appointment",
+			responses: []openAPIResponse{respond(http.StatusOK, "the appointment", types.Appointment{}), fail(http.StatusNotFound, "no such appointment")}},
+		{method: http.MethodDelete, path: "/appointments/{sub_url}", tag: "appointments", summary: "Cancel an appointment",
+			responses: []openAPIResponse{
+				respond(http.StatusOK, "the cancelled appointment", types.Appointment{}),
+				fail(http.StatusNotFound, "no such appointment"),
+				fail(http.StatusConflict, "the appointment is not booked"),
+			}},
+		{method: http.MethodPost, path: "/appointments/{sub_url}/check-in", tag: "appointments", summary: "Check in for an appointment, joining the queue",
+			responses: []openAPIResponse{
+				respond(http.StatusCreated, "the ticket with its place in the queue", ticketResponse{}),
+				fail(http.StatusNotFound, "no such appointment"),
+				fail(http.StatusConflict, "the appointment is not booked or check in has not opened"),
+			}},
+		{method: http.MethodGet, path: "/appointments/{sub_url}/qr.png", tag: "appointments", summary: "QR code of an appointment's page",
+			responses: []openAPIResponse{respondWith(http.StatusOK, "the QR code", "image/png"), fail(http.StatusNotFound, "no such appointment"), fail(http.StatusServiceUnavailable, "PUBLIC_BASE_URL is not set")}},
+		{method: http.MethodGet, path: "/appointments/{sub_url}/qr.svg", tag: "appointments", summary: "QR code of an appointment's page",
+			responses: []openAPIResponse{respondWith(http.StatusOK, "the QR code", "image/svg+xml"), fail(http.StatusNotFound, "no such appointment"), fail(http.StatusServiceUnavailable, "PUBLIC_BASE_URL is not set")}},
+
+		{method: http.MethodGet, path: "/events", tag: "live", summary: "Stream ticket events as server-sent events named after their type",
+			params: []openAPIParam{categoryFilter},
+			responses: []openAPIResponse{
+				respondWith(http.StatusOK, "the event stream, each event's data being a ticket event", "text/event-stream"),
+				fail(http.StatusBadRequest, "a category is not valid or not at this location"),
+			}},
+		{method: http.MethodGet, path: "/display/state", tag: "live", summary: "What the lobby screens show",
+			params:    []openAPIParam{categoryFilter, query("limit", "how many recent calls to include, between 1 and 50", "integer")},
+			responses: []openAPIResponse{respond(http.StatusOK, "the recent calls and the queue of each category", displayState{}), fail(http.StatusBadRequest, "a category or the limit is not valid")}},
+		{method: http.MethodGet, path: "/display", tag: "live", summary: "The lobby display page and its assets",
+			responses: []openAPIResponse{respondWith(http.StatusOK, "the page", "text/html")}},
+		{method: http.MethodGet, path: "/console", tag: "live", summary: "The desk console page and its assets",
+			responses: []openAPIResponse{respondWith(http.StatusOK, "the page", "text/html")}},
+	}
+}
+
+func ticketActionResponses(description string) []openAPIResponse {
+	return []openAPIResponse{
+		respond(http.StatusOK, description, types.Ticket{}),
+		badID,
+		fail(http.StatusNotFound, "no such ticket"),
+		fail(http.StatusConflict, "the ticket is not in a state the action applies to"),
+	}
+}
+
+func reportParams() []openAPIParam {
+	return []openAPIParam{
+		query("from", "RFC 3339 time or date the report starts at, seven days ago by default", "string"),
+		query("to", "RFC 3339 time or date the report ends at, now by default", "string"),
+		query("interval", "'hour' or 'day', the default", "string"),
+		query("timezone", "IANA timezone the periods and dates are in, that of the location by default", "string"),
+		query("format", "'csv' for a CSV file instead of JSON", "string"),
+		categoryFilter,
+	}
+}
+
+func reportResponses() []openAPIResponse {
+	return []openAPIResponse{
+		respond(http.StatusOK, "a row for each period, as JSON or as CSV when asked for", []types.ReportRow{}),
+		fail(http.StatusBadRequest, "the report's parameters are not valid"),
+	}
+}
+
+// locationPathPrefix is the prefix the routes of a location are served under
+// besides the unprefixed routes of the default location.
+const locationPathPrefix = "/locations/{loc}"
+
+var pathVariablePattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// openAPISchemaEnums lists the values of the string types which are enums.
+var openAPISchemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(types.DeskSessionState("")):      {string(types.DeskOpen), string(types.DeskPaused), string(types.DeskClosed)},
+	reflect.TypeOf(types.AppointmentStatus("")):     {string(types.AppointmentBooked), string(types.AppointmentCheckedIn), string(types.AppointmentCancelled)},
+	reflect.TypeOf(types.WebhookDeliveryStatus("")): {string(types.WebhookPending), string(types.WebhookDelivered), string(types.WebhookFailed)},
+	reflect.TypeOf(types.ConfigAction("")):          {string(types.ConfigCreate), string(types.ConfigUpdate), string(types.ConfigDelete)},
+	reflect.TypeOf(types.ConfigKind("")):            {string(types.ConfigLocation), string(types.ConfigCategory), string(types.ConfigDesk)},
+	reflect.TypeOf(types.TicketEventType("")): {
+		string(types.TicketCreated), string(types.TicketCalled), string(types.TicketRecalled), string(types.TicketServing),
+		string(types.TicketTransferred), string(types.TicketClosed), string(types.TicketDeleted), string(types.TicketCancelled),
+		string(types.TicketSnoozed), string(types.TicketNoShow), string(types.TicketRequeued), string(types.TicketCheckedIn),
+	},
+}
+
+// openAPISchemas builds the schemas of the request and response types,
+// naming each struct after its Go type.
+type openAPISchemas map[string]any
+
+func (o openAPISchemas) schemaOf(t reflect.Type) map[string]any {
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == reflect.TypeOf(json.RawMessage{}):
+		return map[string]any{}
+	}
+
+	if enum, ok := openAPISchemaEnums[t]; ok {
+		return map[string]any{"type": "string", "enum": enum}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := o.schemaOf(t.Elem())
+		if _, isRef := schema["$ref"]; !isRef {
+			schema["nullable"] = true
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": o.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": o.schemaOf(t.Elem())}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, ok := o[name]; !ok {
+			// Registered before its fields so that recursive types terminate.
+			o[name] = nil
+			properties := map[string]any{}
+			schema := map[string]any{"type": "object", "properties": properties}
+			if required := o.addProperties(t, properties); len(required) > 0 {
+				schema["required"] = required
+			}
+			o[name] = schema
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+// addProperties adds the JSON fields of a struct to properties, flattening
+// embedded structs as encoding/json does, and returns those its validate tags
+// require.
+func (o openAPISchemas) addProperties(t reflect.Type, properties map[string]any) []string {
+	var required []string
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			required = append(required, o.addProperties(field.Type, properties)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := o.schemaOf(field.Type)
+		if describeRules(schema, field.Tag.Get("validate")) {
+			required = append(required, name)
+		}
+		properties[name] = schema
+	}
+
+	return required
+}
+
+// describeRules adds the bounds and choices of a field's validate tag to its
+// schema, reporting whether the field is required.
+func describeRules(schema map[string]any, rules string) bool {
+	if rules == "" {
+		return false
+	}
+	if _, isRef := schema["$ref"]; isRef {
+		return strings.Contains(rules, "required")
+	}
+
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		key, arg, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "min", "max":
+			n, _ := strconv.Atoi(arg)
+			switch schema["type"] {
+			case "string":
+				schema[key+"Length"] = n
+			case "array":
+				schema[key+"Items"] = n
+			case "integer":
+				schema[key+"imum"] = n
+			}
+		case "oneof":
+			schema["enum"] = strings.Split(arg, "|")
+		}
+	}
+	return required
+}
+
+// schemaName is the exported form of a type's name, so that the unexported
+// request and response types of this package read like the others.
+func schemaName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
+
+func (o openAPISchemas) content(contentType string, body any) map[string]any {
+	media := map[string]any{}
+	if body != nil {
+		media["schema"] = o.schemaOf(reflect.TypeOf(body))
+	}
+	return map[string]any{contentType: media}
+}
+
+func (op openAPIOperation) document(schemas openAPISchemas) map[string]any {
+	var parameters []any
+	for _, match := range pathVariablePattern.FindAllStringSubmatch(op.path, -1) {
+		schemaType := "string"
+		if match[1] == "id" {
+			schemaType = "integer"
+		}
+		parameters = append(parameters, map[string]any{
+			"in":       "path",
+			"name":     match[1],
+			"required": true,
+			"schema":   map[string]any{"type": schemaType},
+		})
+	}
+	for _, param := range op.params {
+		parameters = append(parameters, map[string]any{
+			"in":          param.in,
+			"name":        param.name,
+			"description": param.description,
+			"schema":      map[string]any{"type": param.schemaType},
+		})
+	}
+	if slices.Contains(idempotentMethods, op.method) {
+		parameters = append(parameters, map[string]any{
+			"in":          "header",
+			"name":        idempotencyKeyHeader,
+			"description": "a unique key for the request, so that retries of it are given its response instead of being run again",
+			"schema":      map[string]any{"type": "string", "maxLength": 255},
+		})
+	}
+
+	responses := map[string]any{}
+	for _, response := range op.responses {
+		document := map[string]any{"description": response.description}
+
+		switch {
+		case response.body != nil:
+			document["content"] = schemas.content(cmp.Or(response.contentType, "application/json"), response.body)
+		case response.contentType != "":
+			document["content"] = map[string]any{response.contentType: map[string]any{}}
+		case response.status >= http.StatusBadRequest:
+			document["content"] = map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Error"}}}
+		}
+
+		responses[statusKey(response.status)] = document
+	}
+
+	if op.request != nil {
+		responses[statusKey(http.StatusRequestEntityTooLarge)] = errorResponse("the request body is too large")
+	}
+	if _, ok := responses[statusKey(http.StatusNotFound)]; !ok && !op.root {
+		responses[statusKey(http.StatusNotFound)] = errorResponse("no such location")
+	}
+	if slices.Contains(idempotentMethods, op.method) {
+		if _, ok := responses[statusKey(http.StatusConflict)]; !ok {
+			responses[statusKey(http.StatusConflict)] = errorResponse("a request with the same Idempotency-Key is still being processed")
+		}
+		responses[statusKey(http.StatusUnprocessableEntity)] = errorResponse("the Idempotency-Key was used for a different request")
+	}
+	responses[statusKey(http.StatusInternalServerError)] = errorResponse("an unexpected error")
+
+	document := map[string]any{
+		"tags":        []string{op.tag},
+		"summary":     op.summary,
+		"operationId": operationID(op),
+		"responses":   responses,
+	}
+	if len(parameters) > 0 {
+		document["parameters"] = parameters
+	}
+	if op.request != nil {
+		document["requestBody"] = map[string]any{
+			"required": true,
+			"content":  schemas.content(cmp.Or(op.requestType, "application/json"), op.request),
+		}
+	}
+
+	return document
+}
+
+func errorResponse(description string) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Error"}}},
+	}
+}
+
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
+
+// operationID names an operation after its method and the fixed segments of
+// its path, such as 'putInternalTicketsRecall'.
+func operationID(op openAPIOperation) string {
+	id := strings.ToLower(op.method)
+	for _, segment := range strings.FieldsFunc(op.path, func(r rune) bool { return r == '/' || r == '-' || r == '.' || r == '_' }) {
+		if strings.HasPrefix(segment, "{") {
+			continue
+		}
+		id += strings.ToUpper(segment[:1]) + segment[1:]
+	}
+	return id
+}
+
+// openAPIDocument describes the REST API as an OpenAPI 3 document.
+func openAPIDocument() map[string]any {
+	schemas := openAPISchemas{}
+	paths := map[string]map[string]any{}
+
+	for _, op := range apiOperations() {
+		item, ok := paths[op.path]
+		if !ok {
+			item = map[string]any{}
+			if op.root {
+				item["servers"] = []any{map[string]any{"url": "/"}}
+			}
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = op.document(schemas)
+	}
+
+	schemas["Error"] = map[string]any{
+		"description": "A single error message, or every validation error of a request",
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"errors": map[string]any{"type": "array", "items": map[string]any{"type": "string"}}},
+			},
+		},
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Coda Virtuale",
+			"version": "1",
+			"description": "The queue API. The routes of a location are served under its '/locations/{loc}' prefix, " +
+				"and without a prefix for the default location.",
+		},
+		"servers": []any{
+			map[string]any{"url": "/", "description": "the default location"},
+			map[string]any{
+				"url":         locationPathPrefix,
+				"description": "a location, by its slug",
+				"variables":   map[string]any{"loc": map[string]any{"default": types.DefaultLocation}},
+			},
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+}
+
+func (s *APIServer) addOpenAPIRoutes(router *mux.Router) {
+	router.HandleFunc("/openapi.json", makeHTTPHandler(s.getOpenAPI, []string{http.MethodGet}, s.logger))
+}
+
+func (s *APIServer) getOpenAPI(w http.ResponseWriter, r *http.Request) error {
+	return writeJSON(w, http.StatusOK, openAPIDocument(), s.logger)
+}
+
+// undocumentedRoutes returns the path templates of the routes registered on
+// router which are missing from apiOperations. The routes of a location may
+// be documented without the '/locations/{loc}' prefix.
+func undocumentedRoutes(router *mux.Router) []string {
+	documented := map[string]bool{}
+	scoped := map[string]bool{}
+	for _, op := range apiOperations() {
+		documented[op.path] = true
+		if !op.root {
+			scoped[op.path] = true
+		}
+	}
+
+	missing := map[string]bool{}
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		if documented[path] {
+			return nil
+		}
+		if unprefixed, ok := strings.CutPrefix(path, locationPathPrefix); ok && scoped[unprefixed] {
+			return nil
+		}
+
+		missing[path] = true
+		return nil
+	})
+
+	var paths []string
+	for path := range missing {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
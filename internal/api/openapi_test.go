@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestRoutesAreDocumented(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	for _, path := range undocumentedRoutes(s.newRouter()) {
+		t.Errorf("route %s is missing from the OpenAPI document", path)
+	}
+}
+
+func TestUndocumentedRoutesFindsMissingRoutes(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	router := s.newRouter()
+	router.HandleFunc("/undocumented", func(http.ResponseWriter, *http.Request) {})
+	router.PathPrefix("/locations/{loc}").Subrouter().HandleFunc("/undocumented", func(http.ResponseWriter, *http.Request) {})
+
+	want := []string{"/locations/{loc}/undocumented", "/undocumented"}
+	if got := undocumentedRoutes(router); !slices.Equal(got, want) {
+		t.Errorf("undocumentedRoutes() = %q, want %q", got, want)
+	}
+}
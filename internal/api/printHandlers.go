@@ -0,0 +1,158 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/printing"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+func (s *APIServer) addPrintRoutes(router *mux.Router) {
+	router.HandleFunc("/{sub_url}/qr.png", makeHTTPHandler(s.getTicketQRPNG, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/{sub_url}/qr.svg", makeHTTPHandler(s.getTicketQRSVG, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/{sub_url}/print.html", makeHTTPHandler(s.getTicketPrintHTML, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/{sub_url}/print.escpos", makeHTTPHandler(s.getTicketPrintESCPOS, []string{http.MethodGet}, s.logger))
+}
+
+func (s *APIServer) addAppointmentQRRoutes(router *mux.Router) {
+	router.HandleFunc("/{sub_url}/qr.png", makeHTTPHandler(s.getAppointmentQRPNG, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/{sub_url}/qr.svg", makeHTTPHandler(s.getAppointmentQRSVG, []string{http.MethodGet}, s.logger))
+}
+
+var errNoPublicBaseURL = apiError{"printing is not available until PUBLIC_BASE_URL is set"}
+
+// publicURL builds an absolute URL for a path on this server from
+// PUBLIC_BASE_URL. The request's Host header is never used, as anyone can set
+// it and the URL ends up printed on tickets.
+func (s *APIServer) publicURL(r *http.Request, path string) string {
+	return strings.TrimSuffix(s.publicBaseURL, "/") + locationPrefix(r) + path
+}
+
+// printableTicket looks up the ticket of the SubURL in the route. A zero
+// ticket is returned when a response has already been written.
+func (s *APIServer) printableTicket(w http.ResponseWriter, r *http.Request) (printing.Ticket, error) {
+	if s.publicBaseURL == "" {
+		return printing.Ticket{}, writeJSON(w, http.StatusServiceUnavailable, errNoPublicBaseURL, s.logger)
+	}
+
+	ticket, err := s.store(r).GetTicketBySubURL(mux.Vars(r)["sub_url"])
+	if err != nil {
+		if err == types.ErrnotFound {
+			return printing.Ticket{}, writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return printing.Ticket{}, writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	category, err := s.store(r).GetCategory(ticket.CategoryID)
+	if err != nil {
+		return printing.Ticket{}, writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return printing.Ticket{
+		DisplayNumber: ticket.DisplayNumber(category),
+		Category:      category.Name,
+		URL:           s.publicURL(r, "/t/"+ticket.SubURL),
+		IssuedAt:      ticket.CreatedAt,
+	}, nil
+}
+
+func (s *APIServer) getTicketQRPNG(w http.ResponseWriter, r *http.Request) error {
+	ticket, err := s.printableTicket(w, r)
+	if err != nil || ticket.URL == "" {
+		return err
+	}
+
+	return s.writeQRPNG(w, ticket.URL)
+}
+
+func (s *APIServer) getTicketQRSVG(w http.ResponseWriter, r *http.Request) error {
+	ticket, err := s.printableTicket(w, r)
+	if err != nil || ticket.URL == "" {
+		return err
+	}
+
+	return s.writeQRSVG(w, ticket.URL)
+}
+
+func (s *APIServer) getTicketPrintHTML(w http.ResponseWriter, r *http.Request) error {
+	ticket, err := s.printableTicket(w, r)
+	if err != nil || ticket.URL == "" {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return printing.WriteHTML(w, ticket)
+}
+
+func (s *APIServer) getTicketPrintESCPOS(w http.ResponseWriter, r *http.Request) error {
+	ticket, err := s.printableTicket(w, r)
+	if err != nil || ticket.URL == "" {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, err = w.Write(printing.ESCPOS(ticket))
+	return err
+}
+
+// appointmentURL returns the URL of the appointment of the SubURL in the
+// route, or an empty string when a response has already been written. It is
+// the page a customer opens, and a kiosk reads before checking the appointment
+// in with a POST to its check-in URL.
+func (s *APIServer) appointmentURL(w http.ResponseWriter, r *http.Request) (string, error) {
+	if s.publicBaseURL == "" {
+		return "", writeJSON(w, http.StatusServiceUnavailable, errNoPublicBaseURL, s.logger)
+	}
+
+	appointment, err := s.store(r).GetAppointmentBySubURL(mux.Vars(r)["sub_url"])
+	if err != nil {
+		if err == types.ErrnotFound {
+			return "", writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return "", writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return s.publicURL(r, "/appointments/"+appointment.SubURL), nil
+}
+
+func (s *APIServer) getAppointmentQRPNG(w http.ResponseWriter, r *http.Request) error {
+	url, err := s.appointmentURL(w, r)
+	if err != nil || url == "" {
+		return err
+	}
+
+	return s.writeQRPNG(w, url)
+}
+
+func (s *APIServer) getAppointmentQRSVG(w http.ResponseWriter, r *http.Request) error {
+	url, err := s.appointmentURL(w, r)
+	if err != nil || url == "" {
+		return err
+	}
+
+	return s.writeQRSVG(w, url)
+}
+
+func (s *APIServer) writeQRPNG(w http.ResponseWriter, content string) error {
+	png, err := printing.QRPNG(content, printing.DefaultQRSize)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	_, err = w.Write(png)
+	return err
+}
+
+func (s *APIServer) writeQRSVG(w http.ResponseWriter, content string) error {
+	svg, err := printing.QRSVG(content)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, err = w.Write(svg)
+	return err
+}
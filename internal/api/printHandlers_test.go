@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+func TestPrintNeedsPublicBaseURL(t *testing.T) {
+	s, store := newTestServer(t)
+	router := s.newRouter()
+
+	category := createCategory(t, store, "payments")
+	ticket, err := store.ForLocation(1).CreateTicket(types.TicketCreate{CategoryID: category.ID, SubURL: "abc123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	printTicket := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/t/"+ticket.SubURL+"/print.escpos", nil)
+		r.Host = "attacker.example"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	s.publicBaseURL = ""
+	if w := printTicket(); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("printing without PUBLIC_BASE_URL got %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	s.publicBaseURL = "https://queue.example/"
+	w := printTicket()
+	if want := "https://queue.example/t/" + ticket.SubURL; w.Code != http.StatusOK || !strings.Contains(w.Body.String(), want) {
+		t.Errorf("printing got %d %q, want the URL %q", w.Code, w.Body, want)
+	}
+	if strings.Contains(w.Body.String(), "attacker.example") {
+		t.Errorf("printed ticket %q has the request's host", w.Body)
+	}
+}
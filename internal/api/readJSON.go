@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+var (
+	errBodyTooLarge  = apiError{"the request body is too large"}
+	errNotMergePatch = apiError{"the patch must be sent as '" + mergePatchContentType + "'"}
+)
+
+// withBodyLimit refuses request bodies larger than maxBodyBytes, before the
+// idempotency middleware or a handler reads them whole.
+func (s *APIServer) withBodyLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > s.maxBodyBytes {
+			writeJSON(w, http.StatusRequestEntityTooLarge, errBodyTooLarge, s.logger)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyErrorStatus is the status of a request whose body could not be read.
+func bodyErrorStatus(err error) int {
+	if err == errBodyTooLarge {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// bodyReadError is the error to report for a body which could not be read or
+// decoded.
+func bodyReadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errBodyTooLarge
+	}
+	return errBadRequestBody
+}
+
+// readJSON decodes the JSON body of a request into v, refusing fields which v
+// does not have, so that a misspelt field is reported instead of ignored.
+func readJSON(r *http.Request, v any) error {
+	return decodeStrict(r.Body, v)
+}
+
+// readMergePatch decodes a JSON merge patch (RFC 7396) into patch, a struct of
+// pointers left nil for the members the patch leaves out. The members of a
+// desk or category are all required, so removing one with null is refused
+// rather than taken as leaving it unchanged.
+func readMergePatch(r *http.Request, patch any) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return bodyReadError(err)
+	}
+
+	var members map[string]json.RawMessage
+	if err = json.Unmarshal(body, &members); err != nil || members == nil {
+		return apiError{"the patch must be a JSON object"}
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if string(bytes.TrimSpace(members[name])) == "null" {
+			return apiError{fmt.Sprintf("'%s' is required and cannot be removed", name)}
+		}
+	}
+
+	return decodeStrict(bytes.NewReader(body), patch)
+}
+
+func decodeStrict(body io.Reader, v any) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return apiError{fmt.Sprintf("unknown field '%s'", strings.Trim(field, `"`))}
+		}
+		return bodyReadError(err)
+	}
+	return nil
+}
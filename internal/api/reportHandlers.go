@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// maxReportPeriods limits how many hours or days a single report may cover.
+const maxReportPeriods = 744
+
+func (s *APIServer) addReportRoutes(router *mux.Router) {
+	router.HandleFunc("/reports/categories", makeHTTPHandler(s.getCategoryReport, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/reports/desks", makeHTTPHandler(s.getDeskReport, []string{http.MethodGet}, s.logger))
+}
+
+func (s *APIServer) getCategoryReport(w http.ResponseWriter, r *http.Request) error {
+	return s.writeReport(w, r, types.ReportByCategory)
+}
+
+func (s *APIServer) getDeskReport(w http.ResponseWriter, r *http.Request) error {
+	return s.writeReport(w, r, types.ReportByDesk)
+}
+
+// writeReport responds with the report selected by the query parameters, as
+// JSON or, when asked for with 'format=csv' or an Accept header of text/csv,
+// as a CSV file.
+func (s *APIServer) writeReport(w http.ResponseWriter, r *http.Request, groupBy types.ReportGrouping) error {
+	query, errs := parseReportQuery(r)
+	if len(errs) > 0 {
+		return writeJSON(w, http.StatusBadRequest, errs, s.logger)
+	}
+	query.GroupBy = groupBy
+
+	report, err := s.store(r).Report(query)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	if r.URL.Query().Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		filename := fmt.Sprintf("%s-report-%s-%s.csv", groupBy, query.From.Format("20060102"), query.To.Format("20060102"))
+		return writeReportCSV(w, filename, groupBy, report)
+	}
+
+	return writeJSON(w, http.StatusOK, report, s.logger)
+}
+
+// parseReportQuery reads the 'from' and 'to' times, given either in RFC 3339
+// or as dates in the report's 'timezone', the 'interval' and the
+// 'category_id' filter. Reports default to the last seven days by day in the
+// timezone of the location.
+func parseReportQuery(r *http.Request) (types.ReportQuery, []error) {
+	params := r.URL.Query()
+	var errs []error
+
+	query := types.ReportQuery{Interval: types.ReportByDay, Location: time.UTC}
+
+	tz := params.Get("timezone")
+	if tz == "" {
+		tz = locationFromRequest(r).Timezone
+	}
+
+	if tz != "" {
+		location, err := time.LoadLocation(tz)
+		if err != nil || tz == "Local" {
+			return query, []error{apiError{"'timezone' must be an IANA timezone name"}}
+		}
+		query.Location = location
+	}
+
+	switch interval := types.ReportInterval(params.Get("interval")); interval {
+	case "":
+	case types.ReportByHour, types.ReportByDay:
+		query.Interval = interval
+	default:
+		errs = append(errs, apiError{"'interval' must be 'hour' or 'day'"})
+	}
+
+	now := time.Now().In(query.Location)
+	query.To = now
+	query.From = time.Date(now.Year(), now.Month(), now.Day()-6, 0, 0, 0, 0, query.Location)
+
+	parseTime := func(name string, value string, target *time.Time) {
+		if value == "" {
+			return
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			*target = t
+			return
+		}
+		if t, err := time.ParseInLocation(time.DateOnly, value, query.Location); err == nil {
+			*target = t
+			return
+		}
+		errs = append(errs, apiError{fmt.Sprintf("'%s' must be an RFC 3339 time or a date", name)})
+	}
+
+	parseTime("from", params.Get("from"), &query.From)
+	parseTime("to", params.Get("to"), &query.To)
+
+	if !query.To.After(query.From) {
+		errs = append(errs, apiError{"'to' must be after 'from'"})
+	}
+
+	period := time.Hour
+	if query.Interval == types.ReportByDay {
+		period = 24 * time.Hour
+	}
+	if query.To.Sub(query.From) > maxReportPeriods*period {
+		errs = append(errs, apiError{fmt.Sprintf("a report may cover at most %d periods", maxReportPeriods)})
+	}
+
+	categoryIDs, err := parseCategoryIDs(r)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	query.CategoryIDs = categoryIDs
+
+	return query, errs
+}
+
+func writeReportCSV(w http.ResponseWriter, filename string, groupBy types.ReportGrouping, report []types.ReportRow) error {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	header := []string{"period", "category_id", "category_name", "issued", "called", "served", "no_shows", "abandoned"}
+	if groupBy == types.ReportByDesk {
+		header = []string{"period", "desk_id", "desk_label", "called", "served", "no_shows"}
+	}
+	header = append(header, "wait_p50_seconds", "wait_p90_seconds", "service_p50_seconds", "service_p90_seconds")
+
+	writer := csv.NewWriter(w)
+	writer.Write(header)
+
+	for _, row := range report {
+		record := []string{row.Period.Format(time.RFC3339)}
+		if groupBy == types.ReportByDesk {
+			record = append(record, strconv.Itoa(row.DeskID), row.DeskLabel, strconv.Itoa(row.Called), strconv.Itoa(row.Served), strconv.Itoa(row.NoShows))
+		} else {
+			record = append(record, strconv.Itoa(row.CategoryID), row.CategoryName, strconv.Itoa(row.Issued), strconv.Itoa(row.Called), strconv.Itoa(row.Served), strconv.Itoa(row.NoShows), strconv.Itoa(row.Abandoned))
+		}
+		record = append(record, csvSeconds(row.WaitP50Seconds), csvSeconds(row.WaitP90Seconds), csvSeconds(row.ServiceP50Seconds), csvSeconds(row.ServiceP90Seconds))
+		writer.Write(record)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvSeconds formats a duration in seconds, leaving the cell empty when there
+// was nothing to measure.
+func csvSeconds(seconds *float64) string {
+	if seconds == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*seconds, 'f', 1, 64)
+}
@@ -1,36 +1,63 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
 )
 
 func (s *APIServer) addStaffRoutes(router *mux.Router) {
 	router.HandleFunc("/next", makeHTTPHandler(s.handleNext, []string{http.MethodPut, http.MethodGet}, s.logger))
 	router.HandleFunc("/queue", makeHTTPHandler(s.getQueue, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/tickets/{id}/history", makeHTTPHandler(s.getTicketHistory, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/tickets/{id}/recall", makeHTTPHandler(s.putRecallTicket, []string{http.MethodPut}, s.logger))
+	router.HandleFunc("/tickets/{id}/serve", makeHTTPHandler(s.putServeTicket, []string{http.MethodPut}, s.logger))
+	router.HandleFunc("/tickets/{id}/transfer", makeHTTPHandler(s.putTransferTicket, []string{http.MethodPut}, s.logger))
+	router.HandleFunc("/tickets/{id}/close", makeHTTPHandler(s.putCloseTicket, []string{http.MethodPut}, s.logger))
+	router.HandleFunc("/tickets/{id}/no-show", makeHTTPHandler(s.putNoShowTicket, []string{http.MethodPut}, s.logger))
+	router.HandleFunc("/desks/{id}/tickets", makeHTTPHandler(s.getDeskTickets, []string{http.MethodGet}, s.logger))
+	s.addDeskSessionRoutes(router)
+	s.addAppointmentSlotRoutes(router)
+	s.addReportRoutes(router)
+	s.addConfigRoutes(router)
+	s.addDeskSocketRoutes(router)
+	s.addWebhookRoutes(router)
 }
 
-func (s *APIServer) putNextTicket(w http.ResponseWriter, r *http.Request) error {
-	var requestBody struct {
-		DeskID int `json:"desk_id"`
-	}
+// staffFromRequest identifies the staff member performing an action, as
+// recorded in the ticket history.
+func staffFromRequest(r *http.Request) string {
+	return r.Header.Get("X-Staff-ID")
+}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		return writeJSON(w, http.StatusBadRequest, errBadRequestBody, s.logger)
-	}
+type callNextRequest struct {
+	DeskID int `json:"desk_id" validate:"required,exists=desk"`
+}
 
-	if _, err := s.storage.GetDesk(requestBody.DeskID); err != nil {
-		errBody := badValidationString("desk")
-		return writeJSON(w, http.StatusBadRequest, errors.New(errBody), s.logger)
+type transferTicketRequest struct {
+	CategoryID int `json:"category_id" validate:"required,exists=category"`
+}
+
+func (s *APIServer) putNextTicket(w http.ResponseWriter, r *http.Request) error {
+	var requestBody callNextRequest
+
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
 	}
 
-	nextTicket, err := s.storage.CallNextTicket(requestBody.DeskID)
+	nextTicket, err := s.store(r).CallNextTicket(requestBody.DeskID, staffFromRequest(r))
 	if err != nil {
+		switch err {
+		case types.ErrnotFound:
+			return writeJSON(w, http.StatusNotFound, errors.New("no tickets waiting"), s.logger)
+		case types.ErrDeskClosed, types.ErrDeskPaused:
+			return writeJSON(w, http.StatusConflict, err, s.logger)
+		}
 		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
 	}
 
@@ -50,25 +77,220 @@ func (s *APIServer) getNext(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, errors.New(errBody), s.logger)
 	}
 
-	if _, err := s.storage.GetCategory(categoryID); err != nil {
+	if _, err := s.store(r).GetCategory(categoryID); err != nil {
 		errBody := badValidationString("category")
 		return writeJSON(w, http.StatusBadRequest, errors.New(errBody), s.logger)
 	}
 
-	nextTicket, err := s.storage.SeeNext(categoryID)
+	nextTicket, err := s.store(r).SeeNext(categoryID)
 	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, errors.New("no tickets waiting"), s.logger)
+		}
 		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
 	}
 
 	return writeJSON(w, http.StatusOK, nextTicket, s.logger)
 }
 
+type queueEntry struct {
+	types.Ticket
+	DisplayNumber string `json:"display_number"`
+}
+
+// getQueue lists the waiting tickets in the order they will be called,
+// filtered by the optional 'category_id' list.
 func (s *APIServer) getQueue(w http.ResponseWriter, r *http.Request) error {
-	ticketIDs, err := s.storage.SeeQueue()
+	categoryIDs, err := parseCategoryIDs(r)
+	if err != nil {
+		return writeJSON(w, http.StatusBadRequest, err, s.logger)
+	}
+
+	tickets, err := s.store(r).SeeQueue(categoryIDs)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	queue, err := s.newQueueEntries(r.Context(), tickets)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, queue, s.logger)
+}
+
+func (s *APIServer) getDeskTickets(w http.ResponseWriter, r *http.Request) error {
+	deskID, err := s.deskIDFromVars(w, r)
+	if err != nil || deskID == 0 {
+		return err
+	}
+
+	tickets, err := s.store(r).DeskTickets(deskID)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	entries, err := s.newQueueEntries(r.Context(), tickets)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, entries, s.logger)
+}
+
+func (s *APIServer) newQueueEntries(ctx context.Context, tickets []types.Ticket) ([]queueEntry, error) {
+	categories, err := s.storeFor(ctx).ListCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	categoriesByID := make(map[int]types.Category)
+	for _, category := range categories {
+		categoriesByID[category.ID] = category
+	}
+
+	entries := make([]queueEntry, len(tickets))
+	for i, ticket := range tickets {
+		entries[i] = queueEntry{Ticket: ticket, DisplayNumber: ticket.DisplayNumber(categoriesByID[ticket.CategoryID])}
+	}
+
+	return entries, nil
+}
+
+func (s *APIServer) getTicketHistory(w http.ResponseWriter, r *http.Request) error {
+	idStr := mux.Vars(r)["id"]
+	ticketID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+
+	history, err := s.store(r).GetTicketHistory(ticketID)
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, history, s.logger)
+}
+
+func (s *APIServer) putRecallTicket(w http.ResponseWriter, r *http.Request) error {
+	idStr := mux.Vars(r)["id"]
+	ticketID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+
+	ticket, err := s.store(r).RecallTicket(ticketID, staffFromRequest(r))
+	if err != nil {
+		return writeTicketActionError(w, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, ticket, s.logger)
+}
+
+// putServeTicket records that the customer of a called ticket has turned up,
+// so that they are no longer recalled or marked as a no-show.
+func (s *APIServer) putServeTicket(w http.ResponseWriter, r *http.Request) error {
+	idStr := mux.Vars(r)["id"]
+	ticketID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+
+	ticket, err := s.store(r).ServeTicket(ticketID, staffFromRequest(r))
+	if err != nil {
+		return writeTicketActionError(w, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, ticket, s.logger)
+}
+
+func (s *APIServer) putTransferTicket(w http.ResponseWriter, r *http.Request) error {
+	var requestBody transferTicketRequest
+
+	idStr := mux.Vars(r)["id"]
+	ticketID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
+	}
+
+	ticket, err := s.store(r).TransferTicket(ticketID, requestBody.CategoryID, staffFromRequest(r))
+	if err != nil {
+		return writeTicketActionError(w, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, ticket, s.logger)
+}
+
+func (s *APIServer) putCloseTicket(w http.ResponseWriter, r *http.Request) error {
+	idStr := mux.Vars(r)["id"]
+	ticketID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+
+	ticket, err := s.store(r).CloseTicket(ticketID, staffFromRequest(r))
+	if err != nil {
+		return writeTicketActionError(w, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, ticket, s.logger)
+}
+
+// putNoShowTicket lets staff give up on a customer without waiting for the
+// no-show timeout. The category's requeue setting still applies.
+func (s *APIServer) putNoShowTicket(w http.ResponseWriter, r *http.Request) error {
+	idStr := mux.Vars(r)["id"]
+	ticketID, err := strconv.Atoi(idStr)
+	if err != nil {
+		errBody := "bad ID"
+		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+
+	ticket, err := s.store(r).GetTicket(ticketID)
+	if err != nil {
+		return writeTicketActionError(w, err, s.logger)
+	}
+
+	settings, err := s.store(r).GetCategorySettings(ticket.CategoryID)
 	if err != nil {
 		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
 	}
-	return writeJSON(w, http.StatusOK, ticketIDs, s.logger)
+
+	ticket, err = s.store(r).MarkNoShow(ticketID, settings.RequeueNoShows, staffFromRequest(r))
+	if err != nil {
+		return writeTicketActionError(w, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, ticket, s.logger)
+}
+
+// writeTicketActionError maps the errors returned by the storage's ticket
+// state transitions onto response codes.
+func writeTicketActionError(w http.ResponseWriter, err error, logger *types.SugarWithTrace) error {
+	return writeJSON(w, ticketActionStatus(err), err, logger)
+}
+
+func ticketActionStatus(err error) int {
+	switch err {
+	case types.ErrnotFound:
+		return http.StatusNotFound
+	case types.ErrTicketClosed, types.ErrTicketNotCalled, types.ErrTicketServing, types.ErrTicketNotWaiting, types.ErrSnoozeLimit:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 func (s *APIServer) handleNext(w http.ResponseWriter, r *http.Request) error {
@@ -1,26 +1,91 @@
 package api
 
-import "github.com/khaleelsyed/codaVirtuale/internal/types"
+import (
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
 
 type Storage interface {
-	CallNextTicket(deskID int) (types.Ticket, error)
+	CreateLocation(location types.Location) (types.Location, error)
+	GetLocation(slug string) (types.Location, error)
+	ListLocations() ([]types.Location, error)
+	UpdateLocation(location types.Location) (types.Location, error)
+	GetLocationHours(locationID int) (types.LocationHours, error)
+	SetLocationHours(hours types.LocationHours) (types.LocationHours, error)
+
+	CallNextTicket(deskID int, staff string) (types.Ticket, error)
 	SeeNext(categoryID int) (types.Ticket, error)
-	SeeQueue() ([]int, error)
+	SeeQueue(categoryIDs []int) ([]types.Ticket, error)
+	DeskTickets(deskID int) ([]types.Ticket, error)
 
 	CreateTicket(ticketCreate types.TicketCreate) (types.Ticket, error)
 	GetTicket(id int) (types.Ticket, error)
-	DeleteTicket(id int) error
+	DeleteTicket(id int, staff string) error
+	RecallTicket(id int, staff string) (types.Ticket, error)
+	ServeTicket(id int, staff string) (types.Ticket, error)
+	TransferTicket(id int, categoryID int, staff string) (types.Ticket, error)
+	CloseTicket(id int, staff string) (types.Ticket, error)
+	MarkNoShow(id int, requeue bool, staff string) (types.Ticket, error)
+	GetTicketHistory(id int) ([]types.TicketEvent, error)
+	TicketEventsSince(categoryIDs []int, afterID int, limit int) ([]types.TicketEvent, error)
+
+	GetTicketBySubURL(subURL string) (types.Ticket, error)
+	CancelTicket(id int) (types.Ticket, error)
+	SnoozeTicket(id int, snooze types.TicketSnooze, maxSnoozes int) (types.Ticket, error)
+
+	ServiceDurations(categoryID int, limit int) ([]time.Duration, error)
+	CountOpenDesks(categoryID int) (int, error)
+	CountWaiting(categoryID int) (int, error)
+	TicketsAhead(id int) (int, error)
+	RecentCalls(categoryIDs []int, limit int) ([]types.Call, error)
+	Report(query types.ReportQuery) ([]types.ReportRow, error)
 
 	CreateCategory(name string) (types.Category, error)
 	GetCategory(id int) (types.Category, error)
-	UpdateCategory(id int, name string) (types.Category, error)
-	DeleteCategory(id int) error
+	ListCategories() ([]types.Category, error)
+	UpdateCategory(id int, name string, version int) (types.Category, error)
+	DeleteCategory(id int, version int) error
+	GetCategorySettings(categoryID int) (types.CategorySettings, error)
+	UpdateCategorySettings(settings types.CategorySettings) (types.CategorySettings, error)
+	SetIntakeClosed(categoryID int, closed bool) (types.CategorySettings, error)
+	GetCategoryHours(categoryID int) (types.CategoryHours, error)
+	SetCategoryHours(hours types.CategoryHours) (types.CategoryHours, error)
+
+	ApplyConfig(config types.LocationConfig, prune bool, dryRun bool) ([]types.ConfigChange, error)
+	ExportConfig() (types.LocationConfig, error)
 
 	CreateDesk(label string, categoryID int) (types.Desk, error)
 	GetDesk(id int) (types.Desk, error)
-	UpdateDesk(id int, deskUpdate struct {
-		CategoryID int
-		Label      string
-	}) (types.Desk, error)
-	DeleteDesk(id int) error
+	ListDesks() ([]types.Desk, error)
+	UpdateDesk(id int, deskUpdate types.DeskUpdate, version int) (types.Desk, error)
+	DeleteDesk(id int, version int) error
+
+	CreateAppointmentSlot(slot types.AppointmentSlot) (types.AppointmentSlot, error)
+	GetAppointmentSlot(id int) (types.AppointmentSlot, error)
+	ListAppointmentSlots(categoryID int, from time.Time, to time.Time) ([]types.AppointmentSlot, error)
+	DeleteAppointmentSlot(id int) error
+	BookAppointment(slotID int, name string, subURL string) (types.Appointment, error)
+	GetAppointmentBySubURL(subURL string) (types.Appointment, error)
+	CancelAppointment(id int) (types.Appointment, error)
+	CheckInAppointment(id int, subURL string, window types.CheckInWindow) (types.Appointment, types.Ticket, error)
+
+	GetDeskSession(deskID int) (types.DeskSession, error)
+	OpenDeskSession(deskID int, staff string, token string) (types.DeskSession, error)
+	GetDeskSessionByToken(token string) (types.DeskSession, error)
+	SetDeskSessionState(deskID int, state types.DeskSessionState) (types.DeskSession, error)
+	CloseDeskSession(deskID int) (types.DeskSession, error)
+
+	CreateWebhookSubscription(subscription types.WebhookSubscription) (types.WebhookSubscription, error)
+	GetWebhookSubscription(id int) (types.WebhookSubscription, error)
+	ListWebhookSubscriptions() ([]types.WebhookSubscription, error)
+	UpdateWebhookSubscription(subscription types.WebhookSubscription) (types.WebhookSubscription, error)
+	DeleteWebhookSubscription(id int) error
+	ListWebhookDeliveries(subscriptionID int, limit int) ([]types.WebhookDelivery, error)
+	GetWebhookDelivery(id int) (types.WebhookDelivery, error)
+	RedeliverWebhook(id int) (types.WebhookDelivery, error)
+
+	BeginIdempotentRequest(key string, fingerprint string, window time.Duration) (types.IdempotentRequest, bool, error)
+	CompleteIdempotentRequest(request types.IdempotentRequest) error
+	AbandonIdempotentRequest(key string) error
 }
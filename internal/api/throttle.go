@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+var errTooManyTickets = apiError{"too many tickets requested, please try again later"}
+
+// clientKeys identifies who is asking for a ticket: always by IP address, and
+// also by the optional X-Device-Token header sent by our kiosks and apps.
+func (s *APIServer) clientKeys(r *http.Request) []string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+
+	if s.trustProxyHeaders {
+		if forwarded := forwardedFor(r, s.trustedProxyHops); forwarded != "" {
+			ip = forwarded
+		}
+	}
+
+	keys := []string{"ip:" + ip}
+
+	if device := r.Header.Get("X-Device-Token"); device != "" {
+		keys = append(keys, "device:"+device)
+	}
+
+	return keys
+}
+
+// forwardedFor returns the client address that the nearest of hops trusted
+// proxies saw, or an empty string when it has not been given one. Each proxy
+// appends the address it received the request from to X-Forwarded-For, so the
+// addresses to the left of the ones added by our proxies are whatever the
+// client chose to send.
+func forwardedFor(r *http.Request, hops int) string {
+	var addresses []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, address := range strings.Split(header, ",") {
+			addresses = append(addresses, strings.TrimSpace(address))
+		}
+	}
+	if len(addresses) == 0 {
+		return ""
+	}
+
+	// A request with fewer addresses than hops reached one of the inner
+	// proxies first, which saw the client itself.
+	address := addresses[max(len(addresses)-hops, 0)]
+	if net.ParseIP(address) == nil {
+		return ""
+	}
+	return address
+}
+
+// throttleTicket applies the per-client ticket limit of a category. It writes
+// a 429 response and returns false when the client has reached the limit.
+// Otherwise the ticket is counted against the client straight away, and the
+// returned release gives it back if the ticket is not issued after all.
+func (s *APIServer) throttleTicket(w http.ResponseWriter, r *http.Request, settings types.CategorySettings) (func(), bool, error) {
+	release, allowed, retryAfter := s.reserveTicket(settings, s.clientKeys(r))
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		return nil, false, writeJSON(w, http.StatusTooManyRequests, errTooManyTickets, s.logger)
+	}
+
+	return release, true, nil
+}
+
+// reserveTicket counts a ticket against each of the keys identifying a client.
+// Once any of them has reached the category's limit it returns false and how
+// long the client must wait instead. The returned release takes the ticket
+// off the count again.
+func (s *APIServer) reserveTicket(settings types.CategorySettings, keys []string) (func(), bool, time.Duration) {
+	if settings.MaxTicketsPerClient == 0 {
+		return func() {}, true, 0
+	}
+
+	window := time.Duration(settings.ClientWindowSeconds) * time.Second
+	scoped := limiterKeys(settings, keys)
+	now := time.Now()
+
+	allowed, retryAfter := s.ticketLimiter.Reserve(scoped, settings.MaxTicketsPerClient, window, now)
+	if !allowed {
+		s.logger.Debugw("client reached ticket limit", "category_id", settings.CategoryID, "client", keys)
+		return nil, false, retryAfter
+	}
+
+	release := func() { s.ticketLimiter.Cancel(scoped, now) }
+	return release, true, 0
+}
+
+// limiterKeys scopes the keys of a client to a category, whose limits are
+// counted apart.
+func limiterKeys(settings types.CategorySettings, keys []string) []string {
+	scoped := make([]string, len(keys))
+	for i, key := range keys {
+		scoped[i] = fmt.Sprintf("%d|%s", settings.CategoryID, key)
+	}
+	return scoped
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientKeysBehindProxies(t *testing.T) {
+	tests := []struct {
+		name      string
+		trust     bool
+		hops      int
+		forwarded []string
+		want      string
+	}{
+		{"proxy headers not trusted", false, 1, []string{"203.0.113.7"}, "ip:192.0.2.1"},
+		{"one proxy", true, 1, []string{"203.0.113.7"}, "ip:203.0.113.7"},
+		{"spoofed address before the proxy's", true, 1, []string{"198.51.100.9, 203.0.113.7"}, "ip:203.0.113.7"},
+		{"two proxies", true, 2, []string{"198.51.100.9, 203.0.113.7, 10.0.0.2"}, "ip:203.0.113.7"},
+		{"headers split by a proxy", true, 2, []string{"198.51.100.9, 203.0.113.7", "10.0.0.2"}, "ip:203.0.113.7"},
+		{"fewer addresses than proxies", true, 2, []string{"203.0.113.7"}, "ip:203.0.113.7"},
+		{"not an address", true, 1, []string{"unknown"}, "ip:192.0.2.1"},
+		{"no header", true, 1, nil, "ip:192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t)
+			s.trustProxyHeaders, s.trustedProxyHops = tt.trust, tt.hops
+
+			r := httptest.NewRequest(http.MethodPost, "/ticket", nil)
+			r.RemoteAddr = "192.0.2.1:4321"
+			for _, header := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", header)
+			}
+
+			if got := s.clientKeys(r); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("clientKeys() = %q, want [%q]", got, tt.want)
+			}
+		})
+	}
+}
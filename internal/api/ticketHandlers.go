@@ -1,9 +1,9 @@
 package api
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -19,6 +19,11 @@ func (s *APIServer) addTicketRoutes(router *mux.Router) {
 	router.HandleFunc("", makeHTTPHandler(s.createTicket, []string{http.MethodPost}, s.logger))
 }
 
+type createTicketRequest struct {
+	CategoryID int                  `json:"category_id" validate:"required,exists=category"`
+	Contact    *types.TicketContact `json:"contact,omitempty"`
+}
+
 func (s *APIServer) getTicket(w http.ResponseWriter, r *http.Request) error {
 	idStr := mux.Vars(r)["id"]
 	ticketID, err := strconv.Atoi(idStr)
@@ -27,7 +32,7 @@ func (s *APIServer) getTicket(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
-	ticket, err := s.storage.GetTicket(ticketID)
+	ticket, err := s.store(r).GetTicket(ticketID)
 	if err != nil {
 		if err == types.ErrnotFound {
 			return writeJSON(w, http.StatusNotFound, err, s.logger)
@@ -35,7 +40,12 @@ func (s *APIServer) getTicket(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, badValidationString("ticket"), s.logger)
 	}
 
-	return writeJSON(w, http.StatusOK, ticket, s.logger)
+	response, err := s.newTicketResponse(r.Context(), ticket)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, response, s.logger)
 }
 
 func (s *APIServer) deleteTicket(w http.ResponseWriter, r *http.Request) error {
@@ -47,14 +57,14 @@ func (s *APIServer) deleteTicket(w http.ResponseWriter, r *http.Request) error {
 		return writeJSON(w, http.StatusBadRequest, errBody, s.logger)
 	}
 
-	if _, err := s.storage.GetTicket(deskID); err != nil {
+	if _, err := s.store(r).GetTicket(deskID); err != nil {
 		if err == types.ErrnotFound {
 			return writeJSON(w, http.StatusNotFound, err, s.logger)
 		}
 		return writeJSON(w, http.StatusBadRequest, badValidationString("ticket"), s.logger)
 	}
 
-	if err := s.storage.DeleteTicket(deskID); err != nil {
+	if err := s.store(r).DeleteTicket(deskID, staffFromRequest(r)); err != nil {
 		return writeJSON(w, http.StatusBadRequest, err, s.logger)
 	}
 
@@ -64,52 +74,83 @@ func (s *APIServer) deleteTicket(w http.ResponseWriter, r *http.Request) error {
 func (s *APIServer) createTicket(w http.ResponseWriter, r *http.Request) error {
 	var err error
 
-	var requestBody struct {
-		CategoryID int `json:"category_id"`
+	var requestBody createTicketRequest
+
+	if err = readJSON(r, &requestBody); err != nil {
+		return writeJSON(w, bodyErrorStatus(err), err, s.logger)
 	}
 
-	randomString := func() string {
-		b := make([]byte, 6)
-		rand.Read(b)
-		return hex.EncodeToString(b)
+	errs := s.validateRequest(r, &requestBody)
+	if requestBody.Contact != nil {
+		errs = append(errs, s.validateContact(requestBody.Contact)...)
+	}
+	if len(errs) > 0 {
+		return writeJSON(w, http.StatusBadRequest, errs, s.logger)
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		return writeJSON(w, http.StatusBadRequest, errBadRequestBody, s.logger)
+	if err = s.checkIntake(r.Context(), requestBody.CategoryID); err != nil {
+		if errors.Is(err, types.ErrIntakeClosed) {
+			return writeJSON(w, http.StatusConflict, err, s.logger)
+		}
+		s.logger.Warnw("failed to check category intake", "category_id", requestBody.CategoryID, "error", err)
+		return writeJSON(w, http.StatusInternalServerError, errors.New("error creating ticket"), s.logger)
 	}
 
-	if requestBody.CategoryID == 0 {
-		return writeJSON(w, http.StatusBadRequest, apiError{"bad category ID"}, s.logger)
+	settings, err := s.store(r).GetCategorySettings(requestBody.CategoryID)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, errors.New("error creating ticket"), s.logger)
 	}
 
-	_, err = s.storage.GetCategory(requestBody.CategoryID)
+	release, allowed, err := s.throttleTicket(w, r, settings)
+	if !allowed {
+		return err
+	}
+
+	ticket, err := s.issueTicket(r.Context(), requestBody.CategoryID, requestBody.Contact)
 	if err != nil {
-		if err == types.ErrnotFound {
-			return writeJSON(w, http.StatusNotFound, "category not found", s.logger)
+		release()
+		if err == types.ErrQueueFull {
+			return writeJSON(w, http.StatusConflict, err, s.logger)
 		}
-		s.logger.Tracew("failed to validate category", "category_id", requestBody.CategoryID, "error", err)
-		return writeJSON(w, http.StatusBadRequest, badValidationString("category"), s.logger)
+		return writeJSON(w, http.StatusInternalServerError, errors.New("error creating ticket"), s.logger)
 	}
 
-	var ticket types.Ticket
+	response, err := s.newTicketResponse(r.Context(), ticket)
+	if err != nil {
+		s.logger.Warnw("failed to estimate wait for new ticket", "id", ticket.ID, "error", err)
+		return writeJSON(w, http.StatusCreated, ticketResponse{Ticket: ticket}, s.logger)
+	}
 
-	for range 3 {
-		ticket, err = s.storage.CreateTicket(types.TicketCreate{CategoryID: requestBody.CategoryID, SubURL: randomString()})
+	return writeJSON(w, http.StatusCreated, response, s.logger)
+}
 
+// issueTicket creates a ticket in a category under a new SubURL, retrying
+// when the SubURL is already taken.
+func (s *APIServer) issueTicket(ctx context.Context, categoryID int, contact *types.TicketContact) (types.Ticket, error) {
+	for range 3 {
+		ticket, err := s.storeFor(ctx).CreateTicket(types.TicketCreate{CategoryID: categoryID, SubURL: randomSubURL(), Contact: contact})
 		if err != nil {
 			s.logger.Debugw("Error seen in CreateTicket", "error", err)
 			if strings.HasPrefix(err.Error(), pqUniqueConstraintViolation) {
-				s.logger.Tracew("Failed to create a unique ticket url, retrying", "error", err, "sub_url", ticket.SubURL, "category_id", ticket.CategoryID)
+				s.logger.Tracew("Failed to create a unique ticket url, retrying", "error", err, "category_id", categoryID)
 				continue
 			}
-			return writeJSON(w, http.StatusInternalServerError, "error creating ticket", s.logger)
+			return types.Ticket{}, err
 		}
 
-		return writeJSON(w, http.StatusCreated, ticket, s.logger)
+		return ticket, nil
 	}
 
 	s.logger.Warn("Retry threshold has been reached for generating ticket SubURL")
-	return writeJSON(w, http.StatusInternalServerError, errors.New("error creating ticket"), s.logger)
+	return types.Ticket{}, errors.New("could not generate a unique ticket SubURL")
+}
+
+// randomSubURL generates the private path a customer uses to follow their
+// ticket or appointment.
+func randomSubURL() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
 }
 
 func (s *APIServer) handleTicket(w http.ResponseWriter, r *http.Request) error {
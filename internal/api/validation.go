@@ -0,0 +1,258 @@
+package api
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// Request bodies are validated against the rules in the 'validate' tags of
+// their fields, separated by commas:
+//
+//	required      the field must be given, and a string must not be blank
+//	omitempty     the other rules only apply when the field is given
+//	notblank      a string that is given must not be blank, for the fields of
+//	              merge patches, which required does not suit
+//	min=N, max=N  bounds of a number, or of the length of a string or list
+//	oneof=a|b     a string must be one of the listed values
+//	exists=kind   an ID must be of an existing category or desk of the
+//	              request's location
+//
+// A nil pointer is only checked by required, so that merge patches leave out
+// what they do not change. Nested structs are checked too, their fields named
+// after the path to them, such as 'branding.logo_url'. Rules between fields
+// are left to a validate method of the request, see requestValidator.
+
+// requestValidator is a request with rules its tags cannot express, such as
+// one field being required only when another is set.
+type requestValidator interface {
+	validate() []error
+}
+
+// referenceCheck reports whether the row of a kind, such as "category", with
+// the given ID exists.
+type referenceCheck func(kind string, id int) (bool, error)
+
+// readRequest decodes the JSON body of a request into v and validates it,
+// writing every problem found in one response. It returns false when a
+// response has been written.
+func (s *APIServer) readRequest(w http.ResponseWriter, r *http.Request, v any) (bool, error) {
+	if err := readJSON(r, v); err != nil {
+		return false, writeJSON(w, bodyErrorStatus(err), err, s.logger)
+	}
+	return s.checkRequest(w, r, v)
+}
+
+// readPatch is readRequest for a JSON merge patch, which must be sent as one.
+func (s *APIServer) readPatch(w http.ResponseWriter, r *http.Request, patch any) (bool, error) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != mergePatchContentType {
+		return false, writeJSON(w, http.StatusUnsupportedMediaType, errNotMergePatch, s.logger)
+	}
+	if err := readMergePatch(r, patch); err != nil {
+		return false, writeJSON(w, bodyErrorStatus(err), err, s.logger)
+	}
+	return s.checkRequest(w, r, patch)
+}
+
+func (s *APIServer) checkRequest(w http.ResponseWriter, r *http.Request, v any) (bool, error) {
+	if errs := s.validateRequest(r, v); len(errs) > 0 {
+		return false, writeJSON(w, http.StatusBadRequest, errs, s.logger)
+	}
+	return true, nil
+}
+
+// validateRequest returns an error for every field of a request which breaks
+// one of its rules, looking up the IDs it refers to in the request's location.
+func (s *APIServer) validateRequest(r *http.Request, v any) []error {
+	store := s.store(r)
+
+	return validateStruct(v, func(kind string, id int) (bool, error) {
+		var err error
+		switch kind {
+		case "category":
+			_, err = store.GetCategory(id)
+		case "desk":
+			_, err = store.GetDesk(id)
+		default:
+			panic(fmt.Sprintf("validate: unknown reference '%s'", kind))
+		}
+
+		if err == types.ErrnotFound {
+			return false, nil
+		}
+		return err == nil, err
+	})
+}
+
+// validateStruct checks the fields of the struct v points to against their
+// validate tags, along with its validate method if it has one. Exists may be
+// nil when none of the fields refer to others.
+func validateStruct(v any, exists referenceCheck) []error {
+	var errs []error
+
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Struct {
+		errs = validateFields(value, "", exists, errs)
+	}
+
+	if validator, ok := v.(requestValidator); ok {
+		errs = append(errs, validator.validate()...)
+	}
+	return errs
+}
+
+func validateFields(value reflect.Value, prefix string, exists referenceCheck, errs []error) []error {
+	t := value.Type()
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			errs = validateFields(fieldValue, prefix, exists, errs)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if rules := field.Tag.Get("validate"); rules != "" {
+			if err := validateField(prefix+name, fieldValue, rules, exists); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		for fieldValue.Kind() == reflect.Pointer && !fieldValue.IsNil() {
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() == reflect.Struct && fieldValue.Type() != reflect.TypeOf(time.Time{}) {
+			errs = validateFields(fieldValue, prefix+name+".", exists, errs)
+		}
+	}
+
+	return errs
+}
+
+// validateField checks a field against its rules, returning the first it
+// breaks.
+func validateField(name string, value reflect.Value, rules string, exists referenceCheck) error {
+	var (
+		required, omitempty bool
+		notBlank            bool
+		atLeast, atMost     *int
+		oneOf               []string
+		reference           string
+	)
+
+	for _, rule := range strings.Split(rules, ",") {
+		key, arg, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "omitempty":
+			omitempty = true
+		case "notblank":
+			notBlank = true
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				panic(fmt.Sprintf("validate: bad bound in '%s' of '%s'", rule, name))
+			}
+			if key == "min" {
+				atLeast = &n
+			} else {
+				atMost = &n
+			}
+		case "oneof":
+			oneOf = strings.Split(arg, "|")
+		case "exists":
+			reference = arg
+		default:
+			panic(fmt.Sprintf("validate: unknown rule '%s' of '%s'", rule, name))
+		}
+	}
+
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			if required {
+				return apiError{fmt.Sprintf("'%s' is required", name)}
+			}
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	if value.IsZero() || (value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "") {
+		if required {
+			return apiError{fmt.Sprintf("'%s' is required", name)}
+		}
+		if notBlank && value.Kind() == reflect.String {
+			return apiError{fmt.Sprintf("'%s' must not be blank", name)}
+		}
+		if omitempty {
+			return nil
+		}
+	}
+
+	var size int
+	unit := ""
+	switch value.Kind() {
+	case reflect.String:
+		size, unit = utf8.RuneCountInString(value.String()), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size, unit = value.Len(), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = int(value.Int())
+	}
+
+	if (atLeast != nil && size < *atLeast) || (atMost != nil && size > *atMost) {
+		return apiError{fmt.Sprintf("'%s' must be %s%s", name, describeBounds(atLeast, atMost), unit)}
+	}
+
+	if oneOf != nil && !slices.Contains(oneOf, value.String()) {
+		return apiError{fmt.Sprintf("'%s' must be one of '%s'", name, strings.Join(oneOf, "', '"))}
+	}
+
+	if reference != "" {
+		if exists == nil {
+			panic(fmt.Sprintf("validate: no reference check for '%s'", name))
+		}
+		found, err := exists(reference, int(value.Int()))
+		if err != nil {
+			return apiError{badValidationString(reference)}
+		}
+		if !found {
+			return apiError{fmt.Sprintf("'%s' must be the ID of an existing %s", name, reference)}
+		}
+	}
+
+	return nil
+}
+
+func describeBounds(atLeast, atMost *int) string {
+	switch {
+	case atLeast != nil && atMost != nil:
+		return fmt.Sprintf("between %d and %d", *atLeast, *atMost)
+	case atLeast != nil:
+		return fmt.Sprintf("at least %d", *atLeast)
+	default:
+		return fmt.Sprintf("at most %d", *atMost)
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// serviceHistorySize is how many recently served tickets are given to the
+// wait time strategy.
+const serviceHistorySize = 50
+
+type ticketResponse struct {
+	types.Ticket
+	Position             *int `json:"position,omitempty"`
+	EstimatedWaitSeconds *int `json:"estimated_wait_seconds,omitempty"`
+}
+
+type categoryResponse struct {
+	types.Category
+	Waiting              int  `json:"waiting"`
+	OpenDesks            int  `json:"open_desks"`
+	EstimatedWaitSeconds *int `json:"estimated_wait_seconds,omitempty"`
+}
+
+// estimateWait returns the expected wait in seconds for a ticket with ahead
+// tickets in front of it, or nil when no desk serving the category is open.
+func (s *APIServer) estimateWait(ctx context.Context, categoryID int, ahead int) (*int, int, error) {
+	history, err := s.storeFor(ctx).ServiceDurations(categoryID, serviceHistorySize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	openDesks, err := s.storeFor(ctx).CountOpenDesks(categoryID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	wait, ok := s.waitEstimator.Estimate(history, ahead, openDesks)
+	if !ok {
+		return nil, openDesks, nil
+	}
+
+	seconds := int(wait / time.Second)
+	return &seconds, openDesks, nil
+}
+
+func (s *APIServer) newTicketResponse(ctx context.Context, ticket types.Ticket) (ticketResponse, error) {
+	response := ticketResponse{Ticket: ticket}
+
+	ahead, err := s.storeFor(ctx).TicketsAhead(ticket.ID)
+	if err != nil {
+		if err == types.ErrTicketNotWaiting {
+			return response, nil
+		}
+		return ticketResponse{}, err
+	}
+
+	response.Position = &ahead
+	response.EstimatedWaitSeconds, _, err = s.estimateWait(ctx, ticket.CategoryID, ahead)
+	if err != nil {
+		return ticketResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (s *APIServer) newCategoryResponse(ctx context.Context, category types.Category) (categoryResponse, error) {
+	response := categoryResponse{Category: category}
+
+	var err error
+
+	response.Waiting, err = s.storeFor(ctx).CountWaiting(category.ID)
+	if err != nil {
+		return categoryResponse{}, err
+	}
+
+	response.EstimatedWaitSeconds, response.OpenDesks, err = s.estimateWait(ctx, category.ID, response.Waiting)
+	if err != nil {
+		return categoryResponse{}, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/khaleelsyed/codaVirtuale/internal/safehttp"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const webhookDeliveryListLimit = 100
+
+// webhookEventTypes are the events a webhook subscription may ask for.
+var webhookEventTypes = []string{
+	types.TicketCreated.WebhookEvent(),
+	types.TicketCalled.WebhookEvent(),
+	types.TicketRecalled.WebhookEvent(),
+	types.TicketServing.WebhookEvent(),
+	types.TicketTransferred.WebhookEvent(),
+	types.TicketClosed.WebhookEvent(),
+	types.TicketDeleted.WebhookEvent(),
+	types.TicketCancelled.WebhookEvent(),
+	types.TicketSnoozed.WebhookEvent(),
+	types.TicketNoShow.WebhookEvent(),
+	types.TicketRequeued.WebhookEvent(),
+	types.TicketCheckedIn.WebhookEvent(),
+	types.DeskOpen.WebhookEvent(),
+	types.DeskPaused.WebhookEvent(),
+	types.DeskClosed.WebhookEvent(),
+}
+
+func (s *APIServer) addWebhookRoutes(router *mux.Router) {
+	router.HandleFunc("/webhooks", makeHTTPHandler(s.handleWebhooks, []string{http.MethodGet, http.MethodPost}, s.logger))
+	router.HandleFunc("/webhooks/{id}", makeHTTPHandler(s.handleWebhook, []string{http.MethodGet, http.MethodPut, http.MethodDelete}, s.logger))
+	router.HandleFunc("/webhooks/{id}/deliveries", makeHTTPHandler(s.getWebhookDeliveries, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/webhook-deliveries/{id}", makeHTTPHandler(s.getWebhookDelivery, []string{http.MethodGet}, s.logger))
+	router.HandleFunc("/webhook-deliveries/{id}/redeliver", makeHTTPHandler(s.postRedeliverWebhook, []string{http.MethodPost}, s.logger))
+}
+
+type webhookRequest struct {
+	URL        string   `json:"url" validate:"required,max=2048"`
+	EventTypes []string `json:"event_types"`
+	Active     *bool    `json:"active,omitempty"`
+	Secret     string   `json:"secret,omitempty" validate:"omitempty,min=16,max=100"`
+}
+
+func (body webhookRequest) validate() []error {
+	var errs []error
+
+	if body.URL != "" {
+		if !safehttp.ValidURL(body.URL) {
+			errs = append(errs, apiError{"'url' must be a public https URL"})
+		}
+	}
+	if len(body.EventTypes) == 0 {
+		errs = append(errs, apiError{"'event_types' must list at least one event type"})
+	}
+	for _, eventType := range body.EventTypes {
+		if !slices.Contains(webhookEventTypes, eventType) {
+			errs = append(errs, apiError{fmt.Sprintf("unknown event type '%s'", eventType)})
+		}
+	}
+
+	return errs
+}
+
+func (s *APIServer) webhookIDFromVars(w http.ResponseWriter, r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		errBody := "bad ID"
+		return 0, writeJSON(w, http.StatusBadRequest, errBody, s.logger)
+	}
+	return id, nil
+}
+
+func (s *APIServer) getWebhooks(w http.ResponseWriter, r *http.Request) error {
+	subscriptions, err := s.store(r).ListWebhookSubscriptions()
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	return writeJSON(w, http.StatusOK, subscriptions, s.logger)
+}
+
+// createWebhook subscribes a URL to events. The signing secret is generated
+// unless one is given, and is only ever returned in this response.
+func (s *APIServer) createWebhook(w http.ResponseWriter, r *http.Request) error {
+	var requestBody webhookRequest
+
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
+	}
+
+	secret := requestBody.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		rand.Read(b)
+		secret = hex.EncodeToString(b)
+	}
+
+	subscription, err := s.store(r).CreateWebhookSubscription(types.WebhookSubscription{
+		URL:        requestBody.URL,
+		EventTypes: requestBody.EventTypes,
+		Secret:     secret,
+		Active:     requestBody.Active == nil || *requestBody.Active,
+	})
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, errors.New("error creating webhook"), s.logger)
+	}
+
+	return writeJSON(w, http.StatusCreated, subscription, s.logger)
+}
+
+func (s *APIServer) getWebhook(w http.ResponseWriter, r *http.Request) error {
+	id, err := s.webhookIDFromVars(w, r)
+	if err != nil || id == 0 {
+		return err
+	}
+
+	subscription, err := s.store(r).GetWebhookSubscription(id)
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	subscription.Secret = ""
+	return writeJSON(w, http.StatusOK, subscription, s.logger)
+}
+
+func (s *APIServer) putWebhook(w http.ResponseWriter, r *http.Request) error {
+	id, err := s.webhookIDFromVars(w, r)
+	if err != nil || id == 0 {
+		return err
+	}
+
+	var requestBody webhookRequest
+
+	if ok, err := s.readRequest(w, r, &requestBody); !ok {
+		return err
+	}
+	if requestBody.Secret != "" {
+		return writeJSON(w, http.StatusBadRequest, apiError{"'secret' cannot be changed, create a new webhook instead"}, s.logger)
+	}
+
+	subscription, err := s.store(r).UpdateWebhookSubscription(types.WebhookSubscription{
+		ID:         id,
+		URL:        requestBody.URL,
+		EventTypes: requestBody.EventTypes,
+		Active:     requestBody.Active == nil || *requestBody.Active,
+	})
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	subscription.Secret = ""
+	return writeJSON(w, http.StatusOK, subscription, s.logger)
+}
+
+func (s *APIServer) deleteWebhook(w http.ResponseWriter, r *http.Request) error {
+	id, err := s.webhookIDFromVars(w, r)
+	if err != nil || id == 0 {
+		return err
+	}
+
+	if _, err = s.store(r).GetWebhookSubscription(id); err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	if err = s.store(r).DeleteWebhookSubscription(id); err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusNoContent, nil, s.logger)
+}
+
+// getWebhookDeliveries lists the most recent deliveries of a subscription.
+func (s *APIServer) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) error {
+	id, err := s.webhookIDFromVars(w, r)
+	if err != nil || id == 0 {
+		return err
+	}
+
+	if _, err = s.store(r).GetWebhookSubscription(id); err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	deliveries, err := s.store(r).ListWebhookDeliveries(id, webhookDeliveryListLimit)
+	if err != nil {
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, deliveries, s.logger)
+}
+
+func (s *APIServer) getWebhookDelivery(w http.ResponseWriter, r *http.Request) error {
+	id, err := s.webhookIDFromVars(w, r)
+	if err != nil || id == 0 {
+		return err
+	}
+
+	delivery, err := s.store(r).GetWebhookDelivery(id)
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusOK, delivery, s.logger)
+}
+
+// postRedeliverWebhook queues the payload of a delivery to be sent again.
+func (s *APIServer) postRedeliverWebhook(w http.ResponseWriter, r *http.Request) error {
+	id, err := s.webhookIDFromVars(w, r)
+	if err != nil || id == 0 {
+		return err
+	}
+
+	delivery, err := s.store(r).RedeliverWebhook(id)
+	if err != nil {
+		if err == types.ErrnotFound {
+			return writeJSON(w, http.StatusNotFound, err, s.logger)
+		}
+		return writeJSON(w, http.StatusInternalServerError, err, s.logger)
+	}
+
+	return writeJSON(w, http.StatusAccepted, delivery, s.logger)
+}
+
+func (s *APIServer) handleWebhooks(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.getWebhooks(w, r)
+	case http.MethodPost:
+		return s.createWebhook(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
+
+func (s *APIServer) handleWebhook(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return s.getWebhook(w, r)
+	case http.MethodPut:
+		return s.putWebhook(w, r)
+	case http.MethodDelete:
+		return s.deleteWebhook(w, r)
+	default:
+		s.logger.Errorw("unhandled method", "method", r.Method)
+		return writeJSON(w, http.StatusInternalServerError, fmt.Errorf("unhandled method %s", r.Method), s.logger)
+	}
+}
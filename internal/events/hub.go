@@ -0,0 +1,76 @@
+// Package events fans ticket events out to the live views of the queue.
+package events
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// bufferSize is how many events a subscriber may fall behind by before
+// further events are dropped for it.
+const bufferSize = 64
+
+type Subscription struct {
+	C           <-chan types.TicketEvent
+	c           chan types.TicketEvent
+	categoryIDs []int
+	hub         *Hub
+}
+
+// Close stops delivery to the subscription and closes its channel.
+func (sub *Subscription) Close() {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+
+	if _, found := sub.hub.subscriptions[sub]; found {
+		delete(sub.hub.subscriptions, sub)
+		close(sub.c)
+	}
+}
+
+type Hub struct {
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	logger        *types.SugarWithTrace
+}
+
+func NewHub(logger *types.SugarWithTrace) *Hub {
+	return &Hub{
+		subscriptions: make(map[*Subscription]struct{}),
+		logger:        logger,
+	}
+}
+
+// Subscribe returns a subscription to the events of the given categories, or
+// of every category when none are given.
+func (h *Hub) Subscribe(categoryIDs []int) *Subscription {
+	c := make(chan types.TicketEvent, bufferSize)
+	sub := &Subscription{C: c, c: c, categoryIDs: categoryIDs, hub: h}
+
+	h.mu.Lock()
+	h.subscriptions[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Publish delivers an event to every interested subscription without
+// blocking on slow subscribers.
+func (h *Hub) Publish(event types.TicketEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscriptions {
+		if len(sub.categoryIDs) > 0 && !slices.Contains(sub.categoryIDs, event.CategoryID) {
+			continue
+		}
+
+		select {
+		case sub.c <- event:
+		default:
+			h.logger.Warnw("dropped ticket event for slow subscriber", "event_id", event.ID)
+		}
+	}
+}
@@ -0,0 +1,2056 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: coda/v1/queue.proto
+
+// The queue API for systems that prefer gRPC to the REST API, such as kiosks
+// and signage controllers. It acts on the same storage as the REST API.
+//
+// Calls act on the location whose slug is given in the 'x-location' metadata,
+// or on the default location without it. Staff actions are recorded against
+// the 'x-staff-id' metadata, and tickets are limited per client by address
+// and by the 'x-device-token' metadata, as the X- headers of the REST API do.
+
+package codav1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Ticket struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	CategoryId int64                  `protobuf:"varint,2,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	SubUrl     string                 `protobuf:"bytes,3,opt,name=sub_url,json=subUrl,proto3" json:"sub_url,omitempty"`
+	// desk_id is 0 while the ticket is waiting.
+	DeskId        int64                  `protobuf:"varint,4,opt,name=desk_id,json=deskId,proto3" json:"desk_id,omitempty"`
+	Closed        bool                   `protobuf:"varint,5,opt,name=closed,proto3" json:"closed,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	QueuedAt      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=queued_at,json=queuedAt,proto3" json:"queued_at,omitempty"`
+	Snoozes       int32                  `protobuf:"varint,8,opt,name=snoozes,proto3" json:"snoozes,omitempty"`
+	CalledAt      *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=called_at,json=calledAt,proto3" json:"called_at,omitempty"`
+	Recalls       int32                  `protobuf:"varint,10,opt,name=recalls,proto3" json:"recalls,omitempty"`
+	Priority      bool                   `protobuf:"varint,11,opt,name=priority,proto3" json:"priority,omitempty"`
+	DisplayNumber string                 `protobuf:"bytes,12,opt,name=display_number,json=displayNumber,proto3" json:"display_number,omitempty"`
+	// served_at is unset until staff start serving the customer.
+	ServedAt      *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=served_at,json=servedAt,proto3" json:"served_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ticket) Reset() {
+	*x = Ticket{}
+	mi := &file_coda_v1_queue_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ticket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ticket) ProtoMessage() {}
+
+func (x *Ticket) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ticket.ProtoReflect.Descriptor instead.
+func (*Ticket) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Ticket) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Ticket) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *Ticket) GetSubUrl() string {
+	if x != nil {
+		return x.SubUrl
+	}
+	return ""
+}
+
+func (x *Ticket) GetDeskId() int64 {
+	if x != nil {
+		return x.DeskId
+	}
+	return 0
+}
+
+func (x *Ticket) GetClosed() bool {
+	if x != nil {
+		return x.Closed
+	}
+	return false
+}
+
+func (x *Ticket) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Ticket) GetQueuedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.QueuedAt
+	}
+	return nil
+}
+
+func (x *Ticket) GetSnoozes() int32 {
+	if x != nil {
+		return x.Snoozes
+	}
+	return 0
+}
+
+func (x *Ticket) GetCalledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CalledAt
+	}
+	return nil
+}
+
+func (x *Ticket) GetRecalls() int32 {
+	if x != nil {
+		return x.Recalls
+	}
+	return 0
+}
+
+func (x *Ticket) GetPriority() bool {
+	if x != nil {
+		return x.Priority
+	}
+	return false
+}
+
+func (x *Ticket) GetDisplayNumber() string {
+	if x != nil {
+		return x.DisplayNumber
+	}
+	return ""
+}
+
+func (x *Ticket) GetServedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ServedAt
+	}
+	return nil
+}
+
+// TicketStatus is a ticket with its place in the queue while it is waiting.
+type TicketStatus struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Ticket               *Ticket                `protobuf:"bytes,1,opt,name=ticket,proto3" json:"ticket,omitempty"`
+	Position             *int32                 `protobuf:"varint,2,opt,name=position,proto3,oneof" json:"position,omitempty"`
+	EstimatedWaitSeconds *int32                 `protobuf:"varint,3,opt,name=estimated_wait_seconds,json=estimatedWaitSeconds,proto3,oneof" json:"estimated_wait_seconds,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *TicketStatus) Reset() {
+	*x = TicketStatus{}
+	mi := &file_coda_v1_queue_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TicketStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TicketStatus) ProtoMessage() {}
+
+func (x *TicketStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TicketStatus.ProtoReflect.Descriptor instead.
+func (*TicketStatus) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TicketStatus) GetTicket() *Ticket {
+	if x != nil {
+		return x.Ticket
+	}
+	return nil
+}
+
+func (x *TicketStatus) GetPosition() int32 {
+	if x != nil && x.Position != nil {
+		return *x.Position
+	}
+	return 0
+}
+
+func (x *TicketStatus) GetEstimatedWaitSeconds() int32 {
+	if x != nil && x.EstimatedWaitSeconds != nil {
+		return *x.EstimatedWaitSeconds
+	}
+	return 0
+}
+
+type TicketContact struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Phone         string                 `protobuf:"bytes,2,opt,name=phone,proto3" json:"phone,omitempty"`
+	WebhookUrl    string                 `protobuf:"bytes,3,opt,name=webhook_url,json=webhookUrl,proto3" json:"webhook_url,omitempty"`
+	NotifyAhead   int32                  `protobuf:"varint,4,opt,name=notify_ahead,json=notifyAhead,proto3" json:"notify_ahead,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TicketContact) Reset() {
+	*x = TicketContact{}
+	mi := &file_coda_v1_queue_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TicketContact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TicketContact) ProtoMessage() {}
+
+func (x *TicketContact) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TicketContact.ProtoReflect.Descriptor instead.
+func (*TicketContact) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TicketContact) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *TicketContact) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *TicketContact) GetWebhookUrl() string {
+	if x != nil {
+		return x.WebhookUrl
+	}
+	return ""
+}
+
+func (x *TicketContact) GetNotifyAhead() int32 {
+	if x != nil {
+		return x.NotifyAhead
+	}
+	return 0
+}
+
+type Category struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// version counts the renames of the category.
+	Version       int64 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Category) Reset() {
+	*x = Category{}
+	mi := &file_coda_v1_queue_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Category) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Category) ProtoMessage() {}
+
+func (x *Category) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Category.ProtoReflect.Descriptor instead.
+func (*Category) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Category) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Category) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Category) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// CategoryStatus is a category with the state of its queue.
+type CategoryStatus struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Category             *Category              `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	Waiting              int32                  `protobuf:"varint,2,opt,name=waiting,proto3" json:"waiting,omitempty"`
+	OpenDesks            int32                  `protobuf:"varint,3,opt,name=open_desks,json=openDesks,proto3" json:"open_desks,omitempty"`
+	EstimatedWaitSeconds *int32                 `protobuf:"varint,4,opt,name=estimated_wait_seconds,json=estimatedWaitSeconds,proto3,oneof" json:"estimated_wait_seconds,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *CategoryStatus) Reset() {
+	*x = CategoryStatus{}
+	mi := &file_coda_v1_queue_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryStatus) ProtoMessage() {}
+
+func (x *CategoryStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryStatus.ProtoReflect.Descriptor instead.
+func (*CategoryStatus) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CategoryStatus) GetCategory() *Category {
+	if x != nil {
+		return x.Category
+	}
+	return nil
+}
+
+func (x *CategoryStatus) GetWaiting() int32 {
+	if x != nil {
+		return x.Waiting
+	}
+	return 0
+}
+
+func (x *CategoryStatus) GetOpenDesks() int32 {
+	if x != nil {
+		return x.OpenDesks
+	}
+	return 0
+}
+
+func (x *CategoryStatus) GetEstimatedWaitSeconds() int32 {
+	if x != nil && x.EstimatedWaitSeconds != nil {
+		return *x.EstimatedWaitSeconds
+	}
+	return 0
+}
+
+type Desk struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	CategoryId int64                  `protobuf:"varint,2,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	Label      string                 `protobuf:"bytes,3,opt,name=label,proto3" json:"label,omitempty"`
+	// version counts the changes to the desk.
+	Version       int64 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Desk) Reset() {
+	*x = Desk{}
+	mi := &file_coda_v1_queue_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Desk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Desk) ProtoMessage() {}
+
+func (x *Desk) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Desk.ProtoReflect.Descriptor instead.
+func (*Desk) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Desk) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Desk) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *Desk) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Desk) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type TicketEvent struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	TicketId   int64                  `protobuf:"varint,2,opt,name=ticket_id,json=ticketId,proto3" json:"ticket_id,omitempty"`
+	Type       string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	CategoryId int64                  `protobuf:"varint,4,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	// desk_id is 0 when the ticket was not at a desk.
+	DeskId        int64                  `protobuf:"varint,5,opt,name=desk_id,json=deskId,proto3" json:"desk_id,omitempty"`
+	Staff         string                 `protobuf:"bytes,6,opt,name=staff,proto3" json:"staff,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TicketEvent) Reset() {
+	*x = TicketEvent{}
+	mi := &file_coda_v1_queue_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TicketEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TicketEvent) ProtoMessage() {}
+
+func (x *TicketEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TicketEvent.ProtoReflect.Descriptor instead.
+func (*TicketEvent) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TicketEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TicketEvent) GetTicketId() int64 {
+	if x != nil {
+		return x.TicketId
+	}
+	return 0
+}
+
+func (x *TicketEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *TicketEvent) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *TicketEvent) GetDeskId() int64 {
+	if x != nil {
+		return x.DeskId
+	}
+	return 0
+}
+
+func (x *TicketEvent) GetStaff() string {
+	if x != nil {
+		return x.Staff
+	}
+	return ""
+}
+
+func (x *TicketEvent) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type CreateTicketRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CategoryId    int64                  `protobuf:"varint,1,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	Contact       *TicketContact         `protobuf:"bytes,2,opt,name=contact,proto3" json:"contact,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTicketRequest) Reset() {
+	*x = CreateTicketRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTicketRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTicketRequest) ProtoMessage() {}
+
+func (x *CreateTicketRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTicketRequest.ProtoReflect.Descriptor instead.
+func (*CreateTicketRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateTicketRequest) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *CreateTicketRequest) GetContact() *TicketContact {
+	if x != nil {
+		return x.Contact
+	}
+	return nil
+}
+
+type GetTicketRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTicketRequest) Reset() {
+	*x = GetTicketRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTicketRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTicketRequest) ProtoMessage() {}
+
+func (x *GetTicketRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTicketRequest.ProtoReflect.Descriptor instead.
+func (*GetTicketRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetTicketRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteTicketRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTicketRequest) Reset() {
+	*x = DeleteTicketRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTicketRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTicketRequest) ProtoMessage() {}
+
+func (x *DeleteTicketRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTicketRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTicketRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteTicketRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteTicketResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTicketResponse) Reset() {
+	*x = DeleteTicketResponse{}
+	mi := &file_coda_v1_queue_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTicketResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTicketResponse) ProtoMessage() {}
+
+func (x *DeleteTicketResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTicketResponse.ProtoReflect.Descriptor instead.
+func (*DeleteTicketResponse) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{10}
+}
+
+type ListCategoriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCategoriesRequest) Reset() {
+	*x = ListCategoriesRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCategoriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCategoriesRequest) ProtoMessage() {}
+
+func (x *ListCategoriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCategoriesRequest.ProtoReflect.Descriptor instead.
+func (*ListCategoriesRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{11}
+}
+
+type ListCategoriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Categories    []*Category            `protobuf:"bytes,1,rep,name=categories,proto3" json:"categories,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCategoriesResponse) Reset() {
+	*x = ListCategoriesResponse{}
+	mi := &file_coda_v1_queue_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCategoriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCategoriesResponse) ProtoMessage() {}
+
+func (x *ListCategoriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCategoriesResponse.ProtoReflect.Descriptor instead.
+func (*ListCategoriesResponse) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListCategoriesResponse) GetCategories() []*Category {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+type GetCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCategoryRequest) Reset() {
+	*x = GetCategoryRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCategoryRequest) ProtoMessage() {}
+
+func (x *GetCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCategoryRequest.ProtoReflect.Descriptor instead.
+func (*GetCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetCategoryRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCategoryRequest) Reset() {
+	*x = CreateCategoryRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCategoryRequest) ProtoMessage() {}
+
+func (x *CreateCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCategoryRequest.ProtoReflect.Descriptor instead.
+func (*CreateCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CreateCategoryRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type UpdateCategoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// version is the version the change was based on, so that a concurrent
+	// change is not silently overwritten, or 0 to change it whatever the
+	// version. It is required.
+	Version       *int64 `protobuf:"varint,3,opt,name=version,proto3,oneof" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCategoryRequest) Reset() {
+	*x = UpdateCategoryRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCategoryRequest) ProtoMessage() {}
+
+func (x *UpdateCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCategoryRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UpdateCategoryRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateCategoryRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateCategoryRequest) GetVersion() int64 {
+	if x != nil && x.Version != nil {
+		return *x.Version
+	}
+	return 0
+}
+
+type DeleteCategoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// version is the version the change was based on, so that a concurrent
+	// change is not silently overwritten, or 0 to change it whatever the
+	// version. It is required.
+	Version       *int64 `protobuf:"varint,2,opt,name=version,proto3,oneof" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCategoryRequest) Reset() {
+	*x = DeleteCategoryRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCategoryRequest) ProtoMessage() {}
+
+func (x *DeleteCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCategoryRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DeleteCategoryRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteCategoryRequest) GetVersion() int64 {
+	if x != nil && x.Version != nil {
+		return *x.Version
+	}
+	return 0
+}
+
+type DeleteCategoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCategoryResponse) Reset() {
+	*x = DeleteCategoryResponse{}
+	mi := &file_coda_v1_queue_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCategoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCategoryResponse) ProtoMessage() {}
+
+func (x *DeleteCategoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCategoryResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCategoryResponse) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{17}
+}
+
+type ListDesksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDesksRequest) Reset() {
+	*x = ListDesksRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDesksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDesksRequest) ProtoMessage() {}
+
+func (x *ListDesksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDesksRequest.ProtoReflect.Descriptor instead.
+func (*ListDesksRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{18}
+}
+
+type ListDesksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Desks         []*Desk                `protobuf:"bytes,1,rep,name=desks,proto3" json:"desks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDesksResponse) Reset() {
+	*x = ListDesksResponse{}
+	mi := &file_coda_v1_queue_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDesksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDesksResponse) ProtoMessage() {}
+
+func (x *ListDesksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDesksResponse.ProtoReflect.Descriptor instead.
+func (*ListDesksResponse) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListDesksResponse) GetDesks() []*Desk {
+	if x != nil {
+		return x.Desks
+	}
+	return nil
+}
+
+type GetDeskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeskRequest) Reset() {
+	*x = GetDeskRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeskRequest) ProtoMessage() {}
+
+func (x *GetDeskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeskRequest.ProtoReflect.Descriptor instead.
+func (*GetDeskRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetDeskRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateDeskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	CategoryId    int64                  `protobuf:"varint,2,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDeskRequest) Reset() {
+	*x = CreateDeskRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDeskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDeskRequest) ProtoMessage() {}
+
+func (x *CreateDeskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDeskRequest.ProtoReflect.Descriptor instead.
+func (*CreateDeskRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CreateDeskRequest) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *CreateDeskRequest) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+type UpdateDeskRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Label      string                 `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	CategoryId int64                  `protobuf:"varint,3,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	// version is the version the change was based on, so that a concurrent
+	// change is not silently overwritten, or 0 to change it whatever the
+	// version. It is required.
+	Version       *int64 `protobuf:"varint,4,opt,name=version,proto3,oneof" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateDeskRequest) Reset() {
+	*x = UpdateDeskRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateDeskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDeskRequest) ProtoMessage() {}
+
+func (x *UpdateDeskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDeskRequest.ProtoReflect.Descriptor instead.
+func (*UpdateDeskRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *UpdateDeskRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateDeskRequest) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *UpdateDeskRequest) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *UpdateDeskRequest) GetVersion() int64 {
+	if x != nil && x.Version != nil {
+		return *x.Version
+	}
+	return 0
+}
+
+type DeleteDeskRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// version is the version the change was based on, so that a concurrent
+	// change is not silently overwritten, or 0 to change it whatever the
+	// version. It is required.
+	Version       *int64 `protobuf:"varint,2,opt,name=version,proto3,oneof" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDeskRequest) Reset() {
+	*x = DeleteDeskRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDeskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDeskRequest) ProtoMessage() {}
+
+func (x *DeleteDeskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDeskRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDeskRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DeleteDeskRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteDeskRequest) GetVersion() int64 {
+	if x != nil && x.Version != nil {
+		return *x.Version
+	}
+	return 0
+}
+
+type DeleteDeskResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDeskResponse) Reset() {
+	*x = DeleteDeskResponse{}
+	mi := &file_coda_v1_queue_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDeskResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDeskResponse) ProtoMessage() {}
+
+func (x *DeleteDeskResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDeskResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDeskResponse) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{24}
+}
+
+type CallNextRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeskId        int64                  `protobuf:"varint,1,opt,name=desk_id,json=deskId,proto3" json:"desk_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CallNextRequest) Reset() {
+	*x = CallNextRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CallNextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallNextRequest) ProtoMessage() {}
+
+func (x *CallNextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallNextRequest.ProtoReflect.Descriptor instead.
+func (*CallNextRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CallNextRequest) GetDeskId() int64 {
+	if x != nil {
+		return x.DeskId
+	}
+	return 0
+}
+
+type PeekNextRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CategoryId    int64                  `protobuf:"varint,1,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeekNextRequest) Reset() {
+	*x = PeekNextRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeekNextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeekNextRequest) ProtoMessage() {}
+
+func (x *PeekNextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeekNextRequest.ProtoReflect.Descriptor instead.
+func (*PeekNextRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *PeekNextRequest) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+type ListQueueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CategoryIds   []int64                `protobuf:"varint,1,rep,packed,name=category_ids,json=categoryIds,proto3" json:"category_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListQueueRequest) Reset() {
+	*x = ListQueueRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListQueueRequest) ProtoMessage() {}
+
+func (x *ListQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListQueueRequest.ProtoReflect.Descriptor instead.
+func (*ListQueueRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListQueueRequest) GetCategoryIds() []int64 {
+	if x != nil {
+		return x.CategoryIds
+	}
+	return nil
+}
+
+type ListQueueResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tickets       []*Ticket              `protobuf:"bytes,1,rep,name=tickets,proto3" json:"tickets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListQueueResponse) Reset() {
+	*x = ListQueueResponse{}
+	mi := &file_coda_v1_queue_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListQueueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListQueueResponse) ProtoMessage() {}
+
+func (x *ListQueueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListQueueResponse.ProtoReflect.Descriptor instead.
+func (*ListQueueResponse) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListQueueResponse) GetTickets() []*Ticket {
+	if x != nil {
+		return x.Tickets
+	}
+	return nil
+}
+
+type TicketActionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TicketActionRequest) Reset() {
+	*x = TicketActionRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TicketActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TicketActionRequest) ProtoMessage() {}
+
+func (x *TicketActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TicketActionRequest.ProtoReflect.Descriptor instead.
+func (*TicketActionRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *TicketActionRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type TransferTicketRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	CategoryId    int64                  `protobuf:"varint,2,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferTicketRequest) Reset() {
+	*x = TransferTicketRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferTicketRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferTicketRequest) ProtoMessage() {}
+
+func (x *TransferTicketRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferTicketRequest.ProtoReflect.Descriptor instead.
+func (*TransferTicketRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *TransferTicketRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TransferTicketRequest) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+type WatchQueueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CategoryIds   []int64                `protobuf:"varint,1,rep,packed,name=category_ids,json=categoryIds,proto3" json:"category_ids,omitempty"`
+	LastEventId   int64                  `protobuf:"varint,2,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchQueueRequest) Reset() {
+	*x = WatchQueueRequest{}
+	mi := &file_coda_v1_queue_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchQueueRequest) ProtoMessage() {}
+
+func (x *WatchQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coda_v1_queue_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchQueueRequest.ProtoReflect.Descriptor instead.
+func (*WatchQueueRequest) Descriptor() ([]byte, []int) {
+	return file_coda_v1_queue_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *WatchQueueRequest) GetCategoryIds() []int64 {
+	if x != nil {
+		return x.CategoryIds
+	}
+	return nil
+}
+
+func (x *WatchQueueRequest) GetLastEventId() int64 {
+	if x != nil {
+		return x.LastEventId
+	}
+	return 0
+}
+
+var File_coda_v1_queue_proto protoreflect.FileDescriptor
+
+const file_coda_v1_queue_proto_rawDesc = "" +
+	"\n" +
+	"\x13coda/v1/queue.proto\x12\acoda.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe0\x03\n" +
+	"\x06Ticket\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1f\n" +
+	"\vcategory_id\x18\x02 \x01(\x03R\n" +
+	"categoryId\x12\x17\n" +
+	"\asub_url\x18\x03 \x01(\tR\x06subUrl\x12\x17\n" +
+	"\adesk_id\x18\x04 \x01(\x03R\x06deskId\x12\x16\n" +
+	"\x06closed\x18\x05 \x01(\bR\x06closed\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x127\n" +
+	"\tqueued_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\bqueuedAt\x12\x18\n" +
+	"\asnoozes\x18\b \x01(\x05R\asnoozes\x127\n" +
+	"\tcalled_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\bcalledAt\x12\x18\n" +
+	"\arecalls\x18\n" +
+	" \x01(\x05R\arecalls\x12\x1a\n" +
+	"\bpriority\x18\v \x01(\bR\bpriority\x12%\n" +
+	"\x0edisplay_number\x18\f \x01(\tR\rdisplayNumber\x127\n" +
+	"\tserved_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\bservedAt\"\xbb\x01\n" +
+	"\fTicketStatus\x12'\n" +
+	"\x06ticket\x18\x01 \x01(\v2\x0f.coda.v1.TicketR\x06ticket\x12\x1f\n" +
+	"\bposition\x18\x02 \x01(\x05H\x00R\bposition\x88\x01\x01\x129\n" +
+	"\x16estimated_wait_seconds\x18\x03 \x01(\x05H\x01R\x14estimatedWaitSeconds\x88\x01\x01B\v\n" +
+	"\t_positionB\x19\n" +
+	"\x17_estimated_wait_seconds\"\x7f\n" +
+	"\rTicketContact\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x14\n" +
+	"\x05phone\x18\x02 \x01(\tR\x05phone\x12\x1f\n" +
+	"\vwebhook_url\x18\x03 \x01(\tR\n" +
+	"webhookUrl\x12!\n" +
+	"\fnotify_ahead\x18\x04 \x01(\x05R\vnotifyAhead\"H\n" +
+	"\bCategory\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\"\xce\x01\n" +
+	"\x0eCategoryStatus\x12-\n" +
+	"\bcategory\x18\x01 \x01(\v2\x11.coda.v1.CategoryR\bcategory\x12\x18\n" +
+	"\awaiting\x18\x02 \x01(\x05R\awaiting\x12\x1d\n" +
+	"\n" +
+	"open_desks\x18\x03 \x01(\x05R\topenDesks\x129\n" +
+	"\x16estimated_wait_seconds\x18\x04 \x01(\x05H\x00R\x14estimatedWaitSeconds\x88\x01\x01B\x19\n" +
+	"\x17_estimated_wait_seconds\"g\n" +
+	"\x04Desk\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1f\n" +
+	"\vcategory_id\x18\x02 \x01(\x03R\n" +
+	"categoryId\x12\x14\n" +
+	"\x05label\x18\x03 \x01(\tR\x05label\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\"\xd9\x01\n" +
+	"\vTicketEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1b\n" +
+	"\tticket_id\x18\x02 \x01(\x03R\bticketId\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x1f\n" +
+	"\vcategory_id\x18\x04 \x01(\x03R\n" +
+	"categoryId\x12\x17\n" +
+	"\adesk_id\x18\x05 \x01(\x03R\x06deskId\x12\x14\n" +
+	"\x05staff\x18\x06 \x01(\tR\x05staff\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"h\n" +
+	"\x13CreateTicketRequest\x12\x1f\n" +
+	"\vcategory_id\x18\x01 \x01(\x03R\n" +
+	"categoryId\x120\n" +
+	"\acontact\x18\x02 \x01(\v2\x16.coda.v1.TicketContactR\acontact\"\"\n" +
+	"\x10GetTicketRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"%\n" +
+	"\x13DeleteTicketRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x16\n" +
+	"\x14DeleteTicketResponse\"\x17\n" +
+	"\x15ListCategoriesRequest\"K\n" +
+	"\x16ListCategoriesResponse\x121\n" +
+	"\n" +
+	"categories\x18\x01 \x03(\v2\x11.coda.v1.CategoryR\n" +
+	"categories\"$\n" +
+	"\x12GetCategoryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"+\n" +
+	"\x15CreateCategoryRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"f\n" +
+	"\x15UpdateCategoryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1d\n" +
+	"\aversion\x18\x03 \x01(\x03H\x00R\aversion\x88\x01\x01B\n" +
+	"\n" +
+	"\b_version\"R\n" +
+	"\x15DeleteCategoryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\aversion\x18\x02 \x01(\x03H\x00R\aversion\x88\x01\x01B\n" +
+	"\n" +
+	"\b_version\"\x18\n" +
+	"\x16DeleteCategoryResponse\"\x12\n" +
+	"\x10ListDesksRequest\"8\n" +
+	"\x11ListDesksResponse\x12#\n" +
+	"\x05desks\x18\x01 \x03(\v2\r.coda.v1.DeskR\x05desks\" \n" +
+	"\x0eGetDeskRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"J\n" +
+	"\x11CreateDeskRequest\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\x12\x1f\n" +
+	"\vcategory_id\x18\x02 \x01(\x03R\n" +
+	"categoryId\"\x85\x01\n" +
+	"\x11UpdateDeskRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\x12\x1f\n" +
+	"\vcategory_id\x18\x03 \x01(\x03R\n" +
+	"categoryId\x12\x1d\n" +
+	"\aversion\x18\x04 \x01(\x03H\x00R\aversion\x88\x01\x01B\n" +
+	"\n" +
+	"\b_version\"N\n" +
+	"\x11DeleteDeskRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\aversion\x18\x02 \x01(\x03H\x00R\aversion\x88\x01\x01B\n" +
+	"\n" +
+	"\b_version\"\x14\n" +
+	"\x12DeleteDeskResponse\"*\n" +
+	"\x0fCallNextRequest\x12\x17\n" +
+	"\adesk_id\x18\x01 \x01(\x03R\x06deskId\"2\n" +
+	"\x0fPeekNextRequest\x12\x1f\n" +
+	"\vcategory_id\x18\x01 \x01(\x03R\n" +
+	"categoryId\"5\n" +
+	"\x10ListQueueRequest\x12!\n" +
+	"\fcategory_ids\x18\x01 \x03(\x03R\vcategoryIds\">\n" +
+	"\x11ListQueueResponse\x12)\n" +
+	"\atickets\x18\x01 \x03(\v2\x0f.coda.v1.TicketR\atickets\"%\n" +
+	"\x13TicketActionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"H\n" +
+	"\x15TransferTicketRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1f\n" +
+	"\vcategory_id\x18\x02 \x01(\x03R\n" +
+	"categoryId\"Z\n" +
+	"\x11WatchQueueRequest\x12!\n" +
+	"\fcategory_ids\x18\x01 \x03(\x03R\vcategoryIds\x12\"\n" +
+	"\rlast_event_id\x18\x02 \x01(\x03R\vlastEventId2\xb3\v\n" +
+	"\fQueueService\x12C\n" +
+	"\fCreateTicket\x12\x1c.coda.v1.CreateTicketRequest\x1a\x15.coda.v1.TicketStatus\x12=\n" +
+	"\tGetTicket\x12\x19.coda.v1.GetTicketRequest\x1a\x15.coda.v1.TicketStatus\x12K\n" +
+	"\fDeleteTicket\x12\x1c.coda.v1.DeleteTicketRequest\x1a\x1d.coda.v1.DeleteTicketResponse\x12Q\n" +
+	"\x0eListCategories\x12\x1e.coda.v1.ListCategoriesRequest\x1a\x1f.coda.v1.ListCategoriesResponse\x12C\n" +
+	"\vGetCategory\x12\x1b.coda.v1.GetCategoryRequest\x1a\x17.coda.v1.CategoryStatus\x12C\n" +
+	"\x0eCreateCategory\x12\x1e.coda.v1.CreateCategoryRequest\x1a\x11.coda.v1.Category\x12C\n" +
+	"\x0eUpdateCategory\x12\x1e.coda.v1.UpdateCategoryRequest\x1a\x11.coda.v1.Category\x12Q\n" +
+	"\x0eDeleteCategory\x12\x1e.coda.v1.DeleteCategoryRequest\x1a\x1f.coda.v1.DeleteCategoryResponse\x12B\n" +
+	"\tListDesks\x12\x19.coda.v1.ListDesksRequest\x1a\x1a.coda.v1.ListDesksResponse\x121\n" +
+	"\aGetDesk\x12\x17.coda.v1.GetDeskRequest\x1a\r.coda.v1.Desk\x127\n" +
+	"\n" +
+	"CreateDesk\x12\x1a.coda.v1.CreateDeskRequest\x1a\r.coda.v1.Desk\x127\n" +
+	"\n" +
+	"UpdateDesk\x12\x1a.coda.v1.UpdateDeskRequest\x1a\r.coda.v1.Desk\x12E\n" +
+	"\n" +
+	"DeleteDesk\x12\x1a.coda.v1.DeleteDeskRequest\x1a\x1b.coda.v1.DeleteDeskResponse\x125\n" +
+	"\bCallNext\x12\x18.coda.v1.CallNextRequest\x1a\x0f.coda.v1.Ticket\x125\n" +
+	"\bPeekNext\x12\x18.coda.v1.PeekNextRequest\x1a\x0f.coda.v1.Ticket\x12B\n" +
+	"\tListQueue\x12\x19.coda.v1.ListQueueRequest\x1a\x1a.coda.v1.ListQueueResponse\x12=\n" +
+	"\fRecallTicket\x12\x1c.coda.v1.TicketActionRequest\x1a\x0f.coda.v1.Ticket\x12<\n" +
+	"\vServeTicket\x12\x1c.coda.v1.TicketActionRequest\x1a\x0f.coda.v1.Ticket\x12A\n" +
+	"\x0eTransferTicket\x12\x1e.coda.v1.TransferTicketRequest\x1a\x0f.coda.v1.Ticket\x12<\n" +
+	"\vCloseTicket\x12\x1c.coda.v1.TicketActionRequest\x1a\x0f.coda.v1.Ticket\x12;\n" +
+	"\n" +
+	"MarkNoShow\x12\x1c.coda.v1.TicketActionRequest\x1a\x0f.coda.v1.Ticket\x12@\n" +
+	"\n" +
+	"WatchQueue\x12\x1a.coda.v1.WatchQueueRequest\x1a\x14.coda.v1.TicketEvent0\x01BAZ?github.com/khaleelsyed/codaVirtuale/internal/gen/coda/v1;codav1b\x06proto3"
+
+var (
+	file_coda_v1_queue_proto_rawDescOnce sync.Once
+	file_coda_v1_queue_proto_rawDescData []byte
+)
+
+func file_coda_v1_queue_proto_rawDescGZIP() []byte {
+	file_coda_v1_queue_proto_rawDescOnce.Do(func() {
+		file_coda_v1_queue_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_coda_v1_queue_proto_rawDesc), len(file_coda_v1_queue_proto_rawDesc)))
+	})
+	return file_coda_v1_queue_proto_rawDescData
+}
+
+var file_coda_v1_queue_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
+var file_coda_v1_queue_proto_goTypes = []any{
+	(*Ticket)(nil),                 // 0: coda.v1.Ticket
+	(*TicketStatus)(nil),           // 1: coda.v1.TicketStatus
+	(*TicketContact)(nil),          // 2: coda.v1.TicketContact
+	(*Category)(nil),               // 3: coda.v1.Category
+	(*CategoryStatus)(nil),         // 4: coda.v1.CategoryStatus
+	(*Desk)(nil),                   // 5: coda.v1.Desk
+	(*TicketEvent)(nil),            // 6: coda.v1.TicketEvent
+	(*CreateTicketRequest)(nil),    // 7: coda.v1.CreateTicketRequest
+	(*GetTicketRequest)(nil),       // 8: coda.v1.GetTicketRequest
+	(*DeleteTicketRequest)(nil),    // 9: coda.v1.DeleteTicketRequest
+	(*DeleteTicketResponse)(nil),   // 10: coda.v1.DeleteTicketResponse
+	(*ListCategoriesRequest)(nil),  // 11: coda.v1.ListCategoriesRequest
+	(*ListCategoriesResponse)(nil), // 12: coda.v1.ListCategoriesResponse
+	(*GetCategoryRequest)(nil),     // 13: coda.v1.GetCategoryRequest
+	(*CreateCategoryRequest)(nil),  // 14: coda.v1.CreateCategoryRequest
+	(*UpdateCategoryRequest)(nil),  // 15: coda.v1.UpdateCategoryRequest
+	(*DeleteCategoryRequest)(nil),  // 16: coda.v1.DeleteCategoryRequest
+	(*DeleteCategoryResponse)(nil), // 17: coda.v1.DeleteCategoryResponse
+	(*ListDesksRequest)(nil),       // 18: coda.v1.ListDesksRequest
+	(*ListDesksResponse)(nil),      // 19: coda.v1.ListDesksResponse
+	(*GetDeskRequest)(nil),         // 20: coda.v1.GetDeskRequest
+	(*CreateDeskRequest)(nil),      // 21: coda.v1.CreateDeskRequest
+	(*UpdateDeskRequest)(nil),      // 22: coda.v1.UpdateDeskRequest
+	(*DeleteDeskRequest)(nil),      // 23: coda.v1.DeleteDeskRequest
+	(*DeleteDeskResponse)(nil),     // 24: coda.v1.DeleteDeskResponse
+	(*CallNextRequest)(nil),        // 25: coda.v1.CallNextRequest
+	(*PeekNextRequest)(nil),        // 26: coda.v1.PeekNextRequest
+	(*ListQueueRequest)(nil),       // 27: coda.v1.ListQueueRequest
+	(*ListQueueResponse)(nil),      // 28: coda.v1.ListQueueResponse
+	(*TicketActionRequest)(nil),    // 29: coda.v1.TicketActionRequest
+	(*TransferTicketRequest)(nil),  // 30: coda.v1.TransferTicketRequest
+	(*WatchQueueRequest)(nil),      // 31: coda.v1.WatchQueueRequest
+	(*timestamppb.Timestamp)(nil),  // 32: google.protobuf.Timestamp
+}
+var file_coda_v1_queue_proto_depIdxs = []int32{
+	32, // 0: coda.v1.Ticket.created_at:type_name -> google.protobuf.Timestamp
+	32, // 1: coda.v1.Ticket.queued_at:type_name -> google.protobuf.Timestamp
+	32, // 2: coda.v1.Ticket.called_at:type_name -> google.protobuf.Timestamp
+	32, // 3: coda.v1.Ticket.served_at:type_name -> google.protobuf.Timestamp
+	0,  // 4: coda.v1.TicketStatus.ticket:type_name -> coda.v1.Ticket
+	3,  // 5: coda.v1.CategoryStatus.category:type_name -> coda.v1.Category
+	32, // 6: coda.v1.TicketEvent.created_at:type_name -> google.protobuf.Timestamp
+	2,  // 7: coda.v1.CreateTicketRequest.contact:type_name -> coda.v1.TicketContact
+	3,  // 8: coda.v1.ListCategoriesResponse.categories:type_name -> coda.v1.Category
+	5,  // 9: coda.v1.ListDesksResponse.desks:type_name -> coda.v1.Desk
+	0,  // 10: coda.v1.ListQueueResponse.tickets:type_name -> coda.v1.Ticket
+	7,  // 11: coda.v1.QueueService.CreateTicket:input_type -> coda.v1.CreateTicketRequest
+	8,  // 12: coda.v1.QueueService.GetTicket:input_type -> coda.v1.GetTicketRequest
+	9,  // 13: coda.v1.QueueService.DeleteTicket:input_type -> coda.v1.DeleteTicketRequest
+	11, // 14: coda.v1.QueueService.ListCategories:input_type -> coda.v1.ListCategoriesRequest
+	13, // 15: coda.v1.QueueService.GetCategory:input_type -> coda.v1.GetCategoryRequest
+	14, // 16: coda.v1.QueueService.CreateCategory:input_type -> coda.v1.CreateCategoryRequest
+	15, // 17: coda.v1.QueueService.UpdateCategory:input_type -> coda.v1.UpdateCategoryRequest
+	16, // 18: coda.v1.QueueService.DeleteCategory:input_type -> coda.v1.DeleteCategoryRequest
+	18, // 19: coda.v1.QueueService.ListDesks:input_type -> coda.v1.ListDesksRequest
+	20, // 20: coda.v1.QueueService.GetDesk:input_type -> coda.v1.GetDeskRequest
+	21, // 21: coda.v1.QueueService.CreateDesk:input_type -> coda.v1.CreateDeskRequest
+	22, // 22: coda.v1.QueueService.UpdateDesk:input_type -> coda.v1.UpdateDeskRequest
+	23, // 23: coda.v1.QueueService.DeleteDesk:input_type -> coda.v1.DeleteDeskRequest
+	25, // 24: coda.v1.QueueService.CallNext:input_type -> coda.v1.CallNextRequest
+	26, // 25: coda.v1.QueueService.PeekNext:input_type -> coda.v1.PeekNextRequest
+	27, // 26: coda.v1.QueueService.ListQueue:input_type -> coda.v1.ListQueueRequest
+	29, // 27: coda.v1.QueueService.RecallTicket:input_type -> coda.v1.TicketActionRequest
+	29, // 28: coda.v1.QueueService.ServeTicket:input_type -> coda.v1.TicketActionRequest
+	30, // 29: coda.v1.QueueService.TransferTicket:input_type -> coda.v1.TransferTicketRequest
+	29, // 30: coda.v1.QueueService.CloseTicket:input_type -> coda.v1.TicketActionRequest
+	29, // 31: coda.v1.QueueService.MarkNoShow:input_type -> coda.v1.TicketActionRequest
+	31, // 32: coda.v1.QueueService.WatchQueue:input_type -> coda.v1.WatchQueueRequest
+	1,  // 33: coda.v1.QueueService.CreateTicket:output_type -> coda.v1.TicketStatus
+	1,  // 34: coda.v1.QueueService.GetTicket:output_type -> coda.v1.TicketStatus
+	10, // 35: coda.v1.QueueService.DeleteTicket:output_type -> coda.v1.DeleteTicketResponse
+	12, // 36: coda.v1.QueueService.ListCategories:output_type -> coda.v1.ListCategoriesResponse
+	4,  // 37: coda.v1.QueueService.GetCategory:output_type -> coda.v1.CategoryStatus
+	3,  // 38: coda.v1.QueueService.CreateCategory:output_type -> coda.v1.Category
+	3,  // 39: coda.v1.QueueService.UpdateCategory:output_type -> coda.v1.Category
+	17, // 40: coda.v1.QueueService.DeleteCategory:output_type -> coda.v1.DeleteCategoryResponse
+	19, // 41: coda.v1.QueueService.ListDesks:output_type -> coda.v1.ListDesksResponse
+	5,  // 42: coda.v1.QueueService.GetDesk:output_type -> coda.v1.Desk
+	5,  // 43: coda.v1.QueueService.CreateDesk:output_type -> coda.v1.Desk
+	5,  // 44: coda.v1.QueueService.UpdateDesk:output_type -> coda.v1.Desk
+	24, // 45: coda.v1.QueueService.DeleteDesk:output_type -> coda.v1.DeleteDeskResponse
+	0,  // 46: coda.v1.QueueService.CallNext:output_type -> coda.v1.Ticket
+	0,  // 47: coda.v1.QueueService.PeekNext:output_type -> coda.v1.Ticket
+	28, // 48: coda.v1.QueueService.ListQueue:output_type -> coda.v1.ListQueueResponse
+	0,  // 49: coda.v1.QueueService.RecallTicket:output_type -> coda.v1.Ticket
+	0,  // 50: coda.v1.QueueService.ServeTicket:output_type -> coda.v1.Ticket
+	0,  // 51: coda.v1.QueueService.TransferTicket:output_type -> coda.v1.Ticket
+	0,  // 52: coda.v1.QueueService.CloseTicket:output_type -> coda.v1.Ticket
+	0,  // 53: coda.v1.QueueService.MarkNoShow:output_type -> coda.v1.Ticket
+	6,  // 54: coda.v1.QueueService.WatchQueue:output_type -> coda.v1.TicketEvent
+	33, // [33:55] is the sub-list for method output_type
+	11, // [11:33] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_coda_v1_queue_proto_init() }
+func file_coda_v1_queue_proto_init() {
+	if File_coda_v1_queue_proto != nil {
+		return
+	}
+	file_coda_v1_queue_proto_msgTypes[1].OneofWrappers = []any{}
+	file_coda_v1_queue_proto_msgTypes[4].OneofWrappers = []any{}
+	file_coda_v1_queue_proto_msgTypes[15].OneofWrappers = []any{}
+	file_coda_v1_queue_proto_msgTypes[16].OneofWrappers = []any{}
+	file_coda_v1_queue_proto_msgTypes[22].OneofWrappers = []any{}
+	file_coda_v1_queue_proto_msgTypes[23].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_coda_v1_queue_proto_rawDesc), len(file_coda_v1_queue_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   32,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_coda_v1_queue_proto_goTypes,
+		DependencyIndexes: file_coda_v1_queue_proto_depIdxs,
+		MessageInfos:      file_coda_v1_queue_proto_msgTypes,
+	}.Build()
+	File_coda_v1_queue_proto = out.File
+	file_coda_v1_queue_proto_goTypes = nil
+	file_coda_v1_queue_proto_depIdxs = nil
+}
@@ -0,0 +1,76 @@
+// Package noshow recalls called tickets whose customer has not turned up and,
+// once they have been recalled enough times, marks them as no-shows.
+package noshow
+
+import (
+	"context"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const DefaultInterval = 5 * time.Second
+
+// staff is recorded in the ticket history for actions taken by the worker.
+const staff = "no-show worker"
+
+type Storage interface {
+	OverdueTickets() ([]types.Ticket, error)
+	GetCategorySettings(categoryID int) (types.CategorySettings, error)
+	RecallTicket(id int, staff string) (types.Ticket, error)
+	MarkNoShow(id int, requeue bool, staff string) (types.Ticket, error)
+}
+
+type Worker struct {
+	storage  Storage
+	logger   *types.SugarWithTrace
+	interval time.Duration
+}
+
+func NewWorker(storage Storage, logger *types.SugarWithTrace, interval time.Duration) *Worker {
+	return &Worker{storage: storage, logger: logger, interval: interval}
+}
+
+// Run checks for overdue tickets every interval until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+func (w *Worker) check() {
+	tickets, err := w.storage.OverdueTickets()
+	if err != nil {
+		w.logger.Errorw("failed to list overdue tickets", "error", err)
+		return
+	}
+
+	for _, ticket := range tickets {
+		settings, err := w.storage.GetCategorySettings(ticket.CategoryID)
+		if err != nil {
+			w.logger.Errorw("failed to get category settings", "category_id", ticket.CategoryID, "error", err)
+			continue
+		}
+
+		if ticket.Recalls < settings.MaxRecalls {
+			if _, err = w.storage.RecallTicket(ticket.ID, staff); err != nil {
+				w.logger.Warnw("failed to recall overdue ticket", "id", ticket.ID, "error", err)
+			}
+			continue
+		}
+
+		if _, err = w.storage.MarkNoShow(ticket.ID, settings.RequeueNoShows, staff); err != nil {
+			w.logger.Warnw("failed to mark ticket as no-show", "id", ticket.ID, "error", err)
+			continue
+		}
+		w.logger.Infow("ticket marked as no-show", "id", ticket.ID, "requeued", settings.RequeueNoShows)
+	}
+}
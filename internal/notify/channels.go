@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/safehttp"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// Webhook posts the notification as JSON to the URL the customer gave, for
+// customers relaying notifications to an app or chat service of their own.
+// Its client should be one of safehttp.NewClient, so that the URL cannot
+// reach the server's own network.
+type Webhook struct {
+	Client *http.Client
+}
+
+type webhookPayload struct {
+	types.Notification
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+func (n *Webhook) Notify(ctx context.Context, notification types.Notification) error {
+	subject, message := Message(notification)
+
+	body, err := json.Marshal(webhookPayload{Notification: notification, Subject: subject, Message: message})
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, n.Client, notification.Address, "", body)
+}
+
+// Email sends notifications through an SMTP server, authenticating when a
+// username is set.
+type Email struct {
+	// Addr is the host:port of the SMTP server.
+	Addr     string
+	Username string
+	Password string
+	// From is the sender, either a bare address or one with a display name
+	// such as "Coda Virtuale <queue@example.com>".
+	From string
+}
+
+func (n *Email) Notify(ctx context.Context, notification types.Notification) error {
+	subject, message := Message(notification)
+
+	// The envelope takes the bare address, the header the full form.
+	from, err := mail.ParseAddress(n.From)
+	if err != nil {
+		return permanentError{fmt.Errorf("bad sender address %q: %w", n.From, err)}
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", notification.Address)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(message)
+	msg.WriteString("\r\n")
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		host, _, err := net.SplitHostPort(n.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+
+	err = smtp.SendMail(n.Addr, auth, from.Address, []string{notification.Address}, msg.Bytes())
+
+	// 5xx replies mean the server will never accept the message.
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return permanentError{err}
+	}
+	return err
+}
+
+// SMS sends text messages through an HTTP gateway which accepts a JSON body
+// of the recipient, sender and text, authenticated with a bearer token.
+type SMS struct {
+	Client *http.Client
+	URL    string
+	Token  string
+	From   string
+}
+
+type smsPayload struct {
+	To   string `json:"to"`
+	From string `json:"from,omitempty"`
+	Body string `json:"body"`
+}
+
+func (n *SMS) Notify(ctx context.Context, notification types.Notification) error {
+	_, message := Message(notification)
+
+	body, err := json.Marshal(smsPayload{To: notification.Address, From: n.From, Body: message})
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, n.Client, n.URL, n.Token, body)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, token string, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		if errors.Is(err, safehttp.ErrNotPublic) {
+			return permanentError{err}
+		}
+		return err
+	}
+	defer response.Body.Close()
+
+	return checkStatus(response)
+}
@@ -0,0 +1,260 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/safehttp"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+func calledNotification(channel types.NotificationChannel, address string) types.Notification {
+	return types.Notification{
+		ID:            1,
+		TicketID:      42,
+		DisplayNumber: "A042",
+		Channel:       channel,
+		Address:       address,
+		Kind:          types.NotifyCalled,
+		DeskLabel:     "Desk 3",
+	}
+}
+
+func TestWebhookNotify(t *testing.T) {
+	var got webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := &Webhook{Client: server.Client()}
+	if err := notifier.Notify(context.Background(), calledNotification(types.ChannelWebhook, server.URL)); err != nil {
+		t.Fatalf("Notify() = %v", err)
+	}
+
+	if got.TicketID != 42 || got.Kind != types.NotifyCalled {
+		t.Errorf("payload is of ticket %d and kind %q, want 42 and %q", got.TicketID, got.Kind, types.NotifyCalled)
+	}
+	if got.Subject != "Your ticket has been called" || got.Message != "Ticket A042: please go to Desk 3." {
+		t.Errorf("payload has subject %q and message %q", got.Subject, got.Message)
+	}
+}
+
+func TestWebhookStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantErr   bool
+		permanent bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusBadRequest, true, true},
+		{http.StatusGone, true, true},
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusInternalServerError, true, false},
+		{http.StatusServiceUnavailable, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := (&Webhook{Client: server.Client()}).Notify(context.Background(), calledNotification(types.ChannelWebhook, server.URL))
+			if (err != nil) != tt.wantErr || IsPermanent(err) != tt.permanent {
+				t.Errorf("Notify() = %v, permanent %v, want error %v, permanent %v", err, IsPermanent(err), tt.wantErr, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestWebhookRefusesInternalAddress(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	// The address is refused when connecting, whichever name it was reached
+	// through, even though the URL was never checked with ValidURL.
+	err := (&Webhook{Client: safehttp.NewClient(time.Second)}).Notify(context.Background(), calledNotification(types.ChannelWebhook, server.URL))
+	if err == nil || !IsPermanent(err) {
+		t.Errorf("Notify() = %v, want a permanent error", err)
+	}
+	if hit {
+		t.Error("the request reached the loopback server")
+	}
+}
+
+func TestSMSNotify(t *testing.T) {
+	var got smsPayload
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	notifier := &SMS{Client: server.Client(), URL: server.URL, Token: "secret", From: "CodaV"}
+	if err := notifier.Notify(context.Background(), calledNotification(types.ChannelSMS, "+441234567890")); err != nil {
+		t.Fatalf("Notify() = %v", err)
+	}
+
+	if authorization != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the bearer token", authorization)
+	}
+	want := smsPayload{To: "+441234567890", From: "CodaV", Body: "Ticket A042: please go to Desk 3."}
+	if got != want {
+		t.Errorf("payload = %+v, want %+v", got, want)
+	}
+}
+
+// smtpServer is a stand-in SMTP server accepting a single connection. Like
+// real servers, it refuses a sender that is not a bare address.
+type smtpServer struct {
+	addr     string
+	mailFrom string
+	rcptTo   []string
+	data     string
+	done     chan struct{}
+}
+
+func startSMTPServer(t *testing.T, rejectRecipients bool) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &smtpServer{addr: listener.Addr().String(), done: make(chan struct{})}
+
+	go func() {
+		defer close(server.done)
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 localhost ready")
+
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+
+			verb, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				text.PrintfLine("250 localhost")
+			case "MAIL":
+				server.mailFrom = strings.TrimPrefix(arg, "FROM:")
+				if strings.ContainsAny(strings.TrimSuffix(strings.TrimPrefix(server.mailFrom, "<"), ">"), " <>") {
+					text.PrintfLine("553 5.1.7 bad sender address syntax")
+					continue
+				}
+				text.PrintfLine("250 OK")
+			case "RCPT":
+				if rejectRecipients {
+					text.PrintfLine("550 5.1.1 no such user")
+					continue
+				}
+				server.rcptTo = append(server.rcptTo, strings.TrimPrefix(arg, "TO:"))
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 go ahead")
+				lines, err := text.ReadDotLines()
+				if err != nil {
+					return
+				}
+				server.data = strings.Join(lines, "\n")
+				text.PrintfLine("250 OK")
+			case "QUIT":
+				text.PrintfLine("221 bye")
+				return
+			default:
+				text.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+
+	return server
+}
+
+func TestEmailNotify(t *testing.T) {
+	tests := []struct {
+		name       string
+		from       string
+		wantHeader string
+	}{
+		{"bare address", "queue@example.com", "From: <queue@example.com>"},
+		{"display name", "Coda Virtuale <queue@example.com>", `From: "Coda Virtuale" <queue@example.com>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startSMTPServer(t, false)
+
+			notifier := &Email{Addr: server.addr, From: tt.from}
+			err := notifier.Notify(context.Background(), calledNotification(types.ChannelEmail, "customer@example.org"))
+			<-server.done
+			if err != nil {
+				t.Fatalf("Notify() = %v", err)
+			}
+
+			if server.mailFrom != "<queue@example.com>" {
+				t.Errorf("MAIL FROM:%s, want the bare address", server.mailFrom)
+			}
+			if len(server.rcptTo) != 1 || server.rcptTo[0] != "<customer@example.org>" {
+				t.Errorf("RCPT TO = %q, want the customer", server.rcptTo)
+			}
+			if !strings.Contains(server.data, tt.wantHeader+"\n") {
+				t.Errorf("message has no %q header:\n%s", tt.wantHeader, server.data)
+			}
+			if !strings.Contains(server.data, "Ticket A042: please go to Desk 3.") {
+				t.Errorf("message does not say where to go:\n%s", server.data)
+			}
+		})
+	}
+}
+
+func TestEmailRejected(t *testing.T) {
+	server := startSMTPServer(t, true)
+
+	notifier := &Email{Addr: server.addr, From: "queue@example.com"}
+	err := notifier.Notify(context.Background(), calledNotification(types.ChannelEmail, "nobody@example.org"))
+	<-server.done
+
+	if err == nil || !IsPermanent(err) {
+		t.Errorf("Notify() = %v, want a permanent error", err)
+	}
+}
+
+func TestEmailBadSender(t *testing.T) {
+	notifier := &Email{Addr: "127.0.0.1:1", From: "not an address"}
+	err := notifier.Notify(context.Background(), calledNotification(types.ChannelEmail, "customer@example.org"))
+
+	if err == nil || !IsPermanent(err) {
+		t.Errorf("Notify() = %v, want a permanent error", err)
+	}
+}
@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const DefaultInterval = 2 * time.Second
+
+const (
+	batchSize   = 50
+	lease       = time.Minute
+	maxAttempts = 8
+	baseBackoff = 10 * time.Second
+	maxBackoff  = 30 * time.Minute
+)
+
+type Storage interface {
+	ClaimNotifications(limit int, lease time.Duration) ([]types.Notification, error)
+	MarkNotificationSent(id int) error
+	MarkNotificationFailed(id int, reason string, retryAfter time.Duration) error
+}
+
+// Dispatcher sends the notifications in the outbox, retrying failures with an
+// exponential backoff.
+type Dispatcher struct {
+	storage   Storage
+	notifiers Notifiers
+	logger    *types.SugarWithTrace
+	interval  time.Duration
+}
+
+func NewDispatcher(storage Storage, notifiers Notifiers, logger *types.SugarWithTrace, interval time.Duration) *Dispatcher {
+	return &Dispatcher{storage: storage, notifiers: notifiers, logger: logger, interval: interval}
+}
+
+// Run sends due notifications every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.dispatch(ctx)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	notifications, err := d.storage.ClaimNotifications(batchSize, lease)
+	if err != nil {
+		d.logger.Errorw("failed to claim notifications", "error", err)
+		return
+	}
+
+	for _, notification := range notifications {
+		err = d.send(ctx, notification)
+		if err == nil {
+			if err = d.storage.MarkNotificationSent(notification.ID); err != nil {
+				d.logger.Warnw("failed to mark notification as sent", "id", notification.ID, "error", err)
+			}
+			continue
+		}
+
+		retryAfter := backoff(notification.Attempts)
+		if IsPermanent(err) || notification.Attempts >= maxAttempts {
+			retryAfter = 0
+		}
+
+		d.logger.Warnw("failed to send notification", "id", notification.ID, "channel", notification.Channel, "attempts", notification.Attempts, "retry_after", retryAfter, "error", err)
+
+		if err = d.storage.MarkNotificationFailed(notification.ID, err.Error(), retryAfter); err != nil {
+			d.logger.Warnw("failed to mark notification as failed", "id", notification.ID, "error", err)
+		}
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, notification types.Notification) error {
+	notifier, ok := d.notifiers[notification.Channel]
+	if !ok {
+		return permanentError{fmt.Errorf("channel %q is not configured", notification.Channel)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return notifier.Notify(ctx, notification)
+}
+
+// backoff is how long to wait before the next attempt, doubling with each one
+// made so far.
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,114 @@
+// Package notify sends customers the notifications queued in the outbox when
+// their ticket is close to being called and when it is called.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/safehttp"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// Notifier delivers a notification through a single channel.
+type Notifier interface {
+	Notify(ctx context.Context, notification types.Notification) error
+}
+
+// Notifiers are the channels that have been configured, by the channel they
+// deliver through.
+type Notifiers map[types.NotificationChannel]Notifier
+
+// FromEnv configures the channels whose settings are present in the
+// environment.
+func FromEnv() Notifiers {
+	client := &http.Client{Timeout: 10 * time.Second}
+	notifiers := Notifiers{}
+
+	if os.Getenv("NOTIFY_WEBHOOKS") == "true" {
+		notifiers[types.ChannelWebhook] = &Webhook{Client: safehttp.NewClient(10 * time.Second)}
+	}
+
+	if addr := os.Getenv("NOTIFY_SMTP_ADDR"); addr != "" {
+		notifiers[types.ChannelEmail] = &Email{
+			Addr:     addr,
+			Username: os.Getenv("NOTIFY_SMTP_USERNAME"),
+			Password: os.Getenv("NOTIFY_SMTP_PASSWORD"),
+			From:     os.Getenv("NOTIFY_EMAIL_FROM"),
+		}
+	}
+
+	if url := os.Getenv("NOTIFY_SMS_URL"); url != "" {
+		notifiers[types.ChannelSMS] = &SMS{
+			Client: client,
+			URL:    url,
+			Token:  os.Getenv("NOTIFY_SMS_TOKEN"),
+			From:   os.Getenv("NOTIFY_SMS_FROM"),
+		}
+	}
+
+	return notifiers
+}
+
+// Enabled reports whether notifications can be sent through channel.
+func (n Notifiers) Enabled(channel types.NotificationChannel) bool {
+	_, ok := n[channel]
+	return ok
+}
+
+// Message renders the text sent to the customer.
+func Message(notification types.Notification) (subject string, body string) {
+	switch notification.Kind {
+	case types.NotifyCalled:
+		return "Your ticket has been called",
+			fmt.Sprintf("Ticket %s: please go to %s.", notification.DisplayNumber, notification.DeskLabel)
+	case types.NotifyAhead:
+		if notification.PlacesAhead == 0 {
+			return "You are next",
+				fmt.Sprintf("Ticket %s: you are next in the queue.", notification.DisplayNumber)
+		}
+		return "You will be called soon",
+			fmt.Sprintf("Ticket %s: there are %d tickets ahead of you.", notification.DisplayNumber, notification.PlacesAhead)
+	default:
+		return "Your ticket", fmt.Sprintf("Ticket %s has been updated.", notification.DisplayNumber)
+	}
+}
+
+// permanentError is a failure that retrying will not fix, such as an address
+// that the receiving end rejects.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// IsPermanent reports whether err means the notification should not be
+// retried.
+func IsPermanent(err error) bool {
+	return errors.As(err, &permanentError{})
+}
+
+// checkStatus turns an unsuccessful HTTP response into an error. Redirects,
+// which are not followed, and client errors other than rate limiting are
+// permanent.
+func checkStatus(response *http.Response) error {
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+
+	err := fmt.Errorf("unexpected response status %s", response.Status)
+	if response.StatusCode < 500 && response.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+// Package printing renders tickets as QR codes, printable HTML pages and
+// ESC/POS byte streams for thermal kiosk printers.
+package printing
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// DefaultQRSize is the width in pixels of a PNG QR code.
+const DefaultQRSize = 256
+
+// QRPNG renders content as a square PNG QR code, size pixels wide.
+func QRPNG(content string, size int) ([]byte, error) {
+	return qrcode.Encode(content, qrcode.Medium, size)
+}
+
+// QRSVG renders content as an SVG QR code. Each module is one user unit, so
+// the image scales to whatever size it is displayed at.
+func QRSVG(content string) ([]byte, error) {
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+
+	bitmap := code.Bitmap()
+	size := len(bitmap)
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, size, size)
+
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	b.WriteString(`"/></svg>`)
+
+	return b.Bytes(), nil
+}
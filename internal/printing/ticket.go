@@ -0,0 +1,102 @@
+package printing
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"time"
+)
+
+// Ticket is what gets printed for a customer.
+type Ticket struct {
+	DisplayNumber string
+	Category      string
+	URL           string
+	IssuedAt      time.Time
+}
+
+var ticketTemplate = template.Must(template.New("ticket").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Ticket {{.DisplayNumber}}</title>
+<style>
+  body { font-family: sans-serif; text-align: center; margin: 0; }
+  .ticket { width: 72mm; margin: 0 auto; padding: 4mm 0; }
+  .number { font-size: 48pt; font-weight: bold; margin: 2mm 0; }
+  .category { font-size: 16pt; }
+  .qr { width: 40mm; height: 40mm; margin: 4mm auto; }
+  .issued { font-size: 9pt; color: #555; }
+  @media print { @page { margin: 0; } }
+</style>
+</head>
+<body onload="window.print()">
+<div class="ticket">
+  <div class="category">{{.Category}}</div>
+  <div class="number">{{.DisplayNumber}}</div>
+  <div class="qr">{{.QR}}</div>
+  <div class="issued">{{.IssuedAt.Format "02/01/2006 15:04"}}</div>
+</div>
+</body>
+</html>
+`))
+
+// WriteHTML writes a printable page for a ticket, with its QR code inlined.
+func WriteHTML(w io.Writer, ticket Ticket) error {
+	qr, err := QRSVG(ticket.URL)
+	if err != nil {
+		return err
+	}
+
+	return ticketTemplate.Execute(w, struct {
+		Ticket
+		QR template.HTML
+	}{ticket, template.HTML(qr)})
+}
+
+// ESC/POS commands used to print a ticket.
+var (
+	escInit         = []byte{0x1b, '@'}
+	escAlignCenter  = []byte{0x1b, 'a', 1}
+	escDoubleSize   = []byte{0x1d, '!', 0x11}
+	escQuadSize     = []byte{0x1d, '!', 0x33}
+	escNormalSize   = []byte{0x1d, '!', 0x00}
+	escFeedAndCut   = []byte{0x1d, 'V', 66, 3}
+	escQRModel2     = []byte{0x1d, '(', 'k', 4, 0, 49, 65, 50, 0}
+	escQRModuleSize = []byte{0x1d, '(', 'k', 3, 0, 49, 67, 6}
+	escQRErrorLevel = []byte{0x1d, '(', 'k', 3, 0, 49, 69, 49}
+	escQRPrint      = []byte{0x1d, '(', 'k', 3, 0, 49, 81, 48}
+)
+
+// ESCPOS renders a ticket as commands for an ESC/POS thermal printer. The QR
+// code is drawn by the printer itself from the ticket's URL.
+func ESCPOS(ticket Ticket) []byte {
+	var b bytes.Buffer
+
+	b.Write(escInit)
+	b.Write(escAlignCenter)
+
+	b.Write(escDoubleSize)
+	b.WriteString(ticket.Category + "\n")
+
+	b.Write(escQuadSize)
+	b.WriteString(ticket.DisplayNumber + "\n")
+
+	b.Write(escNormalSize)
+	b.WriteString("\n")
+
+	// Store the URL in the symbol storage area, then print it.
+	data := []byte(ticket.URL)
+	length := len(data) + 3
+	b.Write(escQRModel2)
+	b.Write(escQRModuleSize)
+	b.Write(escQRErrorLevel)
+	b.Write([]byte{0x1d, '(', 'k', byte(length % 256), byte(length / 256), 49, 80, 48})
+	b.Write(data)
+	b.Write(escQRPrint)
+
+	b.WriteString("\n" + ticket.IssuedAt.Format("02/01/2006 15:04") + "\n")
+	b.Write(escFeedAndCut)
+
+	return b.Bytes()
+}
@@ -0,0 +1,98 @@
+// Package ratelimit counts requests per key over a sliding window.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many hits are recorded between removing keys that have no
+// hits left in their window.
+const sweepEvery = 1000
+
+type Limiter struct {
+	mu     sync.Mutex
+	hits   map[string][]time.Time
+	window map[string]time.Duration
+	calls  int
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{
+		hits:   make(map[string][]time.Time),
+		window: make(map[string]time.Duration),
+	}
+}
+
+// Reserve records a hit at now against each of the keys, as long as all of
+// them had fewer than limit hits in the window before now. When any has
+// reached the limit nothing is recorded, and it returns how long until a hit
+// can be recorded against all of them. Checking and recording under the same
+// lock keeps concurrent requests from all getting through on the last hit. A
+// hit for a request that then failed is given back with Cancel, so that it
+// does not count against the client.
+func (l *Limiter) Reserve(keys []string, limit int, window time.Duration, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	for _, key := range keys {
+		hits := dropBefore(l.hits[key], now.Add(-window))
+		if len(hits) >= limit {
+			wait = max(wait, hits[len(hits)-limit].Add(window).Sub(now))
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+
+	l.calls++
+	if l.calls%sweepEvery == 0 {
+		l.sweep(now)
+	}
+
+	for _, key := range keys {
+		l.hits[key] = append(dropBefore(l.hits[key], now.Add(-window)), now)
+		l.window[key] = window
+	}
+
+	return true, 0
+}
+
+// Cancel removes a hit that Reserve recorded at now from each of the keys.
+func (l *Limiter) Cancel(keys []string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		hits := l.hits[key]
+		for i := len(hits) - 1; i >= 0; i-- {
+			if hits[i].Equal(now) {
+				l.hits[key] = append(hits[:i], hits[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, hits := range l.hits {
+		hits = dropBefore(hits, now.Add(-l.window[key]))
+		if len(hits) == 0 {
+			delete(l.hits, key)
+			delete(l.window, key)
+			continue
+		}
+		l.hits[key] = hits
+	}
+}
+
+// dropBefore removes the hits older than cutoff from a slice ordered from
+// oldest to newest.
+func dropBefore(hits []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(cutoff) {
+		i++
+	}
+	return hits[i:]
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReserveIsAtomic(t *testing.T) {
+	l := NewLimiter()
+	now := time.Now()
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := l.Reserve([]string{"ip:1"}, 3, time.Minute, now); ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed.Load() != 3 {
+		t.Errorf("%d concurrent requests were allowed, want the limit of 3", allowed.Load())
+	}
+}
+
+func TestReserve(t *testing.T) {
+	l := NewLimiter()
+	start := time.Now()
+	keys := []string{"ip:1", "device:a"}
+
+	for i := range 2 {
+		if ok, _ := l.Reserve(keys, 2, time.Minute, start.Add(time.Duration(i)*time.Second)); !ok {
+			t.Fatalf("hit %d was refused", i+1)
+		}
+	}
+
+	// Another device at the same address is held back by the address.
+	ok, wait := l.Reserve([]string{"ip:1", "device:b"}, 2, time.Minute, start.Add(10*time.Second))
+	if ok || wait != 50*time.Second {
+		t.Errorf("Reserve() at the limit = %v, %v, want to wait until the first hit leaves the window", ok, wait)
+	}
+
+	// The refused hit was not recorded against the other device.
+	if ok, _ = l.Reserve([]string{"device:b"}, 1, time.Minute, start.Add(10*time.Second)); !ok {
+		t.Errorf("Reserve() for a device without hits was refused")
+	}
+
+	l.Cancel(keys, start.Add(time.Second))
+	if ok, _ = l.Reserve(keys, 2, time.Minute, start.Add(20*time.Second)); !ok {
+		t.Errorf("Reserve() after a hit was cancelled was refused")
+	}
+
+	if ok, _ = l.Reserve(keys, 2, time.Minute, start.Add(61*time.Second)); !ok {
+		t.Errorf("Reserve() once the first hit left the window was refused")
+	}
+}
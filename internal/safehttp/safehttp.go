@@ -0,0 +1,72 @@
+// Package safehttp calls URLs given by users, such as the webhooks of
+// customers and admins, without letting them reach the server's own network.
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrNotPublic is returned when a URL resolves to a loopback, private or other
+// address that is not reachable from the internet.
+var ErrNotPublic = errors.New("address is not public")
+
+// IsPublic reports whether ip is a unicast address outside of the private,
+// loopback and link-local ranges.
+func IsPublic(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// ValidURL reports whether raw is an https URL whose host, as far as can be
+// told before resolving it, is public. The address it resolves to is checked
+// again when connecting, by the client of NewClient.
+func ValidURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil || len(raw) > 2048 {
+		return false
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		return IsPublic(ip)
+	}
+	return u.Hostname() != "localhost"
+}
+
+// NewClient returns a client which only connects to public addresses, checked
+// once the host has been resolved so that a name pointing at an internal
+// address is refused too. It does not follow redirects, which could lead
+// anywhere, nor go through a proxy, which would connect on its behalf.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: checkAddress}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkAddress is the dialer's Control, called with the resolved address of
+// each connection before it is made.
+func checkAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !IsPublic(ip) {
+		return fmt.Errorf("%w: %s", ErrNotPublic, host)
+	}
+	return nil
+}
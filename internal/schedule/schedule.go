@@ -0,0 +1,139 @@
+// Package schedule works out whether a category is open from its weekly
+// opening hours and holiday exceptions.
+package schedule
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const timeLayout = "15:04"
+const dateLayout = "2006-01-02"
+
+// period is an opening period as minutes after midnight, local time.
+type period struct {
+	opens  int
+	closes int
+}
+
+type Schedule struct {
+	location *time.Location
+	weekly   map[time.Weekday][]period
+	holidays map[string][]period
+}
+
+// New builds the schedule of a category from its hours, interpreted in the
+// given IANA timezone.
+func New(timezone string, hours types.OpeningSchedule) (Schedule, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	schedule := Schedule{
+		location: location,
+		weekly:   make(map[time.Weekday][]period),
+		holidays: make(map[string][]period),
+	}
+
+	for _, h := range hours.Weekly {
+		p, err := parsePeriod(h.Opens, h.Closes)
+		if err != nil {
+			return Schedule{}, err
+		}
+		schedule.weekly[h.Weekday] = append(schedule.weekly[h.Weekday], p)
+	}
+
+	for _, h := range hours.Holidays {
+		if _, err := time.Parse(dateLayout, h.Date); err != nil {
+			return Schedule{}, fmt.Errorf("bad holiday date %q", h.Date)
+		}
+
+		if _, found := schedule.holidays[h.Date]; !found {
+			schedule.holidays[h.Date] = []period{}
+		}
+
+		if h.Opens == "" && h.Closes == "" {
+			continue
+		}
+
+		p, err := parsePeriod(h.Opens, h.Closes)
+		if err != nil {
+			return Schedule{}, err
+		}
+		schedule.holidays[h.Date] = append(schedule.holidays[h.Date], p)
+	}
+
+	return schedule, nil
+}
+
+// Validate checks that hours can be turned into a schedule.
+func Validate(timezone string, hours types.OpeningSchedule) error {
+	_, err := New(timezone, hours)
+	return err
+}
+
+func parsePeriod(opens string, closes string) (period, error) {
+	o, err := time.Parse(timeLayout, opens)
+	if err != nil {
+		return period{}, fmt.Errorf("bad opening time %q", opens)
+	}
+
+	c, err := time.Parse(timeLayout, closes)
+	if err != nil {
+		return period{}, fmt.Errorf("bad closing time %q", closes)
+	}
+
+	p := period{
+		opens:  o.Hour()*60 + o.Minute(),
+		closes: c.Hour()*60 + c.Minute(),
+	}
+
+	if p.closes <= p.opens {
+		return period{}, fmt.Errorf("closing time %s must be after opening time %s", closes, opens)
+	}
+
+	return p, nil
+}
+
+// ClosesAt reports whether the category is open at t and, if so, when the
+// current opening period ends. The closing time is zero for a category that
+// has no opening hours, as it never closes. Without weekly hours, that holds
+// for every day but its holidays.
+func (s Schedule) ClosesAt(t time.Time) (time.Time, bool) {
+	if len(s.weekly) == 0 && len(s.holidays) == 0 {
+		return time.Time{}, true
+	}
+
+	local := t.In(s.location)
+	at := func(minutes int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day(), minutes/60, minutes%60, 0, 0, s.location)
+	}
+
+	periods, found := s.holidays[local.Format(dateLayout)]
+	if !found {
+		if len(s.weekly) == 0 {
+			return time.Time{}, true
+		}
+		periods = s.weekly[local.Weekday()]
+	}
+
+	for _, p := range periods {
+		opens := at(p.opens)
+		closes := at(p.closes)
+		if !local.Before(opens) && local.Before(closes) {
+			return closes, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// IsOpen reports whether the category is open at t.
+func (s Schedule) IsOpen(t time.Time) bool {
+	_, open := s.ClosesAt(t)
+	return open
+}
@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const appointmentSlotQuery = `SELECT s.id, s.category_id, s.starts_at, s.ends_at, s.capacity,
+	  (SELECT COUNT(*) FROM appointment a WHERE a.slot_id = s.id AND a.status <> 'cancelled')
+	FROM appointment_slot s`
+
+const appointmentQuery = `SELECT a.id, a.slot_id, s.category_id, s.starts_at, a.name, a.sub_url, a.status, a.ticket_id, a.created_at
+	FROM appointment a
+	JOIN appointment_slot s ON s.id = a.slot_id`
+
+func scanAppointmentSlot(row rowScanner) (types.AppointmentSlot, error) {
+	var slot types.AppointmentSlot
+	err := row.Scan(&slot.ID, &slot.CategoryID, &slot.StartsAt, &slot.EndsAt, &slot.Capacity, &slot.Booked)
+	return slot, err
+}
+
+func scanAppointment(row rowScanner) (types.Appointment, error) {
+	var appointment types.Appointment
+	var ticketID sql.NullInt64
+
+	if err := row.Scan(&appointment.ID, &appointment.SlotID, &appointment.CategoryID, &appointment.StartsAt, &appointment.Name, &appointment.SubURL, &appointment.Status, &ticketID, &appointment.CreatedAt); err != nil {
+		return types.Appointment{}, err
+	}
+
+	appointment.TicketID = -1
+	if ticketID.Valid {
+		appointment.TicketID = int(ticketID.Int64)
+	}
+
+	return appointment, nil
+}
+
+func (s *PostgresStorage) CreateAppointmentSlot(slot types.AppointmentSlot) (types.AppointmentSlot, error) {
+	query := `INSERT INTO appointment_slot (category_id, starts_at, ends_at, capacity)
+	SELECT id, $2, $3, $4
+	FROM category
+	WHERE id = $1 AND ` + locationFilter("location_id", 5) + `
+	RETURNING id, category_id, starts_at, ends_at, capacity, 0`
+
+	slot, err := scanAppointmentSlot(s.db.QueryRow(query, slot.CategoryID, slot.StartsAt, slot.EndsAt, slot.Capacity, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.AppointmentSlot{}, types.ErrnotFound
+		}
+		s.logger.Warnw("could not create appointment slot", "error", err)
+		return types.AppointmentSlot{}, err
+	}
+
+	return slot, nil
+}
+
+func (s *PostgresStorage) GetAppointmentSlot(id int) (types.AppointmentSlot, error) {
+	slot, err := scanAppointmentSlot(s.db.QueryRow(appointmentSlotQuery+" WHERE s.id = $1 AND "+inLocation("s.category_id", "category", 2), id, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.AppointmentSlot{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with GetAppointmentSlot", "id", id, "error", err)
+		return types.AppointmentSlot{}, err
+	}
+
+	return slot, nil
+}
+
+// ListAppointmentSlots returns the slots of a category starting between from
+// and to.
+func (s *PostgresStorage) ListAppointmentSlots(categoryID int, from time.Time, to time.Time) ([]types.AppointmentSlot, error) {
+	query := appointmentSlotQuery + `
+	WHERE s.category_id = $1
+	  AND s.starts_at >= $2
+	  AND s.starts_at < $3
+	  AND ` + inLocation("s.category_id", "category", 4) + `
+	ORDER BY s.starts_at`
+
+	rows, err := s.db.Query(query, categoryID, from, to, s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with ListAppointmentSlots", "category_id", categoryID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	slots := []types.AppointmentSlot{}
+
+	for rows.Next() {
+		slot, err := scanAppointmentSlot(rows)
+		if err != nil {
+			return nil, err
+		}
+		slots = append(slots, slot)
+	}
+
+	return slots, rows.Err()
+}
+
+func (s *PostgresStorage) DeleteAppointmentSlot(id int) error {
+	query := `DELETE FROM appointment_slot
+	WHERE id = $1 AND ` + inLocation("category_id", "category", 2)
+
+	result, err := s.db.Exec(query, id, s.locationID)
+	if err != nil {
+		s.logger.Tracew("error deleting appointment slot", "id", id, "error", err)
+		return err
+	}
+
+	return checkSingleRowAffected(result, id, "DeleteAppointmentSlot", s.logger)
+}
+
+// BookAppointment books a place in a slot, failing with types.ErrSlotFull when
+// the slot has no capacity left.
+func (s *PostgresStorage) BookAppointment(slotID int, name string, subURL string) (types.Appointment, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Appointment{}, err
+	}
+	defer tx.Rollback()
+
+	var capacity, booked int
+
+	err = tx.QueryRow("SELECT capacity FROM appointment_slot WHERE id = $1 AND "+inLocation("category_id", "category", 2)+" FOR UPDATE", slotID, s.locationID).Scan(&capacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Appointment{}, types.ErrnotFound
+		}
+		return types.Appointment{}, err
+	}
+
+	err = tx.QueryRow("SELECT COUNT(*) FROM appointment WHERE slot_id = $1 AND status <> $2", slotID, types.AppointmentCancelled).Scan(&booked)
+	if err != nil {
+		return types.Appointment{}, err
+	}
+
+	if booked >= capacity {
+		return types.Appointment{}, types.ErrSlotFull
+	}
+
+	var id int
+
+	query := "INSERT INTO appointment (slot_id, name, sub_url, status) VALUES ($1, $2, $3, $4) RETURNING id"
+	if err = tx.QueryRow(query, slotID, name, subURL, types.AppointmentBooked).Scan(&id); err != nil {
+		s.logger.Warnw("could not book appointment", "slot_id", slotID, "error", err)
+		return types.Appointment{}, err
+	}
+
+	appointment, err := scanAppointment(tx.QueryRow(appointmentQuery+" WHERE a.id = $1", id))
+	if err != nil {
+		return types.Appointment{}, err
+	}
+
+	return appointment, tx.Commit()
+}
+
+func (s *PostgresStorage) GetAppointmentBySubURL(subURL string) (types.Appointment, error) {
+	appointment, err := scanAppointment(s.db.QueryRow(appointmentQuery+" WHERE a.sub_url = $1 AND "+inLocation("s.category_id", "category", 2), subURL, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Appointment{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with GetAppointmentBySubURL", "error", err)
+		return types.Appointment{}, err
+	}
+
+	return appointment, nil
+}
+
+func (s *PostgresStorage) CancelAppointment(id int) (types.Appointment, error) {
+	query := `UPDATE appointment
+	SET status = $2
+	WHERE id = $1 AND status = $3
+	  AND slot_id IN (SELECT id FROM appointment_slot WHERE ` + inLocation("category_id", "category", 4) + `)`
+
+	result, err := s.db.Exec(query, id, types.AppointmentCancelled, types.AppointmentBooked, s.locationID)
+	if err != nil {
+		s.logger.Tracew("error cancelling appointment", "id", id, "error", err)
+		return types.Appointment{}, err
+	}
+
+	if err = checkSingleRowAffected(result, id, "CancelAppointment", s.logger); err != nil {
+		if err == ErrNoRowsAffected {
+			return types.Appointment{}, types.ErrAppointmentNotBooked
+		}
+		return types.Appointment{}, err
+	}
+
+	return scanAppointment(s.db.QueryRow(appointmentQuery+" WHERE a.id = $1", id))
+}
+
+// CheckInAppointment turns a booked appointment into a ticket under subURL,
+// linked to the appointment by its ticket_id. Customers who check in no
+// earlier than window.Early before their slot and no later than window.Grace
+// after its start are given a priority ticket queued at the start of their
+// slot, so they go ahead of anyone who arrived after it. Later arrivals are
+// queued as walk-ins.
+func (s *PostgresStorage) CheckInAppointment(id int, subURL string, window types.CheckInWindow) (types.Appointment, types.Ticket, error) {
+	priorityQuery := `INSERT INTO ticket (category_id, sub_url, queued_at, priority, location_id)
+	SELECT id, $2, $3::TIMESTAMPTZ AT TIME ZONE current_setting('TimeZone'), TRUE, location_id
+	FROM category
+	WHERE id = $1
+	RETURNING ` + ticketColumns
+
+	walkInQuery := `INSERT INTO ticket (category_id, sub_url, location_id)
+	SELECT id, $2, location_id
+	FROM category
+	WHERE id = $1
+	RETURNING ` + ticketColumns
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Appointment{}, types.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	appointment, err := scanAppointment(tx.QueryRow(appointmentQuery+" WHERE a.id = $1 AND "+inLocation("s.category_id", "category", 2)+" FOR UPDATE OF a", id, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Appointment{}, types.Ticket{}, types.ErrnotFound
+		}
+		return types.Appointment{}, types.Ticket{}, err
+	}
+
+	if appointment.Status != types.AppointmentBooked {
+		return types.Appointment{}, types.Ticket{}, types.ErrAppointmentNotBooked
+	}
+
+	now := time.Now()
+	if now.Before(appointment.StartsAt.Add(-window.Early)) {
+		return types.Appointment{}, types.Ticket{}, types.ErrTooEarly
+	}
+
+	var ticket types.Ticket
+	if now.After(appointment.StartsAt.Add(window.Grace)) {
+		ticket, err = scanTicket(tx.QueryRow(walkInQuery, appointment.CategoryID, subURL))
+	} else {
+		ticket, err = scanTicket(tx.QueryRow(priorityQuery, appointment.CategoryID, subURL, appointment.StartsAt))
+	}
+	if err != nil {
+		s.logger.Warnw("could not create ticket for appointment", "id", id, "error", err)
+		return types.Appointment{}, types.Ticket{}, err
+	}
+
+	if err = insertTicketEvent(tx, ticket.ID, types.TicketCheckedIn, ""); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", ticket.ID, "type", types.TicketCheckedIn, "error", err)
+		return types.Appointment{}, types.Ticket{}, err
+	}
+
+	query := "UPDATE appointment SET status = $2, ticket_id = $3 WHERE id = $1"
+	if _, err = tx.Exec(query, id, types.AppointmentCheckedIn, ticket.ID); err != nil {
+		return types.Appointment{}, types.Ticket{}, err
+	}
+
+	appointment.Status = types.AppointmentCheckedIn
+	appointment.TicketID = ticket.ID
+
+	return appointment, ticket, tx.Commit()
+}
+
+func (s *PostgresStorage) createAppointmentTables() error {
+	query := `CREATE TABLE IF NOT EXISTS appointment_slot(
+	id SERIAL PRIMARY KEY,
+	category_id INT NOT NULL REFERENCES category(id) ON DELETE CASCADE,
+	starts_at TIMESTAMPTZ NOT NULL,
+	ends_at TIMESTAMPTZ NOT NULL,
+	capacity INT NOT NULL CHECK (capacity > 0),
+	CHECK (ends_at > starts_at)
+	);
+
+	CREATE INDEX IF NOT EXISTS appointment_slot_category_starts_at_idx ON appointment_slot(category_id, starts_at);
+
+	CREATE TABLE IF NOT EXISTS appointment(
+	id SERIAL PRIMARY KEY,
+	slot_id INT NOT NULL REFERENCES appointment_slot(id) ON DELETE CASCADE,
+	name VARCHAR(100) NOT NULL,
+	sub_url TEXT NOT NULL UNIQUE,
+	status VARCHAR(20) NOT NULL,
+	ticket_id INT REFERENCES ticket(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);`
+
+	_, err := s.db.Exec(query)
+	return err
+}
@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const categorySettingsColumns = "category_id, no_show_timeout_seconds, max_recalls, requeue_no_shows, timezone, intake_closed, max_waiting, max_tickets_per_client, client_window_seconds"
+
+func defaultCategorySettings(categoryID int, timezone string) types.CategorySettings {
+	return types.CategorySettings{
+		CategoryID:           categoryID,
+		NoShowTimeoutSeconds: 120,
+		MaxRecalls:           2,
+		Timezone:             timezone,
+		ClientWindowSeconds:  3600,
+	}
+}
+
+func scanCategorySettings(row rowScanner) (types.CategorySettings, error) {
+	var settings types.CategorySettings
+
+	err := row.Scan(&settings.CategoryID, &settings.NoShowTimeoutSeconds, &settings.MaxRecalls, &settings.RequeueNoShows, &settings.Timezone, &settings.IntakeClosed, &settings.MaxWaiting, &settings.MaxTicketsPerClient, &settings.ClientWindowSeconds)
+	return settings, err
+}
+
+// GetCategorySettings returns the settings of a category, or the defaults if
+// they have never been changed. Categories default to the timezone of their
+// location.
+func (s *PostgresStorage) GetCategorySettings(categoryID int) (types.CategorySettings, error) {
+	locationQuery := `SELECT l.timezone
+	FROM category c
+	JOIN location l ON l.id = c.location_id
+	WHERE c.id = $1 AND ` + locationFilter("c.location_id", 2)
+
+	var timezone string
+	if err := s.db.QueryRow(locationQuery, categoryID, s.locationID).Scan(&timezone); err != nil {
+		if err == sql.ErrNoRows {
+			return types.CategorySettings{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with GetCategorySettings", "category_id", categoryID, "error", err)
+		return types.CategorySettings{}, err
+	}
+
+	query := "SELECT " + categorySettingsColumns + " FROM category_settings WHERE category_id = $1"
+
+	settings, err := scanCategorySettings(s.db.QueryRow(query, categoryID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return defaultCategorySettings(categoryID, timezone), nil
+		}
+		s.logger.Warnw("error with GetCategorySettings", "category_id", categoryID, "error", err)
+		return types.CategorySettings{}, err
+	}
+
+	return settings, nil
+}
+
+func (s *PostgresStorage) UpdateCategorySettings(settings types.CategorySettings) (types.CategorySettings, error) {
+	updated, err := upsertCategorySettings(s.db, settings, s.locationID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.CategorySettings{}, types.ErrnotFound
+		}
+		s.logger.Warnw("could not update category settings", "category_id", settings.CategoryID, "error", err)
+		return types.CategorySettings{}, err
+	}
+
+	return updated, nil
+}
+
+// upsertCategorySettings writes the settings of a category of the location
+// locationID, returning sql.ErrNoRows if it has no such category. Whether
+// intake is closed is only set for a category without settings yet, being
+// otherwise left to SetIntakeClosed.
+func upsertCategorySettings(q queryer, settings types.CategorySettings, locationID int) (types.CategorySettings, error) {
+	query := `INSERT INTO category_settings (` + categorySettingsColumns + `)
+	SELECT id, $2, $3, $4, $5, $6, $7, $8, $9
+	FROM category
+	WHERE id = $1 AND ` + locationFilter("location_id", 10) + `
+	ON CONFLICT (category_id) DO UPDATE
+	SET no_show_timeout_seconds = EXCLUDED.no_show_timeout_seconds,
+	  max_recalls = EXCLUDED.max_recalls,
+	  requeue_no_shows = EXCLUDED.requeue_no_shows,
+	  timezone = EXCLUDED.timezone,
+	  max_waiting = EXCLUDED.max_waiting,
+	  max_tickets_per_client = EXCLUDED.max_tickets_per_client,
+	  client_window_seconds = EXCLUDED.client_window_seconds
+	RETURNING ` + categorySettingsColumns
+
+	return scanCategorySettings(q.QueryRow(query, settings.CategoryID, settings.NoShowTimeoutSeconds, settings.MaxRecalls, settings.RequeueNoShows, settings.Timezone, settings.IntakeClosed, settings.MaxWaiting, settings.MaxTicketsPerClient, settings.ClientWindowSeconds, locationID))
+}
+
+// SetIntakeClosed opens or closes a category to new tickets, leaving the rest
+// of its settings unchanged.
+func (s *PostgresStorage) SetIntakeClosed(categoryID int, closed bool) (types.CategorySettings, error) {
+	query := `INSERT INTO category_settings (category_id, intake_closed, timezone)
+	SELECT c.id, $2, l.timezone
+	FROM category c
+	JOIN location l ON l.id = c.location_id
+	WHERE c.id = $1 AND ` + locationFilter("c.location_id", 3) + `
+	ON CONFLICT (category_id) DO UPDATE
+	SET intake_closed = EXCLUDED.intake_closed
+	RETURNING ` + categorySettingsColumns
+
+	settings, err := scanCategorySettings(s.db.QueryRow(query, categoryID, closed, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.CategorySettings{}, types.ErrnotFound
+		}
+		s.logger.Warnw("could not update category intake", "category_id", categoryID, "closed", closed, "error", err)
+		return types.CategorySettings{}, err
+	}
+
+	return settings, nil
+}
+
+func (s *PostgresStorage) GetCategoryHours(categoryID int) (types.CategoryHours, error) {
+	if err := s.checkCategory(categoryID); err != nil {
+		return types.CategoryHours{}, err
+	}
+
+	schedule, err := getOpeningSchedule(s.db, "category_hours", "category_holiday", "category_id", categoryID)
+	if err != nil {
+		s.logger.Warnw("error with GetCategoryHours", "category_id", categoryID, "error", err)
+		return types.CategoryHours{}, err
+	}
+
+	return types.CategoryHours{CategoryID: categoryID, OpeningSchedule: schedule}, nil
+}
+
+// SetCategoryHours replaces all of the weekly hours and holiday exceptions of
+// a category.
+func (s *PostgresStorage) SetCategoryHours(hours types.CategoryHours) (types.CategoryHours, error) {
+	if err := s.checkCategory(hours.CategoryID); err != nil {
+		return types.CategoryHours{}, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.CategoryHours{}, err
+	}
+	defer tx.Rollback()
+
+	if err = setOpeningSchedule(tx, "category_hours", "category_holiday", "category_id", hours.CategoryID, hours.OpeningSchedule); err != nil {
+		s.logger.Warnw("could not set category hours", "category_id", hours.CategoryID, "error", err)
+		return types.CategoryHours{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return types.CategoryHours{}, err
+	}
+
+	return s.GetCategoryHours(hours.CategoryID)
+}
+
+// checkCategory returns types.ErrnotFound unless the category is in the
+// storage's location.
+func (s *PostgresStorage) checkCategory(categoryID int) error {
+	var exists bool
+
+	query := "SELECT EXISTS (SELECT 1 FROM category WHERE id = $1 AND " + locationFilter("location_id", 2) + ")"
+	if err := s.db.QueryRow(query, categoryID, s.locationID).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return types.ErrnotFound
+	}
+	return nil
+}
+
+// getOpeningSchedule reads the hours kept in table and the holidays kept in
+// holidayTable for the category or location whose ID is in column.
+func getOpeningSchedule(q queryer, table string, holidayTable string, column string, id int) (types.OpeningSchedule, error) {
+	schedule := types.OpeningSchedule{
+		Weekly:   []types.OpeningHours{},
+		Holidays: []types.HolidayException{},
+	}
+
+	weeklyQuery := `SELECT weekday, to_char(opens, 'HH24:MI'), to_char(closes, 'HH24:MI')
+	FROM ` + table + `
+	WHERE ` + column + ` = $1
+	ORDER BY weekday, opens`
+
+	rows, err := q.Query(weeklyQuery, id)
+	if err != nil {
+		return types.OpeningSchedule{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h types.OpeningHours
+		if err = rows.Scan(&h.Weekday, &h.Opens, &h.Closes); err != nil {
+			return types.OpeningSchedule{}, err
+		}
+		schedule.Weekly = append(schedule.Weekly, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return types.OpeningSchedule{}, err
+	}
+
+	holidayQuery := `SELECT to_char(date, 'YYYY-MM-DD'), COALESCE(to_char(opens, 'HH24:MI'), ''), COALESCE(to_char(closes, 'HH24:MI'), '')
+	FROM ` + holidayTable + `
+	WHERE ` + column + ` = $1
+	ORDER BY date, opens`
+
+	holidayRows, err := q.Query(holidayQuery, id)
+	if err != nil {
+		return types.OpeningSchedule{}, err
+	}
+	defer holidayRows.Close()
+
+	for holidayRows.Next() {
+		var h types.HolidayException
+		if err = holidayRows.Scan(&h.Date, &h.Opens, &h.Closes); err != nil {
+			return types.OpeningSchedule{}, err
+		}
+		schedule.Holidays = append(schedule.Holidays, h)
+	}
+
+	return schedule, holidayRows.Err()
+}
+
+// setOpeningSchedule replaces the hours and holidays written by
+// getOpeningSchedule.
+func setOpeningSchedule(tx *sql.Tx, table string, holidayTable string, column string, id int, schedule types.OpeningSchedule) error {
+	if _, err := tx.Exec("DELETE FROM "+table+" WHERE "+column+" = $1", id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM "+holidayTable+" WHERE "+column+" = $1", id); err != nil {
+		return err
+	}
+
+	for _, h := range schedule.Weekly {
+		query := "INSERT INTO " + table + " (" + column + ", weekday, opens, closes) VALUES ($1, $2, $3, $4)"
+		if _, err := tx.Exec(query, id, h.Weekday, h.Opens, h.Closes); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range schedule.Holidays {
+		query := "INSERT INTO " + holidayTable + " (" + column + ", date, opens, closes) VALUES ($1, $2, NULLIF($3, '')::TIME, NULLIF($4, '')::TIME)"
+		if _, err := tx.Exec(query, id, h.Date, h.Opens, h.Closes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCapacity locks a category for the rest of the transaction, so that
+// concurrent ticket creation cannot take its queue past max_waiting.
+func checkCapacity(tx *sql.Tx, categoryID int) error {
+	query := `SELECT COALESCE(cs.max_waiting, 0)
+	FROM category c
+	LEFT JOIN category_settings cs ON cs.category_id = c.id
+	WHERE c.id = $1
+	FOR UPDATE OF c`
+
+	var maxWaiting int
+	if err := tx.QueryRow(query, categoryID).Scan(&maxWaiting); err != nil {
+		if err == sql.ErrNoRows {
+			return types.ErrnotFound
+		}
+		return err
+	}
+
+	if maxWaiting == 0 {
+		return nil
+	}
+
+	var waiting int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM ticket WHERE category_id = $1 AND "+waitingTicket, categoryID).Scan(&waiting); err != nil {
+		return err
+	}
+
+	if waiting >= maxWaiting {
+		return types.ErrQueueFull
+	}
+
+	return nil
+}
+
+func (s *PostgresStorage) createCategoryHoursTables() error {
+	query := `CREATE TABLE IF NOT EXISTS category_hours(
+	id SERIAL PRIMARY KEY,
+	category_id INT NOT NULL REFERENCES category(id) ON DELETE CASCADE,
+	weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+	opens TIME NOT NULL,
+	closes TIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS category_holiday(
+	id SERIAL PRIMARY KEY,
+	category_id INT NOT NULL REFERENCES category(id) ON DELETE CASCADE,
+	date DATE NOT NULL,
+	opens TIME,
+	closes TIME
+	);`
+
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *PostgresStorage) createCategorySettingsTable() error {
+	query := `CREATE TABLE IF NOT EXISTS category_settings(
+	category_id INT PRIMARY KEY REFERENCES category(id) ON DELETE CASCADE,
+	no_show_timeout_seconds INT NOT NULL DEFAULT 120,
+	max_recalls INT NOT NULL DEFAULT 2,
+	requeue_no_shows BOOLEAN NOT NULL DEFAULT FALSE
+	);
+
+	ALTER TABLE category_settings ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+	ALTER TABLE category_settings ADD COLUMN IF NOT EXISTS intake_closed BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE category_settings ADD COLUMN IF NOT EXISTS max_waiting INT NOT NULL DEFAULT 0;
+	ALTER TABLE category_settings ADD COLUMN IF NOT EXISTS max_tickets_per_client INT NOT NULL DEFAULT 0;
+	ALTER TABLE category_settings ADD COLUMN IF NOT EXISTS client_window_seconds INT NOT NULL DEFAULT 3600;`
+
+	_, err := s.db.Exec(query)
+	return err
+}
@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// ApplyConfig brings the categories and desks of the storage's location in
+// line with config in a single transaction: the missing ones are created and
+// those that differ are updated. Categories and desks the config leaves out
+// are deleted when prune is set. The changes are listed in the order they
+// were made, and on a dry run are worked out in the same way but rolled back.
+//
+// Desks must only refer to categories named in the config. A category or desk
+// to be pruned that still has tickets fails with types.ErrInUse.
+func (s *PostgresStorage) ApplyConfig(config types.LocationConfig, prune bool, dryRun bool) ([]types.ConfigChange, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the location makes concurrent applies wait for each other, as
+	// each works out its changes from what the one before left.
+	var slug, timezone string
+	if err = tx.QueryRow("SELECT slug, timezone FROM location WHERE id = $1 FOR UPDATE", s.locationID).Scan(&slug, &timezone); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, types.ErrnotFound
+		}
+		return nil, err
+	}
+
+	changes := []types.ConfigChange{}
+
+	if config.Hours != nil {
+		changed, err := applyOpeningSchedule(tx, "location_hours", "location_holiday", "location_id", s.locationID, *config.Hours)
+		if err != nil {
+			s.logger.Warnw("could not apply location hours", "location_id", s.locationID, "error", err)
+			return nil, err
+		}
+		if changed {
+			changes = append(changes, types.ConfigChange{Action: types.ConfigUpdate, Kind: types.ConfigLocation, Name: slug, Fields: []string{"hours"}})
+		}
+	}
+
+	categories, err := lockCategories(tx, s.locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryIDs := map[string]int{}
+	for _, category := range categories {
+		categoryIDs[category.Name] = category.ID
+	}
+
+	configured := map[string]bool{}
+	for _, category := range config.Categories {
+		configured[category.Name] = true
+
+		change, err := s.applyCategoryConfig(tx, category, categoryIDs, timezone)
+		if err != nil {
+			s.logger.Warnw("could not apply category config", "name", category.Name, "error", err)
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	deskChanges, err := s.applyDeskConfigs(tx, config.Desks, categoryIDs, prune)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, deskChanges...)
+
+	if prune {
+		for _, category := range categories {
+			if configured[category.Name] {
+				continue
+			}
+			if _, err = tx.Exec("DELETE FROM category WHERE id = $1", category.ID); err != nil {
+				if isInUse(err) {
+					return nil, fmt.Errorf("cannot delete category '%s': %w", category.Name, types.ErrInUse)
+				}
+				s.logger.Warnw("could not prune category", "id", category.ID, "error", err)
+				return nil, err
+			}
+			changes = append(changes, types.ConfigChange{Action: types.ConfigDelete, Kind: types.ConfigCategory, Name: category.Name})
+		}
+	}
+
+	if dryRun {
+		return changes, nil
+	}
+
+	return changes, tx.Commit()
+}
+
+// applyCategoryConfig creates a category missing from ids, adding it there,
+// and sets the settings and hours the config gives it. The category's intake
+// is left open or closed as it was.
+func (s *PostgresStorage) applyCategoryConfig(tx *sql.Tx, config types.CategoryConfig, ids map[string]int, timezone string) (*types.ConfigChange, error) {
+	change := &types.ConfigChange{Action: types.ConfigUpdate, Kind: types.ConfigCategory, Name: config.Name}
+
+	id, ok := ids[config.Name]
+	if !ok {
+		if err := tx.QueryRow("INSERT INTO category (name, location_id) VALUES ($1, $2) RETURNING id", config.Name, s.locationID).Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[config.Name] = id
+		change.Action = types.ConfigCreate
+	}
+
+	if config.Settings != nil {
+		current, err := scanCategorySettings(tx.QueryRow("SELECT "+categorySettingsColumns+" FROM category_settings WHERE category_id = $1", id))
+		if err == sql.ErrNoRows {
+			current, err = defaultCategorySettings(id, timezone), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		settings := *config.Settings
+		settings.CategoryID, settings.IntakeClosed = id, current.IntakeClosed
+		if settings.Timezone == "" {
+			settings.Timezone = timezone
+		}
+
+		if settings != current {
+			if _, err = upsertCategorySettings(tx, settings, s.locationID); err != nil {
+				return nil, err
+			}
+			change.Fields = append(change.Fields, "settings")
+		}
+	}
+
+	if config.Hours != nil {
+		changed, err := applyOpeningSchedule(tx, "category_hours", "category_holiday", "category_id", id, *config.Hours)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			change.Fields = append(change.Fields, "hours")
+		}
+	}
+
+	if change.Action == types.ConfigUpdate && len(change.Fields) == 0 {
+		return nil, nil
+	}
+	return change, nil
+}
+
+// applyDeskConfigs creates and moves the desks of a config, matching them to
+// the existing desks by label, and deletes the rest when prune is set.
+func (s *PostgresStorage) applyDeskConfigs(tx *sql.Tx, configs []types.DeskConfig, categoryIDs map[string]int, prune bool) ([]types.ConfigChange, error) {
+	rows, err := tx.Query("SELECT id, label, category_id FROM desk WHERE location_id = $1 ORDER BY id FOR UPDATE", s.locationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var desks []types.Desk
+	for rows.Next() {
+		var desk types.Desk
+		if err = rows.Scan(&desk.ID, &desk.Label, &desk.CategoryID); err != nil {
+			return nil, err
+		}
+		desks = append(desks, desk)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var changes []types.ConfigChange
+	configured := map[string]bool{}
+
+	for _, config := range configs {
+		configured[config.Label] = true
+
+		categoryID, ok := categoryIDs[config.Category]
+		if !ok {
+			return nil, fmt.Errorf("desk '%s' refers to category '%s': %w", config.Label, config.Category, types.ErrnotFound)
+		}
+
+		i := slices.IndexFunc(desks, func(desk types.Desk) bool { return desk.Label == config.Label })
+		switch {
+		case i < 0:
+			if _, err = tx.Exec("INSERT INTO desk (label, category_id, location_id) VALUES ($1, $2, $3)", config.Label, categoryID, s.locationID); err != nil {
+				s.logger.Warnw("could not create desk from config", "label", config.Label, "error", err)
+				return nil, err
+			}
+			changes = append(changes, types.ConfigChange{Action: types.ConfigCreate, Kind: types.ConfigDesk, Name: config.Label})
+		case desks[i].CategoryID != categoryID:
+			if _, err = tx.Exec("UPDATE desk SET category_id = $1, version = version + 1 WHERE id = $2", categoryID, desks[i].ID); err != nil {
+				s.logger.Warnw("could not move desk from config", "id", desks[i].ID, "error", err)
+				return nil, err
+			}
+			changes = append(changes, types.ConfigChange{Action: types.ConfigUpdate, Kind: types.ConfigDesk, Name: config.Label, Fields: []string{"category"}})
+		}
+	}
+
+	if !prune {
+		return changes, nil
+	}
+
+	for _, desk := range desks {
+		if configured[desk.Label] {
+			continue
+		}
+		if _, err = tx.Exec("DELETE FROM desk WHERE id = $1", desk.ID); err != nil {
+			if isInUse(err) {
+				return nil, fmt.Errorf("cannot delete desk '%s': %w", desk.Label, types.ErrInUse)
+			}
+			s.logger.Warnw("could not prune desk", "id", desk.ID, "error", err)
+			return nil, err
+		}
+		changes = append(changes, types.ConfigChange{Action: types.ConfigDelete, Kind: types.ConfigDesk, Name: desk.Label})
+	}
+
+	return changes, nil
+}
+
+// ExportConfig describes the storage's location as a config which, applied
+// to it, would change nothing. Every category is given its settings and
+// hours, so that applying the config elsewhere reproduces them exactly.
+func (s *PostgresStorage) ExportConfig() (types.LocationConfig, error) {
+	hours, err := getOpeningSchedule(s.db, "location_hours", "location_holiday", "location_id", s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with ExportConfig", "location_id", s.locationID, "error", err)
+		return types.LocationConfig{}, err
+	}
+
+	config := types.LocationConfig{Hours: &hours, Categories: []types.CategoryConfig{}, Desks: []types.DeskConfig{}}
+
+	categories, err := s.ListCategories()
+	if err != nil {
+		return types.LocationConfig{}, err
+	}
+
+	names := map[int]string{}
+	for _, category := range categories {
+		names[category.ID] = category.Name
+
+		settings, err := s.GetCategorySettings(category.ID)
+		if err != nil {
+			return types.LocationConfig{}, err
+		}
+
+		hours, err := getOpeningSchedule(s.db, "category_hours", "category_holiday", "category_id", category.ID)
+		if err != nil {
+			s.logger.Warnw("error with ExportConfig", "category_id", category.ID, "error", err)
+			return types.LocationConfig{}, err
+		}
+
+		config.Categories = append(config.Categories, types.CategoryConfig{Name: category.Name, Settings: &settings, Hours: &hours})
+	}
+
+	desks, err := s.ListDesks()
+	if err != nil {
+		return types.LocationConfig{}, err
+	}
+
+	for _, desk := range desks {
+		config.Desks = append(config.Desks, types.DeskConfig{Label: desk.Label, Category: names[desk.CategoryID]})
+	}
+
+	return config, nil
+}
+
+// applyOpeningSchedule replaces the schedule kept by setOpeningSchedule unless
+// it is already the same, reporting whether it changed.
+func applyOpeningSchedule(tx *sql.Tx, table string, holidayTable string, column string, id int, schedule types.OpeningSchedule) (bool, error) {
+	current, err := getOpeningSchedule(tx, table, holidayTable, column, id)
+	if err != nil {
+		return false, err
+	}
+
+	if sameOpeningSchedule(current, schedule) {
+		return false, nil
+	}
+
+	return true, setOpeningSchedule(tx, table, holidayTable, column, id, schedule)
+}
+
+// sameOpeningSchedule compares two schedules in the order and the form
+// getOpeningSchedule reads them, so that "9:00" matches "09:00".
+func sameOpeningSchedule(a types.OpeningSchedule, b types.OpeningSchedule) bool {
+	normalize := func(schedule types.OpeningSchedule) types.OpeningSchedule {
+		var normalized types.OpeningSchedule
+		for _, h := range schedule.Weekly {
+			normalized.Weekly = append(normalized.Weekly, types.OpeningHours{Weekday: h.Weekday, Opens: normalizeTime(h.Opens), Closes: normalizeTime(h.Closes)})
+		}
+		for _, h := range schedule.Holidays {
+			normalized.Holidays = append(normalized.Holidays, types.HolidayException{Date: h.Date, Opens: normalizeTime(h.Opens), Closes: normalizeTime(h.Closes)})
+		}
+
+		slices.SortFunc(normalized.Weekly, func(x, y types.OpeningHours) int {
+			return cmp.Or(cmp.Compare(x.Weekday, y.Weekday), cmp.Compare(x.Opens, y.Opens))
+		})
+		slices.SortFunc(normalized.Holidays, func(x, y types.HolidayException) int {
+			return cmp.Or(cmp.Compare(x.Date, y.Date), cmp.Compare(x.Opens, y.Opens))
+		})
+		return normalized
+	}
+
+	a, b = normalize(a), normalize(b)
+	return slices.Equal(a.Weekly, b.Weekly) && slices.Equal(a.Holidays, b.Holidays)
+}
+
+// normalizeTime formats a time of day as Postgres' to_char(t, 'HH24:MI') does,
+// leaving empty and malformed times as they are.
+func normalizeTime(value string) string {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return value
+	}
+	return t.Format("15:04")
+}
+
+// lockCategories reads the categories of a location, locking them for the
+// rest of the transaction.
+func lockCategories(tx *sql.Tx, locationID int) ([]types.Category, error) {
+	rows, err := tx.Query("SELECT id, name FROM category WHERE location_id = $1 ORDER BY id FOR UPDATE", locationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []types.Category
+	for rows.Next() {
+		var category types.Category
+		if err = rows.Scan(&category.ID, &category.Name); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+func (s *PostgresStorage) GetTicketBySubURL(subURL string) (types.Ticket, error) {
+	ticket, err := scanTicket(s.db.QueryRow("SELECT "+ticketColumns+" FROM ticket WHERE sub_url = $1 AND deleted_at IS NULL AND "+locationFilter("location_id", 2), subURL, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Ticket{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with GetTicketBySubURL", "error", err)
+		return types.Ticket{}, err
+	}
+
+	return ticket, nil
+}
+
+// CancelTicket closes a ticket on behalf of its customer, giving up their place
+// in the queue or releasing the desk they were called to.
+func (s *PostgresStorage) CancelTicket(id int) (types.Ticket, error) {
+	query := `UPDATE ticket
+	SET closed = TRUE
+	WHERE id = $1
+	RETURNING ` + ticketColumns
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	ticket, err := s.lockTicket(tx, id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+
+	ticket, err = scanTicket(tx.QueryRow(query, id))
+	if err != nil {
+		s.logger.Tracew("error cancelling ticket", "id", id, "error", err)
+		return types.Ticket{}, err
+	}
+
+	if err = insertTicketEvent(tx, id, types.TicketCancelled, ""); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", id, "type", types.TicketCancelled, "error", err)
+		return types.Ticket{}, err
+	}
+
+	if err = enqueueAheadNotifications(tx, ticket.CategoryID); err != nil {
+		s.logger.Warnw("could not enqueue ahead notifications", "category_id", ticket.CategoryID, "error", err)
+		return types.Ticket{}, err
+	}
+
+	return ticket, tx.Commit()
+}
+
+// SnoozeTicket moves a waiting ticket back in its queue, at most maxSnoozes
+// times. Only the snoozed ticket's queued_at changes, so every other ticket
+// keeps its order relative to the rest of the queue.
+//
+// When snoozing by places the ticket is placed just behind the ticket that is
+// that many places behind it, or at the back of the queue if there are fewer.
+// When snoozing by minutes it is queued as if it had arrived that much later.
+func (s *PostgresStorage) SnoozeTicket(id int, snooze types.TicketSnooze, maxSnoozes int) (types.Ticket, error) {
+	byPlacesQuery := `UPDATE ticket t
+	SET queued_at = COALESCE(
+	    (
+	      SELECT behind.queued_at
+	      FROM ticket behind
+	      WHERE behind.category_id = t.category_id
+	        AND behind.closed = FALSE
+	        AND behind.desk_id IS NULL
+	        AND behind.deleted_at IS NULL
+	        AND (behind.queued_at, behind.id) > (t.queued_at, t.id)
+	      ORDER BY behind.queued_at, behind.id
+	      LIMIT 1 OFFSET $2 - 1
+	    ),
+	    (
+	      SELECT MAX(behind.queued_at)
+	      FROM ticket behind
+	      WHERE behind.category_id = t.category_id
+	        AND behind.closed = FALSE
+	        AND behind.desk_id IS NULL
+	        AND behind.deleted_at IS NULL
+	    )
+	  ) + INTERVAL '1 microsecond',
+	  snooze_count = t.snooze_count + 1
+	WHERE t.id = $1
+	RETURNING ` + ticketColumns
+
+	byMinutesQuery := `UPDATE ticket
+	SET queued_at = queued_at + make_interval(mins => $2),
+	  snooze_count = snooze_count + 1
+	WHERE id = $1
+	RETURNING ` + ticketColumns
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	ticket, err := s.lockTicket(tx, id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+	if ticket.DeskID != -1 {
+		return types.Ticket{}, types.ErrTicketNotWaiting
+	}
+	if ticket.Snoozes >= maxSnoozes {
+		return types.Ticket{}, types.ErrSnoozeLimit
+	}
+
+	if snooze.Places > 0 {
+		ticket, err = scanTicket(tx.QueryRow(byPlacesQuery, id, snooze.Places))
+	} else {
+		ticket, err = scanTicket(tx.QueryRow(byMinutesQuery, id, snooze.Minutes))
+	}
+	if err != nil {
+		s.logger.Tracew("error snoozing ticket", "id", id, "places", snooze.Places, "minutes", snooze.Minutes, "error", err)
+		return types.Ticket{}, err
+	}
+
+	if err = insertTicketEvent(tx, id, types.TicketSnoozed, ""); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", id, "type", types.TicketSnoozed, "error", err)
+		return types.Ticket{}, err
+	}
+
+	// The tickets the snoozed one let past have moved up.
+	if err = enqueueAheadNotifications(tx, ticket.CategoryID); err != nil {
+		s.logger.Warnw("could not enqueue ahead notifications", "category_id", ticket.CategoryID, "error", err)
+		return types.Ticket{}, err
+	}
+
+	return ticket, tx.Commit()
+}
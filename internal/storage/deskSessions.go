@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const deskSessionColumns = "id, desk_id, staff, state, opened_at, state_changed_at, closed_at"
+
+func scanDeskSession(row rowScanner) (types.DeskSession, error) {
+	var session types.DeskSession
+	var closedAt sql.NullTime
+
+	if err := row.Scan(&session.ID, &session.DeskID, &session.Staff, &session.State, &session.OpenedAt, &session.StateChangedAt, &closedAt); err != nil {
+		return types.DeskSession{}, err
+	}
+
+	if closedAt.Valid {
+		session.ClosedAt = &closedAt.Time
+	}
+
+	return session, nil
+}
+
+// enqueueDeskSessionDeliveries queues a change of a desk session for the
+// webhooks of the desk's location.
+func enqueueDeskSessionDeliveries(tx *sql.Tx, session types.DeskSession) error {
+	var locationID int
+	if err := tx.QueryRow("SELECT location_id FROM desk WHERE id = $1", session.DeskID).Scan(&locationID); err != nil {
+		return err
+	}
+
+	return enqueueWebhookDeliveries(tx, locationID, session.State.WebhookEvent(), session)
+}
+
+// GetDeskSession returns the most recent session of a desk, which will be in
+// the closed state if nobody is currently signed in.
+func (s *PostgresStorage) GetDeskSession(deskID int) (types.DeskSession, error) {
+	query := `SELECT ` + deskSessionColumns + `
+	FROM desk_session
+	WHERE desk_id = $1 AND ` + inLocation("desk_id", "desk", 2) + `
+	ORDER BY id DESC
+	LIMIT 1`
+
+	session, err := scanDeskSession(s.db.QueryRow(query, deskID, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.DeskSession{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with GetDeskSession", "desk_id", deskID, "error", err)
+		return types.DeskSession{}, err
+	}
+
+	return session, nil
+}
+
+// hashToken is what is stored of a desk session's token, so that the tokens of
+// open sessions cannot be read back from the database.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// OpenDeskSession signs staff in to a desk. The session can later be found by
+// its token with GetDeskSessionByToken until it is closed.
+func (s *PostgresStorage) OpenDeskSession(deskID int, staff string, token string) (types.DeskSession, error) {
+	query := `INSERT INTO desk_session (desk_id, staff, state, token_hash)
+	SELECT id, $2, $3, $5
+	FROM desk
+	WHERE id = $1 AND ` + locationFilter("location_id", 4) + `
+	RETURNING ` + deskSessionColumns
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.DeskSession{}, err
+	}
+	defer tx.Rollback()
+
+	session, err := scanDeskSession(tx.QueryRow(query, deskID, staff, types.DeskOpen, s.locationID, hashToken(token)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.DeskSession{}, types.ErrnotFound
+		}
+		if isUniqueViolation(err) {
+			return types.DeskSession{}, types.ErrDeskSessionOpen
+		}
+		s.logger.Warnw("could not open desk session", "desk_id", deskID, "error", err)
+		return types.DeskSession{}, err
+	}
+
+	if err = enqueueDeskSessionDeliveries(tx, session); err != nil {
+		s.logger.Warnw("could not enqueue webhook deliveries", "desk_id", deskID, "error", err)
+		return types.DeskSession{}, err
+	}
+
+	return session, tx.Commit()
+}
+
+// GetDeskSessionByToken returns the open session that was given the token.
+func (s *PostgresStorage) GetDeskSessionByToken(token string) (types.DeskSession, error) {
+	query := `SELECT ` + deskSessionColumns + `
+	FROM desk_session
+	WHERE token_hash = $1 AND closed_at IS NULL AND ` + inLocation("desk_id", "desk", 2)
+
+	session, err := scanDeskSession(s.db.QueryRow(query, hashToken(token), s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.DeskSession{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with GetDeskSessionByToken", "error", err)
+		return types.DeskSession{}, err
+	}
+
+	return session, nil
+}
+
+// SetDeskSessionState pauses or resumes the open session of a desk.
+func (s *PostgresStorage) SetDeskSessionState(deskID int, state types.DeskSessionState) (types.DeskSession, error) {
+	query := `UPDATE desk_session
+	SET state = $2, state_changed_at = NOW()
+	WHERE desk_id = $1 AND closed_at IS NULL AND ` + inLocation("desk_id", "desk", 3) + `
+	RETURNING ` + deskSessionColumns
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.DeskSession{}, err
+	}
+	defer tx.Rollback()
+
+	session, err := scanDeskSession(tx.QueryRow(query, deskID, state, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.DeskSession{}, types.ErrDeskClosed
+		}
+		s.logger.Warnw("could not update desk session", "desk_id", deskID, "state", state, "error", err)
+		return types.DeskSession{}, err
+	}
+
+	if err = enqueueDeskSessionDeliveries(tx, session); err != nil {
+		s.logger.Warnw("could not enqueue webhook deliveries", "desk_id", deskID, "error", err)
+		return types.DeskSession{}, err
+	}
+
+	return session, tx.Commit()
+}
+
+func (s *PostgresStorage) CloseDeskSession(deskID int) (types.DeskSession, error) {
+	query := `UPDATE desk_session
+	SET state = $2, state_changed_at = NOW(), closed_at = NOW()
+	WHERE desk_id = $1 AND closed_at IS NULL AND ` + inLocation("desk_id", "desk", 3) + `
+	RETURNING ` + deskSessionColumns
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.DeskSession{}, err
+	}
+	defer tx.Rollback()
+
+	session, err := scanDeskSession(tx.QueryRow(query, deskID, types.DeskClosed, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.DeskSession{}, types.ErrDeskClosed
+		}
+		s.logger.Warnw("could not close desk session", "desk_id", deskID, "error", err)
+		return types.DeskSession{}, err
+	}
+
+	if err = enqueueDeskSessionDeliveries(tx, session); err != nil {
+		s.logger.Warnw("could not enqueue webhook deliveries", "desk_id", deskID, "error", err)
+		return types.DeskSession{}, err
+	}
+
+	return session, tx.Commit()
+}
+
+// checkDeskOpen locks the open session of a desk for the rest of the
+// transaction, so that it cannot be paused or closed while a ticket is being
+// assigned to it.
+func checkDeskOpen(tx *sql.Tx, deskID int) error {
+	var state types.DeskSessionState
+
+	err := tx.QueryRow("SELECT state FROM desk_session WHERE desk_id = $1 AND closed_at IS NULL FOR SHARE", deskID).Scan(&state)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.ErrDeskClosed
+		}
+		return err
+	}
+
+	if state == types.DeskPaused {
+		return types.ErrDeskPaused
+	}
+
+	return nil
+}
+
+func (s *PostgresStorage) createDeskSessionTable() error {
+	query := `CREATE TABLE IF NOT EXISTS desk_session(
+	id SERIAL PRIMARY KEY,
+	desk_id INT NOT NULL REFERENCES desk(id) ON DELETE CASCADE,
+	staff VARCHAR(50) NOT NULL,
+	state VARCHAR(10) NOT NULL,
+	opened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	state_changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	closed_at TIMESTAMP
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS desk_session_active_idx ON desk_session(desk_id) WHERE closed_at IS NULL;`
+
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *PostgresStorage) alterDeskSessionTableToken() error {
+	query := `ALTER TABLE desk_session ADD COLUMN IF NOT EXISTS token_hash CHAR(64);
+	CREATE UNIQUE INDEX IF NOT EXISTS desk_session_token_idx ON desk_session(token_hash) WHERE closed_at IS NULL;`
+
+	_, err := s.db.Exec(query)
+	return err
+}
@@ -3,13 +3,27 @@ package storage
 import (
 	"errors"
 	"fmt"
+
+	"github.com/lib/pq"
 )
 
 var ErrNotImplemented = errors.New("not implemented")
 var ErrNotFound = errors.New("not found")
 var ErrNoRowsAffected = errors.New("no rows affected")
-var errDeskNull = errors.New("sql: Scan error on column index 3, name \"desk_id\": converting NULL to int is unsupported")
 
 func errAffectedMultipleRows(operation string) error {
 	return fmt.Errorf("multiple rows were affected during %s", operation)
 }
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// isInUse reports whether a row could not be deleted because others still
+// refer to it, or because a trigger such as
+// prevent_category_delete_on_open_tickets refused.
+func isInUse(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && (pqErr.Code == "23503" || pqErr.Code == "P0001")
+}
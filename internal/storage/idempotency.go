@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// idempotencyLease is how long a claimed key is kept for a request that has
+// not completed. A server that stopped while running the request never stores
+// its response, so the key is claimed again by the next retry after this.
+const idempotencyLease = time.Minute
+
+// BeginIdempotentRequest claims an idempotency key for a request, reporting
+// whether it was claimed. A key that is already taken is returned as it is,
+// whether or not its response has been stored yet, unless it has been running
+// for longer than idempotencyLease. Keys older than window are forgotten, and
+// may be used again.
+func (s *PostgresStorage) BeginIdempotentRequest(key string, fingerprint string, window time.Duration) (types.IdempotentRequest, bool, error) {
+	if _, err := s.db.Exec("DELETE FROM idempotency_key WHERE created_at < NOW() - make_interval(secs => $1)", window.Seconds()); err != nil {
+		s.logger.Warnw("could not purge idempotency keys", "error", err)
+		return types.IdempotentRequest{}, false, err
+	}
+
+	query := `INSERT INTO idempotency_key (key, fingerprint) VALUES ($1, $2)
+	ON CONFLICT (key) DO UPDATE
+	SET fingerprint = EXCLUDED.fingerprint, created_at = NOW()
+	WHERE idempotency_key.completed_at IS NULL
+	  AND idempotency_key.created_at < NOW() - make_interval(secs => $3)`
+
+	result, err := s.db.Exec(query, key, fingerprint, idempotencyLease.Seconds())
+	if err != nil {
+		s.logger.Warnw("could not claim idempotency key", "error", err)
+		return types.IdempotentRequest{}, false, err
+	}
+
+	if claimed, err := result.RowsAffected(); err != nil || claimed == 1 {
+		return types.IdempotentRequest{Key: key, Fingerprint: fingerprint}, claimed == 1, err
+	}
+
+	request := types.IdempotentRequest{Key: key}
+	var status sql.NullInt64
+
+	query = "SELECT fingerprint, status, content_type, body FROM idempotency_key WHERE key = $1"
+	if err = s.db.QueryRow(query, key).Scan(&request.Fingerprint, &status, &request.ContentType, &request.Body); err != nil {
+		if err == sql.ErrNoRows {
+			// Completed and forgotten in between, which only an abandoned
+			// request can be, so the key is free to claim again.
+			return s.BeginIdempotentRequest(key, fingerprint, window)
+		}
+		s.logger.Warnw("error with BeginIdempotentRequest", "error", err)
+		return types.IdempotentRequest{}, false, err
+	}
+	request.Status = int(status.Int64)
+
+	return request, false, nil
+}
+
+// CompleteIdempotentRequest stores the response of a claimed request for its
+// retries.
+func (s *PostgresStorage) CompleteIdempotentRequest(request types.IdempotentRequest) error {
+	query := `UPDATE idempotency_key
+	SET status = $2, content_type = $3, body = $4, completed_at = NOW()
+	WHERE key = $1`
+
+	if _, err := s.db.Exec(query, request.Key, request.Status, request.ContentType, request.Body); err != nil {
+		s.logger.Warnw("could not store idempotent response", "error", err)
+		return err
+	}
+	return nil
+}
+
+// AbandonIdempotentRequest releases the key of a request that failed in a way
+// a retry might not, so that the retry runs it again.
+func (s *PostgresStorage) AbandonIdempotentRequest(key string) error {
+	if _, err := s.db.Exec("DELETE FROM idempotency_key WHERE key = $1 AND status IS NULL", key); err != nil {
+		s.logger.Warnw("could not release idempotency key", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *PostgresStorage) createIdempotencyKeyTable() error {
+	query := `CREATE TABLE IF NOT EXISTS idempotency_key(
+	key VARCHAR(255) PRIMARY KEY,
+	fingerprint CHAR(64) NOT NULL,
+	status INT,
+	content_type TEXT NOT NULL DEFAULT '',
+	body BYTEA,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	completed_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idempotency_key_created_at_idx ON idempotency_key(created_at);`
+
+	_, err := s.db.Exec(query)
+	return err
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const locationColumns = "id, slug, name, timezone, logo_url, accent_color, welcome_message"
+
+// ForLocation returns a copy of the storage whose queries only see and change
+// the categories, desks and tickets of a location, along with everything that
+// belongs to them. The storage returned by NewPostgresStorage sees every
+// location, and is only meant for background workers.
+func (s *PostgresStorage) ForLocation(locationID int) *PostgresStorage {
+	scoped := *s
+	scoped.locationID = locationID
+	return &scoped
+}
+
+// locationFilter restricts a query to the location of the storage, given as
+// parameter n, unless the storage sees every location.
+func locationFilter(column string, n int) string {
+	return fmt.Sprintf("($%d = 0 OR %s = $%d)", n, column, n)
+}
+
+// inLocation restricts a query to the rows whose column refers to a category
+// or desk, named by table, of the location given as parameter n.
+func inLocation(column string, table string, n int) string {
+	return fmt.Sprintf("%s IN (SELECT id FROM %s WHERE %s)", column, table, locationFilter("location_id", n))
+}
+
+func scanLocation(row rowScanner) (types.Location, error) {
+	var location types.Location
+
+	err := row.Scan(&location.ID, &location.Slug, &location.Name, &location.Timezone, &location.Branding.LogoURL, &location.Branding.AccentColor, &location.Branding.WelcomeMessage)
+	return location, err
+}
+
+func (s *PostgresStorage) CreateLocation(location types.Location) (types.Location, error) {
+	query := `INSERT INTO location (slug, name, timezone, logo_url, accent_color, welcome_message)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING ` + locationColumns
+
+	created, err := scanLocation(s.db.QueryRow(query, location.Slug, location.Name, location.Timezone, location.Branding.LogoURL, location.Branding.AccentColor, location.Branding.WelcomeMessage))
+	if err != nil {
+		s.logger.Warnw("could not create location", "slug", location.Slug, "error", err)
+		return types.Location{}, err
+	}
+
+	return created, nil
+}
+
+func (s *PostgresStorage) GetLocation(slug string) (types.Location, error) {
+	location, err := scanLocation(s.db.QueryRow("SELECT "+locationColumns+" FROM location WHERE slug = $1", slug))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Location{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with GetLocation", "slug", slug, "error", err)
+		return types.Location{}, err
+	}
+
+	return location, nil
+}
+
+func (s *PostgresStorage) ListLocations() ([]types.Location, error) {
+	rows, err := s.db.Query("SELECT " + locationColumns + " FROM location ORDER BY id")
+	if err != nil {
+		s.logger.Warnw("error with ListLocations", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []types.Location{}
+
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, rows.Err()
+}
+
+// UpdateLocation changes the name and settings of a location. Its slug is
+// kept, as it is part of the URLs customers have been given.
+func (s *PostgresStorage) UpdateLocation(location types.Location) (types.Location, error) {
+	query := `UPDATE location
+	SET name = $2, timezone = $3, logo_url = $4, accent_color = $5, welcome_message = $6
+	WHERE id = $1
+	RETURNING ` + locationColumns
+
+	updated, err := scanLocation(s.db.QueryRow(query, location.ID, location.Name, location.Timezone, location.Branding.LogoURL, location.Branding.AccentColor, location.Branding.WelcomeMessage))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Location{}, types.ErrnotFound
+		}
+		s.logger.Warnw("could not update location", "id", location.ID, "error", err)
+		return types.Location{}, err
+	}
+
+	return updated, nil
+}
+
+func (s *PostgresStorage) GetLocationHours(locationID int) (types.LocationHours, error) {
+	schedule, err := getOpeningSchedule(s.db, "location_hours", "location_holiday", "location_id", locationID)
+	if err != nil {
+		s.logger.Warnw("error with GetLocationHours", "location_id", locationID, "error", err)
+		return types.LocationHours{}, err
+	}
+
+	return types.LocationHours{LocationID: locationID, OpeningSchedule: schedule}, nil
+}
+
+// SetLocationHours replaces all of the weekly hours and holiday exceptions of
+// a location.
+func (s *PostgresStorage) SetLocationHours(hours types.LocationHours) (types.LocationHours, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.LocationHours{}, err
+	}
+	defer tx.Rollback()
+
+	if err = setOpeningSchedule(tx, "location_hours", "location_holiday", "location_id", hours.LocationID, hours.OpeningSchedule); err != nil {
+		s.logger.Warnw("could not set location hours", "location_id", hours.LocationID, "error", err)
+		return types.LocationHours{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return types.LocationHours{}, err
+	}
+
+	return s.GetLocationHours(hours.LocationID)
+}
+
+func (s *PostgresStorage) createLocationTables() error {
+	query := `CREATE TABLE IF NOT EXISTS location(
+	id SERIAL PRIMARY KEY,
+	slug VARCHAR(50) NOT NULL UNIQUE,
+	name VARCHAR(100) NOT NULL,
+	timezone TEXT NOT NULL DEFAULT 'UTC',
+	logo_url VARCHAR(2048) NOT NULL DEFAULT '',
+	accent_color VARCHAR(7) NOT NULL DEFAULT '',
+	welcome_message VARCHAR(500) NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+
+	INSERT INTO location (slug, name) VALUES ('` + types.DefaultLocation + `', 'Default') ON CONFLICT (slug) DO NOTHING;
+
+	CREATE TABLE IF NOT EXISTS location_hours(
+	id SERIAL PRIMARY KEY,
+	location_id INT NOT NULL REFERENCES location(id) ON DELETE CASCADE,
+	weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+	opens TIME NOT NULL,
+	closes TIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS location_holiday(
+	id SERIAL PRIMARY KEY,
+	location_id INT NOT NULL REFERENCES location(id) ON DELETE CASCADE,
+	date DATE NOT NULL,
+	opens TIME,
+	closes TIME
+	);`
+
+	_, err := s.db.Exec(query)
+	return err
+}
+
+// alterTablesLocation moves the existing categories, desks and tickets into
+// the default location. Categories are named uniquely within a location, and
+// the foreign keys on (id, location_id) stop a desk or ticket ever referring
+// to a category or desk of another location.
+func (s *PostgresStorage) alterTablesLocation() error {
+	query := `ALTER TABLE category ADD COLUMN IF NOT EXISTS location_id INT REFERENCES location(id);
+	ALTER TABLE desk ADD COLUMN IF NOT EXISTS location_id INT REFERENCES location(id);
+	ALTER TABLE ticket ADD COLUMN IF NOT EXISTS location_id INT REFERENCES location(id);
+
+	UPDATE category
+	SET location_id = (SELECT id FROM location WHERE slug = '` + types.DefaultLocation + `')
+	WHERE location_id IS NULL;
+
+	UPDATE desk d
+	SET location_id = COALESCE(
+	    (SELECT c.location_id FROM category c WHERE c.id = d.category_id),
+	    (SELECT id FROM location WHERE slug = '` + types.DefaultLocation + `')
+	  )
+	WHERE location_id IS NULL;
+
+	UPDATE ticket t
+	SET location_id = COALESCE(
+	    (SELECT c.location_id FROM category c WHERE c.id = t.category_id),
+	    (SELECT id FROM location WHERE slug = '` + types.DefaultLocation + `')
+	  )
+	WHERE location_id IS NULL;
+
+	ALTER TABLE category ALTER COLUMN location_id SET NOT NULL;
+	ALTER TABLE desk ALTER COLUMN location_id SET NOT NULL;
+	ALTER TABLE ticket ALTER COLUMN location_id SET NOT NULL;
+
+	ALTER TABLE category DROP CONSTRAINT IF EXISTS category_name_key;
+	CREATE UNIQUE INDEX IF NOT EXISTS category_location_name_idx ON category(location_id, name);
+	CREATE INDEX IF NOT EXISTS ticket_location_idx ON ticket(location_id);
+
+	DO $$
+	BEGIN
+	  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'category_id_location_key') THEN
+	    ALTER TABLE category ADD CONSTRAINT category_id_location_key UNIQUE (id, location_id);
+	  END IF;
+	  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'desk_id_location_key') THEN
+	    ALTER TABLE desk ADD CONSTRAINT desk_id_location_key UNIQUE (id, location_id);
+	  END IF;
+	  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'desk_category_location_fkey') THEN
+	    ALTER TABLE desk ADD CONSTRAINT desk_category_location_fkey
+	      FOREIGN KEY (category_id, location_id) REFERENCES category(id, location_id);
+	  END IF;
+	  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'ticket_category_location_fkey') THEN
+	    ALTER TABLE ticket ADD CONSTRAINT ticket_category_location_fkey
+	      FOREIGN KEY (category_id, location_id) REFERENCES category(id, location_id);
+	  END IF;
+	  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'ticket_desk_location_fkey') THEN
+	    ALTER TABLE ticket ADD CONSTRAINT ticket_desk_location_fkey
+	      FOREIGN KEY (desk_id, location_id) REFERENCES desk(id, location_id);
+	  END IF;
+	END
+	$$;`
+
+	_, err := s.db.Exec(query)
+	return err
+}
@@ -1,125 +1,1072 @@
 package storage
 
 import (
-	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"github.com/lib/pq"
 )
 
-type MockStorage struct{}
+// MockStorage keeps locations, categories, desks and tickets in memory, so
+// that the API can be run in tests without a database. Like PostgresStorage it
+// starts out with the default location, sees every location until it is
+// scoped with ForLocation, and hands every ticket event it records to the
+// function given to OnTicketEvent.
+//
+// Appointments, webhooks, reports and configuration files are not kept, and
+// return types.ErrNotImplemented.
+type MockStorage struct {
+	*mockData
+	locationID int
+}
+
+type mockData struct {
+	mu sync.Mutex
+
+	ids           map[string]int
+	locations     []types.Location
+	locationHours map[int]types.OpeningSchedule
+	categories    []*mockCategory
+	desks         []*mockDesk
+	tickets       []*mockTicket
+	events        []types.TicketEvent
+	sessions      []*mockSession
+	idempotency   map[string]*mockIdempotentRequest
+	publish       func(types.TicketEvent)
+}
+
+type mockCategory struct {
+	types.Category
+	locationID int
+	settings   *types.CategorySettings
+	hours      types.OpeningSchedule
+}
+
+type mockDesk struct {
+	types.Desk
+	locationID int
+}
+
+type mockTicket struct {
+	types.Ticket
+	locationID int
+	deleted    bool
+}
+
+type mockSession struct {
+	types.DeskSession
+	locationID int
+	token      string
+}
+
+type mockIdempotentRequest struct {
+	types.IdempotentRequest
+	createdAt time.Time
+}
+
+func NewMockStorage() *MockStorage {
+	m := &MockStorage{mockData: &mockData{
+		ids:           make(map[string]int),
+		locationHours: make(map[int]types.OpeningSchedule),
+		idempotency:   make(map[string]*mockIdempotentRequest),
+	}}
+
+	m.locations = append(m.locations, types.Location{ID: m.nextID("location"), Slug: types.DefaultLocation, Name: "Default", Timezone: "UTC"})
+	return m
+}
+
+// ForLocation returns a copy of the storage which only sees the categories,
+// desks and tickets of a location, sharing the data of the original.
+func (m *MockStorage) ForLocation(locationID int) *MockStorage {
+	return &MockStorage{mockData: m.mockData, locationID: locationID}
+}
+
+func (m *MockStorage) Init() error {
+	return nil
+}
+
+// OnTicketEvent calls publish with every ticket event recorded from then on,
+// standing in for the ListenTicketEvents of PostgresStorage.
+func (m *MockStorage) OnTicketEvent(publish func(types.TicketEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.publish = publish
+}
 
-func (s MockStorage) CallNextTicket(deskID int) (types.Ticket, error) {
+// nextID returns the next of the serial IDs of kind, starting from one.
+func (m *mockData) nextID(kind string) int {
+	m.ids[kind]++
+	return m.ids[kind]
+}
 
-	return types.Ticket{
-		ID:         2,
-		CategoryID: 4,
-		SubURL:     "frjikll23l",
+// uniqueViolation is the error Postgres gives when a unique constraint is
+// broken, for the callers that look for it.
+func uniqueViolation(constraint string) error {
+	return &pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "` + constraint + `"`}
+}
+
+func (m *MockStorage) sees(locationID int) bool {
+	return m.locationID == 0 || m.locationID == locationID
+}
+
+func (m *MockStorage) category(id int) (*mockCategory, error) {
+	for _, category := range m.categories {
+		if category.ID == id && m.sees(category.locationID) {
+			return category, nil
+		}
+	}
+	return nil, types.ErrnotFound
+}
+
+func (m *MockStorage) desk(id int) (*mockDesk, error) {
+	for _, desk := range m.desks {
+		if desk.ID == id && m.sees(desk.locationID) {
+			return desk, nil
+		}
+	}
+	return nil, types.ErrnotFound
+}
+
+func (m *MockStorage) ticket(id int) (*mockTicket, error) {
+	for _, ticket := range m.tickets {
+		if ticket.ID == id && !ticket.deleted && m.sees(ticket.locationID) {
+			return ticket, nil
+		}
+	}
+	return nil, types.ErrnotFound
+}
+
+// openSession returns the session of a desk that has not been closed, or nil.
+func (m *MockStorage) openSession(deskID int) *mockSession {
+	for _, session := range m.sessions {
+		if session.DeskID == deskID && session.ClosedAt == nil {
+			return session
+		}
+	}
+	return nil
+}
+
+func (t *mockTicket) waiting() bool {
+	return !t.Closed && !t.deleted && t.DeskID == -1
+}
+
+// recordEvent appends to the history of a ticket and hands the event to the
+// function given to OnTicketEvent, as the listener of PostgresStorage would.
+func (m *MockStorage) recordEvent(ticket *mockTicket, eventType types.TicketEventType, staff string, deskID int) {
+	event := types.TicketEvent{
+		ID:         m.nextID("ticket_event"),
+		TicketID:   ticket.ID,
+		Type:       eventType,
+		CategoryID: ticket.CategoryID,
 		DeskID:     deskID,
+		Staff:      staff,
 		CreatedAt:  time.Now(),
-	}, nil
+	}
+	m.events = append(m.events, event)
+
+	if m.publish != nil {
+		m.publish(event)
+	}
 }
 
-func (s MockStorage) SeeNext(categoryID int) (types.Ticket, error) {
+func (m *MockStorage) CreateLocation(location types.Location) (types.Location, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	return types.Ticket{
-		ID:         2,
-		CategoryID: categoryID,
-		SubURL:     "frjikll23l",
-		DeskID:     4,
-		CreatedAt:  time.Now(),
-	}, nil
+	for _, existing := range m.locations {
+		if existing.Slug == location.Slug {
+			return types.Location{}, uniqueViolation("location_slug_key")
+		}
+	}
+
+	location.ID = m.nextID("location")
+	m.locations = append(m.locations, location)
+	return location, nil
 }
 
-func (s MockStorage) SeeQueue() ([]int, error) {
-	return []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, nil
+func (m *MockStorage) GetLocation(slug string) (types.Location, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, location := range m.locations {
+		if location.Slug == slug {
+			return location, nil
+		}
+	}
+	return types.Location{}, types.ErrnotFound
 }
 
-func (s MockStorage) CreateTicket(categoryID int) (types.Ticket, error) {
-	return types.Ticket{
-		ID:         8,
-		CategoryID: categoryID,
-		SubURL:     "hjkl8",
-		DeskID:     1,
-		CreatedAt:  time.Now(),
-	}, nil
+func (m *MockStorage) ListLocations() ([]types.Location, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.locations), nil
 }
 
-func (s MockStorage) GetTicket(ticketID int) (types.Ticket, error) {
-	return types.Ticket{
-		ID:         ticketID,
-		CategoryID: 4,
-		SubURL:     "hjkl8",
-		DeskID:     1,
-		CreatedAt:  time.Now(),
-	}, nil
+func (m *MockStorage) UpdateLocation(location types.Location) (types.Location, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.locations {
+		if existing.ID == location.ID {
+			location.Slug = existing.Slug
+			m.locations[i] = location
+			return location, nil
+		}
+	}
+	return types.Location{}, types.ErrnotFound
+}
+
+func (m *MockStorage) GetLocationHours(locationID int) (types.LocationHours, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return types.LocationHours{LocationID: locationID, OpeningSchedule: m.locationHours[locationID]}, nil
+}
+
+func (m *MockStorage) SetLocationHours(hours types.LocationHours) (types.LocationHours, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.locationHours[hours.LocationID] = hours.OpeningSchedule
+	return hours, nil
+}
+
+func (m *MockStorage) CallNextTicket(deskID int, staff string) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	desk, err := m.desk(deskID)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+
+	session := m.openSession(deskID)
+	if session == nil {
+		return types.Ticket{}, types.ErrDeskClosed
+	}
+	if session.State == types.DeskPaused {
+		return types.Ticket{}, types.ErrDeskPaused
+	}
+
+	next := m.nextWaiting(desk.CategoryID)
+	if next == nil {
+		return types.Ticket{}, types.ErrnotFound
+	}
+
+	now := time.Now()
+	next.DeskID, next.CalledAt, next.ServedAt, next.Recalls = deskID, &now, nil, 0
+	m.recordEvent(next, types.TicketCalled, staff, deskID)
+
+	return next.Ticket, nil
+}
+
+// nextWaiting returns the ticket at the front of a category's queue, or nil
+// when none are waiting.
+func (m *MockStorage) nextWaiting(categoryID int) *mockTicket {
+	var next *mockTicket
+	for _, ticket := range m.tickets {
+		if ticket.CategoryID != categoryID || !ticket.waiting() || !m.sees(ticket.locationID) {
+			continue
+		}
+		if next == nil || ticket.QueuedAt.Before(next.QueuedAt) {
+			next = ticket
+		}
+	}
+	return next
+}
+
+func (m *MockStorage) SeeNext(categoryID int) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := m.nextWaiting(categoryID)
+	if next == nil {
+		return types.Ticket{}, types.ErrnotFound
+	}
+	return next.Ticket, nil
+}
+
+func (m *MockStorage) SeeQueue(categoryIDs []int) ([]types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tickets := []types.Ticket{}
+	for _, ticket := range m.tickets {
+		if !ticket.waiting() || !m.sees(ticket.locationID) {
+			continue
+		}
+		if len(categoryIDs) > 0 && !slices.Contains(categoryIDs, ticket.CategoryID) {
+			continue
+		}
+		tickets = append(tickets, ticket.Ticket)
+	}
+
+	slices.SortStableFunc(tickets, func(a, b types.Ticket) int {
+		return a.QueuedAt.Compare(b.QueuedAt)
+	})
+	return tickets, nil
+}
+
+func (m *MockStorage) DeskTickets(deskID int) ([]types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tickets := []types.Ticket{}
+	for _, ticket := range m.tickets {
+		if ticket.DeskID == deskID && !ticket.Closed && !ticket.deleted && m.sees(ticket.locationID) {
+			tickets = append(tickets, ticket.Ticket)
+		}
+	}
+	return tickets, nil
+}
+
+func (m *MockStorage) CreateTicket(ticketCreate types.TicketCreate) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, err := m.category(ticketCreate.CategoryID)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+
+	if category.settings != nil && category.settings.MaxWaiting > 0 {
+		waiting := 0
+		for _, ticket := range m.tickets {
+			if ticket.CategoryID == category.ID && ticket.waiting() {
+				waiting++
+			}
+		}
+		if waiting >= category.settings.MaxWaiting {
+			return types.Ticket{}, types.ErrQueueFull
+		}
+	}
+
+	for _, ticket := range m.tickets {
+		if ticket.SubURL == ticketCreate.SubURL {
+			return types.Ticket{}, uniqueViolation("ticket_sub_url_key")
+		}
+	}
+
+	now := time.Now()
+	ticket := &mockTicket{
+		Ticket: types.Ticket{
+			ID:         m.nextID("ticket"),
+			CategoryID: category.ID,
+			SubURL:     ticketCreate.SubURL,
+			DeskID:     -1,
+			CreatedAt:  now,
+			QueuedAt:   now,
+		},
+		locationID: category.locationID,
+	}
+	m.tickets = append(m.tickets, ticket)
+	m.recordEvent(ticket, types.TicketCreated, "", -1)
+
+	return ticket.Ticket, nil
+}
+
+func (m *MockStorage) GetTicket(id int) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ticket, err := m.ticket(id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	return ticket.Ticket, nil
 }
 
-func (s MockStorage) DeleteTicket(ticketID int) error {
+func (m *MockStorage) DeleteTicket(id int, staff string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ticket, err := m.ticket(id)
+	if err != nil {
+		return err
+	}
+
+	ticket.Closed, ticket.deleted = true, true
+	m.recordEvent(ticket, types.TicketDeleted, staff, ticket.DeskID)
 	return nil
 }
 
-func (s MockStorage) CreateCategory(name string) (types.Category, error) {
-	return types.Category{ID: 1, Name: name}, nil
+func (m *MockStorage) RecallTicket(id int, staff string) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ticket, err := m.ticket(id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+	if ticket.DeskID == -1 {
+		return types.Ticket{}, types.ErrTicketNotCalled
+	}
+	if ticket.ServedAt != nil {
+		return types.Ticket{}, types.ErrTicketServing
+	}
+
+	now := time.Now()
+	ticket.CalledAt = &now
+	ticket.Recalls++
+	m.recordEvent(ticket, types.TicketRecalled, staff, ticket.DeskID)
+
+	return ticket.Ticket, nil
+}
+
+func (m *MockStorage) ServeTicket(id int, staff string) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ticket, err := m.ticket(id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+	if ticket.DeskID == -1 {
+		return types.Ticket{}, types.ErrTicketNotCalled
+	}
+	if ticket.ServedAt != nil {
+		return types.Ticket{}, types.ErrTicketServing
+	}
+
+	now := time.Now()
+	ticket.ServedAt = &now
+	m.recordEvent(ticket, types.TicketServing, staff, ticket.DeskID)
+
+	return ticket.Ticket, nil
+}
+
+func (m *MockStorage) TransferTicket(id int, categoryID int, staff string) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ticket, err := m.ticket(id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+
+	deskID := ticket.DeskID
+	ticket.CategoryID, ticket.DeskID, ticket.CalledAt, ticket.ServedAt, ticket.Recalls = categoryID, -1, nil, nil, 0
+	m.recordEvent(ticket, types.TicketTransferred, staff, deskID)
+
+	return ticket.Ticket, nil
+}
+
+func (m *MockStorage) CloseTicket(id int, staff string) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ticket, err := m.ticket(id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+
+	ticket.Closed = true
+	m.recordEvent(ticket, types.TicketClosed, staff, ticket.DeskID)
+
+	return ticket.Ticket, nil
+}
+
+func (m *MockStorage) MarkNoShow(id int, requeue bool, staff string) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ticket, err := m.ticket(id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+	if ticket.DeskID == -1 {
+		return types.Ticket{}, types.ErrTicketNotCalled
+	}
+	if ticket.ServedAt != nil {
+		return types.Ticket{}, types.ErrTicketServing
+	}
+
+	deskID := ticket.DeskID
+	if requeue {
+		ticket.DeskID, ticket.CalledAt, ticket.Recalls, ticket.QueuedAt = -1, nil, 0, time.Now()
+		m.recordEvent(ticket, types.TicketRequeued, staff, deskID)
+	} else {
+		ticket.Closed = true
+		m.recordEvent(ticket, types.TicketNoShow, staff, deskID)
+	}
+
+	return ticket.Ticket, nil
+}
+
+func (m *MockStorage) GetTicketHistory(id int) ([]types.TicketEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.ticket(id); err != nil {
+		return nil, err
+	}
+
+	history := []types.TicketEvent{}
+	for _, event := range m.events {
+		if event.TicketID == id {
+			history = append(history, event)
+		}
+	}
+	return history, nil
+}
+
+func (m *MockStorage) TicketEventsSince(categoryIDs []int, afterID int, limit int) ([]types.TicketEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := []types.TicketEvent{}
+	for _, event := range m.events {
+		if len(events) == limit {
+			break
+		}
+		if event.ID <= afterID || !slices.Contains(categoryIDs, event.CategoryID) {
+			continue
+		}
+		if _, err := m.category(event.CategoryID); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (m *MockStorage) GetTicketBySubURL(subURL string) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, ticket := range m.tickets {
+		if ticket.SubURL == subURL && !ticket.deleted && m.sees(ticket.locationID) {
+			return ticket.Ticket, nil
+		}
+	}
+	return types.Ticket{}, types.ErrnotFound
+}
+
+func (m *MockStorage) CancelTicket(id int) (types.Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ticket, err := m.ticket(id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	if !ticket.waiting() {
+		return types.Ticket{}, types.ErrTicketNotWaiting
+	}
+
+	ticket.Closed = true
+	m.recordEvent(ticket, types.TicketCancelled, "", -1)
+
+	return ticket.Ticket, nil
+}
+
+func (m *MockStorage) SnoozeTicket(id int, snooze types.TicketSnooze, maxSnoozes int) (types.Ticket, error) {
+	return types.Ticket{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) ServiceDurations(categoryID int, limit int) ([]time.Duration, error) {
+	return nil, nil
+}
+
+func (m *MockStorage) CountOpenDesks(categoryID int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, desk := range m.desks {
+		if desk.CategoryID != categoryID || !m.sees(desk.locationID) {
+			continue
+		}
+		if session := m.openSession(desk.ID); session != nil && session.State == types.DeskOpen {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockStorage) CountWaiting(categoryID int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, ticket := range m.tickets {
+		if ticket.CategoryID == categoryID && ticket.waiting() && m.sees(ticket.locationID) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockStorage) TicketsAhead(id int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ticket, err := m.ticket(id)
+	if err != nil {
+		return 0, err
+	}
+	if !ticket.waiting() {
+		return 0, types.ErrTicketNotWaiting
+	}
+
+	count := 0
+	for _, other := range m.tickets {
+		if other.CategoryID == ticket.CategoryID && other.waiting() && other.QueuedAt.Before(ticket.QueuedAt) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockStorage) RecentCalls(categoryIDs []int, limit int) ([]types.Call, error) {
+	return []types.Call{}, nil
 }
 
-func (s MockStorage) GetCategory(id int) (types.Category, error) {
-	return types.Category{
-		ID:   id,
-		Name: fmt.Sprintf("Desk %d", id),
-	}, nil
+func (m *MockStorage) Report(query types.ReportQuery) ([]types.ReportRow, error) {
+	return nil, types.ErrNotImplemented
 }
 
-func (s MockStorage) UpdateCategory(id int, name string) (types.Category, error) {
-	return types.Category{ID: id, Name: name}, nil
+func (m *MockStorage) CreateCategory(name string) (types.Category, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, category := range m.categories {
+		if category.Name == name && category.locationID == m.locationID {
+			return types.Category{}, uniqueViolation("category_location_id_name_key")
+		}
+	}
+
+	category := &mockCategory{Category: types.Category{ID: m.nextID("category"), Name: name, Version: 1}, locationID: m.locationID}
+	m.categories = append(m.categories, category)
+	return category.Category, nil
+}
+
+func (m *MockStorage) GetCategory(id int) (types.Category, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, err := m.category(id)
+	if err != nil {
+		return types.Category{}, err
+	}
+	return category.Category, nil
+}
+
+func (m *MockStorage) ListCategories() ([]types.Category, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	categories := []types.Category{}
+	for _, category := range m.categories {
+		if m.sees(category.locationID) {
+			categories = append(categories, category.Category)
+		}
+	}
+	return categories, nil
+}
+
+func (m *MockStorage) UpdateCategory(id int, name string, version int) (types.Category, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, err := m.category(id)
+	if err != nil {
+		return types.Category{}, err
+	}
+	if err = checkVersion(category.Version, version); err != nil {
+		return types.Category{}, err
+	}
+
+	category.Name = name
+	category.Version++
+	return category.Category, nil
 }
 
-func (s MockStorage) DeleteCategory(id int) error {
+func (m *MockStorage) DeleteCategory(id int, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, err := m.category(id)
+	if err != nil {
+		return err
+	}
+	if err = checkVersion(category.Version, version); err != nil {
+		return err
+	}
+
+	for _, desk := range m.desks {
+		if desk.CategoryID == id {
+			return types.ErrInUse
+		}
+	}
+	for _, ticket := range m.tickets {
+		if ticket.CategoryID == id && !ticket.Closed {
+			return types.ErrInUse
+		}
+	}
+
+	m.categories = slices.DeleteFunc(m.categories, func(c *mockCategory) bool { return c == category })
 	return nil
 }
 
-func (s MockStorage) CreateDesk(label string, categoryID int) (types.Desk, error) {
-	return types.Desk{
-		ID:         1,
-		CategoryID: categoryID,
-		Label:      label,
-	}, nil
+// categoryTimezone is the timezone a category's settings default to, that of
+// its location.
+func (m *MockStorage) categoryTimezone(category *mockCategory) string {
+	for _, location := range m.locations {
+		if location.ID == category.locationID {
+			return location.Timezone
+		}
+	}
+	return ""
+}
+
+func (m *MockStorage) GetCategorySettings(categoryID int) (types.CategorySettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, err := m.category(categoryID)
+	if err != nil {
+		return types.CategorySettings{}, err
+	}
+
+	if category.settings == nil {
+		return defaultCategorySettings(categoryID, m.categoryTimezone(category)), nil
+	}
+	return *category.settings, nil
+}
+
+// UpdateCategorySettings replaces the settings of a category, keeping whether
+// its intake is closed as upsertCategorySettings does.
+func (m *MockStorage) UpdateCategorySettings(settings types.CategorySettings) (types.CategorySettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, err := m.category(settings.CategoryID)
+	if err != nil {
+		return types.CategorySettings{}, err
+	}
+
+	settings.IntakeClosed = category.settings != nil && category.settings.IntakeClosed
+	category.settings = &settings
+	return settings, nil
+}
+
+func (m *MockStorage) SetIntakeClosed(categoryID int, closed bool) (types.CategorySettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, err := m.category(categoryID)
+	if err != nil {
+		return types.CategorySettings{}, err
+	}
+
+	if category.settings == nil {
+		settings := defaultCategorySettings(categoryID, m.categoryTimezone(category))
+		category.settings = &settings
+	}
+	category.settings.IntakeClosed = closed
+	return *category.settings, nil
+}
+
+func (m *MockStorage) GetCategoryHours(categoryID int) (types.CategoryHours, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, err := m.category(categoryID)
+	if err != nil {
+		return types.CategoryHours{}, err
+	}
+	return types.CategoryHours{CategoryID: categoryID, OpeningSchedule: category.hours}, nil
+}
+
+func (m *MockStorage) SetCategoryHours(hours types.CategoryHours) (types.CategoryHours, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, err := m.category(hours.CategoryID)
+	if err != nil {
+		return types.CategoryHours{}, err
+	}
+
+	category.hours = hours.OpeningSchedule
+	return hours, nil
+}
+
+func (m *MockStorage) ApplyConfig(config types.LocationConfig, prune bool, dryRun bool) ([]types.ConfigChange, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (m *MockStorage) ExportConfig() (types.LocationConfig, error) {
+	return types.LocationConfig{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) CreateDesk(label string, categoryID int) (types.Desk, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, err := m.category(categoryID)
+	if err != nil {
+		return types.Desk{}, err
+	}
+
+	desk := &mockDesk{Desk: types.Desk{ID: m.nextID("desk"), CategoryID: categoryID, Label: label, Version: 1}, locationID: category.locationID}
+	m.desks = append(m.desks, desk)
+	return desk.Desk, nil
+}
+
+func (m *MockStorage) GetDesk(id int) (types.Desk, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	desk, err := m.desk(id)
+	if err != nil {
+		return types.Desk{}, err
+	}
+	return desk.Desk, nil
 }
 
-func (s MockStorage) GetDesk(id int) (types.Desk, error) {
-	return types.Desk{
-		ID:         id,
-		CategoryID: 1,
-		Label:      fmt.Sprintf("desk %d", id),
-	}, nil
+func (m *MockStorage) ListDesks() ([]types.Desk, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	desks := []types.Desk{}
+	for _, desk := range m.desks {
+		if m.sees(desk.locationID) {
+			desks = append(desks, desk.Desk)
+		}
+	}
+	return desks, nil
 }
 
-func (s MockStorage) UpdateDesk(id int, deskUpdate struct {
-	CategoryID int
-	Label      string
-}) (types.Desk, error) {
-	if deskUpdate.CategoryID == 0 {
-		deskUpdate.CategoryID = 1
+func (m *MockStorage) UpdateDesk(id int, deskUpdate types.DeskUpdate, version int) (types.Desk, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	desk, err := m.desk(id)
+	if err != nil {
+		return types.Desk{}, err
+	}
+	if err = checkVersion(desk.Version, version); err != nil {
+		return types.Desk{}, err
 	}
 
-	if deskUpdate.Label == "" {
-		deskUpdate.Label = fmt.Sprintf("desk %d", id)
+	if deskUpdate.CategoryID != 0 {
+		if _, err = m.category(deskUpdate.CategoryID); err != nil {
+			return types.Desk{}, err
+		}
+		desk.CategoryID = deskUpdate.CategoryID
+	}
+	if deskUpdate.Label != "" {
+		desk.Label = deskUpdate.Label
 	}
-	return types.Desk{
-		ID:         id,
-		CategoryID: deskUpdate.CategoryID,
-		Label:      deskUpdate.Label,
-	}, nil
+	desk.Version++
+
+	return desk.Desk, nil
 }
 
-func (s MockStorage) DeleteDesk(id int) error {
+func (m *MockStorage) DeleteDesk(id int, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	desk, err := m.desk(id)
+	if err != nil {
+		return err
+	}
+	if err = checkVersion(desk.Version, version); err != nil {
+		return err
+	}
+
+	m.desks = slices.DeleteFunc(m.desks, func(d *mockDesk) bool { return d == desk })
 	return nil
 }
 
-func NewMockStorage() (MockStorage, error) {
-	return MockStorage{}, nil
+func (m *MockStorage) CreateAppointmentSlot(slot types.AppointmentSlot) (types.AppointmentSlot, error) {
+	return types.AppointmentSlot{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) GetAppointmentSlot(id int) (types.AppointmentSlot, error) {
+	return types.AppointmentSlot{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) ListAppointmentSlots(categoryID int, from time.Time, to time.Time) ([]types.AppointmentSlot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (m *MockStorage) DeleteAppointmentSlot(id int) error {
+	return types.ErrNotImplemented
+}
+
+func (m *MockStorage) BookAppointment(slotID int, name string, subURL string) (types.Appointment, error) {
+	return types.Appointment{}, types.ErrNotImplemented
 }
 
-func (s MockStorage) Init() error {
+func (m *MockStorage) GetAppointmentBySubURL(subURL string) (types.Appointment, error) {
+	return types.Appointment{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) CancelAppointment(id int) (types.Appointment, error) {
+	return types.Appointment{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) CheckInAppointment(id int, subURL string, window types.CheckInWindow) (types.Appointment, types.Ticket, error) {
+	return types.Appointment{}, types.Ticket{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) GetDeskSession(deskID int) (types.DeskSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, session := range slices.Backward(m.sessions) {
+		if session.DeskID == deskID && m.sees(session.locationID) {
+			return session.DeskSession, nil
+		}
+	}
+	return types.DeskSession{}, types.ErrnotFound
+}
+
+func (m *MockStorage) OpenDeskSession(deskID int, staff string, token string) (types.DeskSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	desk, err := m.desk(deskID)
+	if err != nil {
+		return types.DeskSession{}, err
+	}
+	if m.openSession(deskID) != nil {
+		return types.DeskSession{}, types.ErrDeskSessionOpen
+	}
+
+	now := time.Now()
+	session := &mockSession{
+		DeskSession: types.DeskSession{ID: m.nextID("desk_session"), DeskID: deskID, Staff: staff, State: types.DeskOpen, OpenedAt: now, StateChangedAt: now},
+		locationID:  desk.locationID,
+		token:       token,
+	}
+	m.sessions = append(m.sessions, session)
+	return session.DeskSession, nil
+}
+
+func (m *MockStorage) GetDeskSessionByToken(token string) (types.DeskSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, session := range m.sessions {
+		if session.token == token && session.ClosedAt == nil && m.sees(session.locationID) {
+			return session.DeskSession, nil
+		}
+	}
+	return types.DeskSession{}, types.ErrnotFound
+}
+
+func (m *MockStorage) SetDeskSessionState(deskID int, state types.DeskSessionState) (types.DeskSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session := m.openSession(deskID)
+	if session == nil || !m.sees(session.locationID) {
+		return types.DeskSession{}, types.ErrDeskClosed
+	}
+
+	session.State, session.StateChangedAt = state, time.Now()
+	return session.DeskSession, nil
+}
+
+func (m *MockStorage) CloseDeskSession(deskID int) (types.DeskSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session := m.openSession(deskID)
+	if session == nil || !m.sees(session.locationID) {
+		return types.DeskSession{}, types.ErrDeskClosed
+	}
+
+	now := time.Now()
+	session.State, session.StateChangedAt, session.ClosedAt = types.DeskClosed, now, &now
+	return session.DeskSession, nil
+}
+
+func (m *MockStorage) CreateWebhookSubscription(subscription types.WebhookSubscription) (types.WebhookSubscription, error) {
+	return types.WebhookSubscription{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) GetWebhookSubscription(id int) (types.WebhookSubscription, error) {
+	return types.WebhookSubscription{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) ListWebhookSubscriptions() ([]types.WebhookSubscription, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (m *MockStorage) UpdateWebhookSubscription(subscription types.WebhookSubscription) (types.WebhookSubscription, error) {
+	return types.WebhookSubscription{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) DeleteWebhookSubscription(id int) error {
+	return types.ErrNotImplemented
+}
+
+func (m *MockStorage) ListWebhookDeliveries(subscriptionID int, limit int) ([]types.WebhookDelivery, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (m *MockStorage) GetWebhookDelivery(id int) (types.WebhookDelivery, error) {
+	return types.WebhookDelivery{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) RedeliverWebhook(id int) (types.WebhookDelivery, error) {
+	return types.WebhookDelivery{}, types.ErrNotImplemented
+}
+
+func (m *MockStorage) BeginIdempotentRequest(key string, fingerprint string, window time.Duration) (types.IdempotentRequest, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, found := m.idempotency[key]; found && time.Since(existing.createdAt) < window {
+		if existing.Status != 0 || time.Since(existing.createdAt) < idempotencyLease {
+			return existing.IdempotentRequest, false, nil
+		}
+	}
+
+	request := types.IdempotentRequest{Key: key, Fingerprint: fingerprint}
+	m.idempotency[key] = &mockIdempotentRequest{IdempotentRequest: request, createdAt: time.Now()}
+	return request, true, nil
+}
+
+func (m *MockStorage) CompleteIdempotentRequest(request types.IdempotentRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, found := m.idempotency[request.Key]; found {
+		existing.IdempotentRequest = request
+	}
+	return nil
+}
+
+func (m *MockStorage) AbandonIdempotentRequest(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, found := m.idempotency[key]; found && existing.Status == 0 {
+		delete(m.idempotency, key)
+	}
 	return nil
 }
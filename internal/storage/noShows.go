@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// OverdueTickets returns the called tickets whose customer has not turned up
+// within their category's no-show timeout since they were last called. Tickets
+// being served are left alone. A category whose settings were never saved has
+// the default timeout, as GetCategorySettings reports.
+func (s *PostgresStorage) OverdueTickets() ([]types.Ticket, error) {
+	query := `SELECT ` + prefixColumns("t", ticketColumns) + `
+	FROM ticket t
+	LEFT JOIN category_settings cs ON cs.category_id = t.category_id
+	WHERE t.closed = FALSE
+	  AND t.desk_id IS NOT NULL
+	  AND t.served_at IS NULL
+	  AND t.deleted_at IS NULL
+	  AND COALESCE(cs.no_show_timeout_seconds, $1) > 0
+	  AND t.called_at < NOW() - make_interval(secs => COALESCE(cs.no_show_timeout_seconds, $1))
+	ORDER BY t.called_at`
+
+	defaults := defaultCategorySettings(0, "")
+
+	return s.queryTickets("OverdueTickets", query, defaults.NoShowTimeoutSeconds)
+}
+
+// MarkNoShow records that the customer of a called ticket did not turn up and
+// frees its desk. With requeue the ticket goes back to the end of its queue,
+// otherwise it is closed.
+func (s *PostgresStorage) MarkNoShow(id int, requeue bool, staff string) (types.Ticket, error) {
+	closeQuery := `UPDATE ticket
+	SET closed = TRUE
+	WHERE id = $1
+	RETURNING ` + ticketColumns
+
+	requeueQuery := `UPDATE ticket
+	SET desk_id = NULL, called_at = NULL, served_at = NULL, recall_count = 0, queued_at = NOW()
+	WHERE id = $1
+	RETURNING ` + ticketColumns
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	ticket, err := s.lockTicket(tx, id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+	if ticket.DeskID == -1 {
+		return types.Ticket{}, types.ErrTicketNotCalled
+	}
+	if ticket.ServedAt != nil {
+		return types.Ticket{}, types.ErrTicketServing
+	}
+
+	// The no-show is recorded against the desk before it is freed.
+	if err = insertTicketEvent(tx, id, types.TicketNoShow, staff); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", id, "type", types.TicketNoShow, "error", err)
+		return types.Ticket{}, err
+	}
+
+	if !requeue {
+		ticket, err = scanTicket(tx.QueryRow(closeQuery, id))
+		if err != nil {
+			s.logger.Tracew("error closing no-show ticket", "id", id, "error", err)
+			return types.Ticket{}, err
+		}
+		return ticket, tx.Commit()
+	}
+
+	ticket, err = scanTicket(tx.QueryRow(requeueQuery, id))
+	if err != nil {
+		s.logger.Tracew("error requeueing no-show ticket", "id", id, "error", err)
+		return types.Ticket{}, err
+	}
+
+	if err = insertTicketEvent(tx, id, types.TicketRequeued, staff); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", id, "type", types.TicketRequeued, "error", err)
+		return types.Ticket{}, err
+	}
+
+	// The ticket is at the back of the queue, so its customer is told again
+	// once it comes within reach of the front.
+	if err = resetAheadNotification(tx, id); err != nil {
+		s.logger.Warnw("could not reset ahead notification", "id", id, "error", err)
+		return types.Ticket{}, err
+	}
+
+	if err = enqueueAheadNotifications(tx, ticket.CategoryID); err != nil {
+		s.logger.Warnw("could not enqueue ahead notifications", "category_id", ticket.CategoryID, "error", err)
+		return types.Ticket{}, err
+	}
+
+	return ticket, tx.Commit()
+}
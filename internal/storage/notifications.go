@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+// contactChannels expands a ticket_contact row, aliased c, into one row per
+// channel the customer gave an address for.
+const contactChannels = `CROSS JOIN LATERAL (
+	    VALUES ('email', c.email), ('sms', c.phone), ('webhook', c.webhook_url)
+	  ) AS ch(channel, address)`
+
+// insertTicketContact stores the contact details of a new ticket in the same
+// transaction that creates it.
+func insertTicketContact(tx *sql.Tx, ticketID int, contact types.TicketContact) error {
+	query := `INSERT INTO ticket_contact (ticket_id, email, phone, webhook_url, notify_ahead)
+	VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), $5)`
+
+	_, err := tx.Exec(query, ticketID, contact.Email, contact.Phone, contact.WebhookURL, contact.NotifyAhead)
+	return err
+}
+
+// enqueueCalledNotifications adds a message to the outbox for each channel the
+// customer of a ticket opted in to, telling them the desk it was called to.
+func enqueueCalledNotifications(tx *sql.Tx, ticketID int) error {
+	query := `INSERT INTO notification_outbox (ticket_id, channel, address, kind, desk_label)
+	SELECT c.ticket_id, ch.channel, ch.address, $2, d.label
+	FROM ticket_contact c
+	JOIN ticket t ON t.id = c.ticket_id
+	JOIN desk d ON d.id = t.desk_id
+	` + contactChannels + `
+	WHERE c.ticket_id = $1
+	  AND ch.address IS NOT NULL`
+
+	_, err := tx.Exec(query, ticketID, types.NotifyCalled)
+	return err
+}
+
+// enqueueAheadNotifications adds a message to the outbox for the waiting
+// tickets of a category that have come within the number of places their
+// customer asked to be told about. Each ticket is only told once.
+func enqueueAheadNotifications(tx *sql.Tx, categoryID int) error {
+	query := `WITH queue AS (
+	    SELECT id, ROW_NUMBER() OVER (ORDER BY queued_at, id) - 1 AS ahead
+	    FROM ticket
+	    WHERE category_id = $1 AND ` + waitingTicket + `
+	  ), due AS (
+	    UPDATE ticket_contact c
+	    SET ahead_notified_at = NOW()
+	    FROM queue q
+	    WHERE c.ticket_id = q.id
+	      AND c.ahead_notified_at IS NULL
+	      AND c.notify_ahead > 0
+	      AND q.ahead <= c.notify_ahead
+	    RETURNING c.ticket_id, c.email, c.phone, c.webhook_url, q.ahead
+	  )
+	INSERT INTO notification_outbox (ticket_id, channel, address, kind, places_ahead)
+	SELECT c.ticket_id, ch.channel, ch.address, $2, c.ahead
+	FROM due c
+	` + contactChannels + `
+	WHERE ch.address IS NOT NULL`
+
+	_, err := tx.Exec(query, categoryID, types.NotifyAhead)
+	return err
+}
+
+// resetAheadNotification lets the customer of a ticket that has gone back
+// into a queue be told again when it comes within reach of the front.
+func resetAheadNotification(tx *sql.Tx, ticketID int) error {
+	_, err := tx.Exec("UPDATE ticket_contact SET ahead_notified_at = NULL WHERE ticket_id = $1", ticketID)
+	return err
+}
+
+// ClaimNotifications returns up to limit notifications that are due to be
+// sent and counts the attempt. They are not due again until lease has passed,
+// so a notification whose sender dies is retried rather than lost. Telling a
+// customer how many places are ahead of them is given up on once their ticket
+// is no longer waiting.
+func (s *PostgresStorage) ClaimNotifications(limit int, lease time.Duration) ([]types.Notification, error) {
+	query := `WITH stale AS (
+	    UPDATE notification_outbox
+	    SET failed_at = NOW(), last_error = 'the ticket is no longer waiting'
+	    WHERE kind = $3
+	      AND sent_at IS NULL
+	      AND failed_at IS NULL
+	      AND NOT EXISTS (SELECT 1 FROM ticket t WHERE t.id = notification_outbox.ticket_id AND ` + waitingTicket + `)
+	  )
+	UPDATE notification_outbox o
+	SET attempts = o.attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2)
+	FROM ticket t
+	JOIN category cat ON cat.id = t.category_id
+	WHERE o.id IN (
+	    SELECT id
+	    FROM notification_outbox n
+	    WHERE sent_at IS NULL
+	      AND failed_at IS NULL
+	      AND next_attempt_at <= NOW()
+	      AND (kind <> $3 OR EXISTS (SELECT 1 FROM ticket t WHERE t.id = n.ticket_id AND ` + waitingTicket + `))
+	    ORDER BY next_attempt_at, id
+	    LIMIT $1
+	    FOR UPDATE SKIP LOCKED
+	  )
+	  AND t.id = o.ticket_id
+	RETURNING o.id, o.ticket_id, cat.name, o.channel, o.address, o.kind, COALESCE(o.places_ahead, 0), COALESCE(o.desk_label, ''), o.attempts, o.created_at`
+
+	rows, err := s.db.Query(query, limit, lease.Seconds(), types.NotifyAhead)
+	if err != nil {
+		s.logger.Warnw("error with ClaimNotifications", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []types.Notification
+
+	for rows.Next() {
+		var notification types.Notification
+		var categoryName string
+
+		if err = rows.Scan(&notification.ID, &notification.TicketID, &categoryName, &notification.Channel, &notification.Address, &notification.Kind, &notification.PlacesAhead, &notification.DeskLabel, &notification.Attempts, &notification.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		notification.DisplayNumber = types.Ticket{ID: notification.TicketID}.DisplayNumber(types.Category{Name: categoryName})
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (s *PostgresStorage) MarkNotificationSent(id int) error {
+	result, err := s.db.Exec("UPDATE notification_outbox SET sent_at = NOW(), last_error = NULL WHERE id = $1", id)
+	if err != nil {
+		s.logger.Tracew("error marking notification as sent", "id", id, "error", err)
+		return err
+	}
+
+	return checkSingleRowAffected(result, id, "MarkNotificationSent", s.logger)
+}
+
+// MarkNotificationFailed records why sending a notification failed. It is
+// retried once retryAfter has passed, or given up on when retryAfter is zero.
+func (s *PostgresStorage) MarkNotificationFailed(id int, reason string, retryAfter time.Duration) error {
+	query := `UPDATE notification_outbox
+	SET last_error = $2,
+	  next_attempt_at = NOW() + make_interval(secs => $3),
+	  failed_at = CASE WHEN $3 = 0 THEN NOW() END
+	WHERE id = $1`
+
+	result, err := s.db.Exec(query, id, reason, retryAfter.Seconds())
+	if err != nil {
+		s.logger.Tracew("error marking notification as failed", "id", id, "error", err)
+		return err
+	}
+
+	return checkSingleRowAffected(result, id, "MarkNotificationFailed", s.logger)
+}
+
+func (s *PostgresStorage) createNotificationTables() error {
+	query := `CREATE TABLE IF NOT EXISTS ticket_contact(
+	ticket_id INT PRIMARY KEY REFERENCES ticket(id),
+	email VARCHAR(254),
+	phone VARCHAR(20),
+	webhook_url VARCHAR(2048),
+	notify_ahead INT NOT NULL DEFAULT 0 CHECK (notify_ahead >= 0),
+	ahead_notified_at TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS notification_outbox(
+	id BIGSERIAL PRIMARY KEY,
+	ticket_id INT NOT NULL REFERENCES ticket(id),
+	channel VARCHAR(20) NOT NULL,
+	address VARCHAR(2048) NOT NULL,
+	kind VARCHAR(20) NOT NULL,
+	places_ahead INT,
+	desk_label VARCHAR(50),
+	attempts INT NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	last_error TEXT,
+	sent_at TIMESTAMP,
+	failed_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS notification_outbox_due_idx ON notification_outbox(next_attempt_at)
+	WHERE sent_at IS NULL AND failed_at IS NULL;`
+
+	_, err := s.db.Exec(query)
+	return err
+}
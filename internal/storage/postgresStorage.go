@@ -4,121 +4,496 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/khaleelsyed/codaVirtuale/internal/types"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type PostgresStorage struct {
-	db     *sql.DB
-	logger *types.SugarWithTrace
-}
-
-func (s *PostgresStorage) CallNextTicket(deskID int) (types.Ticket, error) {
-	query := `UPDATE ticket t
-	SET desk_id = $1
-	FROM desk d
-	WHERE d.id = $1
-	  AND t.category_id = d.category_id
-	  AND t.closed = FALSE
-	  AND t.desk_id IS NULL
-	  AND t.id = (
-	    SELECT id
-	    FROM ticket
-	    WHERE category_id = d.category_id
-	      AND closed = FALSE
-	      AND desk_id IS NULL
-	    ORDER BY created_at
+	db      *sql.DB
+	connStr string
+	logger  *types.SugarWithTrace
+	// locationID is the location the storage is scoped to, or zero when it
+	// sees every location.
+	locationID int
+}
+
+func (s *PostgresStorage) CallNextTicket(deskID int, staff string) (types.Ticket, error) {
+	query := `UPDATE ticket
+	SET desk_id = $1, called_at = NOW(), served_at = NULL, recall_count = 0
+	WHERE id = (
+	    SELECT t.id
+	    FROM ticket t
+	    JOIN desk d ON d.category_id = t.category_id
+	    WHERE d.id = $1
+	      AND ` + locationFilter("d.location_id", 2) + `
+	      AND t.location_id = d.location_id
+	      AND t.closed = FALSE
+	      AND t.desk_id IS NULL
+	      AND t.deleted_at IS NULL
+	    ORDER BY t.queued_at, t.id
 	    LIMIT 1
+	    FOR UPDATE OF t SKIP LOCKED
 	  )
-	RETURNING t.id, t.category_id, t.sub_url, t.desk_id, t.closed, t.created_at;`
+	RETURNING ` + ticketColumns
 
-	result, err := s.db.Exec(query, deskID)
+	tx, err := s.db.Begin()
 	if err != nil {
-		s.logger.Warnw("error with CallNextTicket", "desk_id", deskID, "error", err)
+		return types.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	if err = checkDeskOpen(tx, deskID); err != nil {
+		return types.Ticket{}, err
 	}
 
-	if err = checkSingleRowAffected(result, deskID, "CallNextTicket", s.logger); err != nil {
-		if err == ErrNoRowsAffected {
+	ticket, err := scanTicket(tx.QueryRow(query, deskID, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
 			return types.Ticket{}, types.ErrnotFound
 		}
+		s.logger.Warnw("error with CallNextTicket", "desk_id", deskID, "error", err)
+		return types.Ticket{}, err
+	}
 
+	if err = insertTicketEvent(tx, ticket.ID, types.TicketCalled, staff); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", ticket.ID, "type", types.TicketCalled, "error", err)
 		return types.Ticket{}, err
 	}
 
-	result.RowsAffected()
+	if err = enqueueCalledNotifications(tx, ticket.ID); err != nil {
+		s.logger.Warnw("could not enqueue called notifications", "id", ticket.ID, "error", err)
+		return types.Ticket{}, err
+	}
 
-	return types.Ticket{}, types.ErrNotImplemented
+	if err = enqueueAheadNotifications(tx, ticket.CategoryID); err != nil {
+		s.logger.Warnw("could not enqueue ahead notifications", "category_id", ticket.CategoryID, "error", err)
+		return types.Ticket{}, err
+	}
+
+	return ticket, tx.Commit()
 }
 
 func (s *PostgresStorage) SeeNext(categoryID int) (types.Ticket, error) {
+	query := `SELECT ` + ticketColumns + `
+	FROM ticket
+	WHERE category_id = $1 AND ` + locationFilter("location_id", 2) + ` AND ` + waitingTicket + `
+	ORDER BY queued_at, id
+	LIMIT 1`
+
+	ticket, err := scanTicket(s.db.QueryRow(query, categoryID, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Ticket{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with SeeNext", "category_id", categoryID, "error", err)
+		return types.Ticket{}, err
+	}
 
-	return types.Ticket{}, types.ErrNotImplemented
+	return ticket, nil
 }
 
-func (s *PostgresStorage) SeeQueue() ([]int, error) {
-	return []int{}, types.ErrNotImplemented
+// SeeQueue returns the waiting tickets in the order they will be called,
+// optionally only from the given categories.
+func (s *PostgresStorage) SeeQueue(categoryIDs []int) ([]types.Ticket, error) {
+	query := `SELECT ` + ticketColumns + `
+	FROM ticket
+	WHERE ` + waitingTicket + `
+	  AND (COALESCE(cardinality($1::INT[]), 0) = 0 OR category_id = ANY($1))
+	  AND ` + locationFilter("location_id", 2) + `
+	ORDER BY queued_at, id`
+
+	return s.queryTickets("SeeQueue", query, pq.Array(categoryIDs), s.locationID)
+}
+
+// DeskTickets returns the open tickets that have been called to a desk.
+func (s *PostgresStorage) DeskTickets(deskID int) ([]types.Ticket, error) {
+	query := `SELECT ` + ticketColumns + `
+	FROM ticket
+	WHERE desk_id = $1 AND closed = FALSE AND deleted_at IS NULL AND ` + locationFilter("location_id", 2) + `
+	ORDER BY called_at DESC`
+
+	return s.queryTickets("DeskTickets", query, deskID, s.locationID)
+}
+
+func (s *PostgresStorage) queryTickets(operation string, query string, args ...any) ([]types.Ticket, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		s.logger.Warnw(fmt.Sprintf("error with %s", operation), "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := []types.Ticket{}
+
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, rows.Err()
 }
 
 func (s *PostgresStorage) CreateTicket(ticketCreate types.TicketCreate) (types.Ticket, error) {
-	result, err := s.db.Query("INSERT INTO ticket (category_id, sub_url) VALUES ($1, $2) RETURNING id, category_id, sub_url, closed, created_at", ticketCreate.CategoryID, ticketCreate.SubURL)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	if err = checkCapacity(tx, ticketCreate.CategoryID); err != nil {
+		return types.Ticket{}, err
+	}
+
+	query := `INSERT INTO ticket (category_id, sub_url, location_id)
+	SELECT id, $2, location_id
+	FROM category
+	WHERE id = $1 AND ` + locationFilter("location_id", 3) + `
+	RETURNING ` + ticketColumns
+
+	ticket, err := scanTicket(tx.QueryRow(query, ticketCreate.CategoryID, ticketCreate.SubURL, s.locationID))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Ticket{}, types.ErrnotFound
+		}
 		s.logger.Warnw("could not create ticket", "error", err)
 		return types.Ticket{}, err
 	}
-	defer result.Close()
 
-	var ticket types.Ticket
+	if err = insertTicketEvent(tx, ticket.ID, types.TicketCreated, ""); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", ticket.ID, "type", types.TicketCreated, "error", err)
+		return types.Ticket{}, err
+	}
 
-	for result.Next() {
-		if err = result.Scan(&ticket.ID, &ticket.CategoryID, &ticket.SubURL, &ticket.Closed, &ticket.CreatedAt); err != nil {
+	if ticketCreate.Contact != nil {
+		if err = insertTicketContact(tx, ticket.ID, *ticketCreate.Contact); err != nil {
+			s.logger.Warnw("could not store ticket contact", "id", ticket.ID, "error", err)
 			return types.Ticket{}, err
 		}
-		ticket.DeskID = -1
 	}
 
-	return ticket, nil
+	// A ticket joining a short queue may already be within reach of the front.
+	if err = enqueueAheadNotifications(tx, ticket.CategoryID); err != nil {
+		s.logger.Warnw("could not enqueue ahead notifications", "category_id", ticket.CategoryID, "error", err)
+		return types.Ticket{}, err
+	}
+
+	return ticket, tx.Commit()
 }
 
 func (s *PostgresStorage) GetTicket(id int) (types.Ticket, error) {
-	result, err := s.db.Query("SELECT id, category_id, sub_url, desk_id, closed, created_at FROM ticket WHERE id = $1", id)
+	query := "SELECT " + ticketColumns + " FROM ticket WHERE id = $1 AND deleted_at IS NULL AND " + locationFilter("location_id", 2)
+
+	ticket, err := scanTicket(s.db.QueryRow(query, id, s.locationID))
 	if err != nil {
-		s.logger.Warnw("error with GetCategory", "error", err)
+		if err == sql.ErrNoRows {
+			return types.Ticket{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with GetTicket", "id", id, "error", err)
+		return types.Ticket{}, err
 	}
-	defer result.Close()
 
-	var ticket types.Ticket
+	return ticket, nil
+}
 
-	if result.Next() {
-		err = result.Scan(&ticket.ID, &ticket.CategoryID, &ticket.SubURL, &ticket.DeskID, &ticket.Closed, &ticket.CreatedAt)
-		if err != nil {
-			if err.Error() == errDeskNull.Error() {
-				ticket.DeskID = -1
-				return ticket, nil
-			}
+// DeleteTicket soft deletes a ticket so that its history is kept. A deleted
+// ticket is also closed, so it no longer blocks its category being removed.
+func (s *PostgresStorage) DeleteTicket(id int, staff string) error {
+	query := `UPDATE ticket
+	SET closed = TRUE, deleted_at = NOW()
+	WHERE id = $1 AND deleted_at IS NULL AND ` + locationFilter("location_id", 2)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, id, s.locationID)
+	if err != nil {
+		s.logger.Tracew("error deleting ticket", "id", id, "error", err)
+		return err
+	}
+
+	if err = checkSingleRowAffected(result, id, "DeleteTicket", s.logger); err != nil {
+		return err
+	}
+
+	if err = insertTicketEvent(tx, id, types.TicketDeleted, staff); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", id, "type", types.TicketDeleted, "error", err)
+		return err
+	}
+
+	var categoryID int
+	if err = tx.QueryRow("SELECT category_id FROM ticket WHERE id = $1", id).Scan(&categoryID); err != nil {
+		return err
+	}
+
+	if err = enqueueAheadNotifications(tx, categoryID); err != nil {
+		s.logger.Warnw("could not enqueue ahead notifications", "category_id", categoryID, "error", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStorage) RecallTicket(id int, staff string) (types.Ticket, error) {
+	query := `UPDATE ticket
+	SET called_at = NOW(), recall_count = recall_count + 1
+	WHERE id = $1
+	RETURNING ` + ticketColumns
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	ticket, err := s.lockTicket(tx, id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+	if ticket.DeskID == -1 {
+		return types.Ticket{}, types.ErrTicketNotCalled
+	}
+	if ticket.ServedAt != nil {
+		return types.Ticket{}, types.ErrTicketServing
+	}
+
+	ticket, err = scanTicket(tx.QueryRow(query, id))
+	if err != nil {
+		s.logger.Tracew("error recalling ticket", "id", id, "error", err)
+		return types.Ticket{}, err
+	}
+
+	if err = insertTicketEvent(tx, id, types.TicketRecalled, staff); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", id, "type", types.TicketRecalled, "error", err)
+		return types.Ticket{}, err
+	}
+
+	if err = enqueueCalledNotifications(tx, id); err != nil {
+		s.logger.Warnw("could not enqueue called notifications", "id", id, "error", err)
+		return types.Ticket{}, err
+	}
+
+	return ticket, tx.Commit()
+}
+
+// ServeTicket records that the customer of a called ticket has turned up at
+// its desk, which stops the no-show worker from recalling it.
+func (s *PostgresStorage) ServeTicket(id int, staff string) (types.Ticket, error) {
+	query := `UPDATE ticket
+	SET served_at = NOW()
+	WHERE id = $1
+	RETURNING ` + ticketColumns
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	ticket, err := s.lockTicket(tx, id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+	if ticket.DeskID == -1 {
+		return types.Ticket{}, types.ErrTicketNotCalled
+	}
+	if ticket.ServedAt != nil {
+		return types.Ticket{}, types.ErrTicketServing
+	}
+
+	ticket, err = scanTicket(tx.QueryRow(query, id))
+	if err != nil {
+		s.logger.Tracew("error serving ticket", "id", id, "error", err)
+		return types.Ticket{}, err
+	}
+
+	if err = insertTicketEvent(tx, id, types.TicketServing, staff); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", id, "type", types.TicketServing, "error", err)
+		return types.Ticket{}, err
+	}
+
+	return ticket, tx.Commit()
+}
+
+// TransferTicket moves a ticket to another category's queue. The ticket is
+// released from its desk but keeps the place given by its queued_at.
+func (s *PostgresStorage) TransferTicket(id int, categoryID int, staff string) (types.Ticket, error) {
+	query := `UPDATE ticket
+	SET category_id = $2, desk_id = NULL, called_at = NULL, served_at = NULL, recall_count = 0
+	WHERE id = $1
+	RETURNING ` + ticketColumns
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	transferredFrom, err := s.lockTicket(tx, id)
+	if err != nil {
+		return types.Ticket{}, err
+	}
+
+	if transferredFrom.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+
+	ticket, err := scanTicket(tx.QueryRow(query, id, categoryID))
+	if err != nil {
+		s.logger.Tracew("error transferring ticket", "id", id, "category_id", categoryID, "error", err)
+		return types.Ticket{}, err
+	}
+
+	// The transfer takes the ticket off its desk, which is still recorded as
+	// the one that made it. A ticket that was never called is recorded
+	// without a desk, as the ticket now has none.
+	fromDeskID := max(transferredFrom.DeskID, 0)
+	if err = insertTicketEventAtDesk(tx, id, types.TicketTransferred, staff, fromDeskID); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", id, "type", types.TicketTransferred, "error", err)
+		return types.Ticket{}, err
+	}
+
+	// The ticket leaves a place in its old queue and takes one in its new
+	// queue, where it may be further from the front.
+	if err = resetAheadNotification(tx, id); err != nil {
+		s.logger.Warnw("could not reset ahead notification", "id", id, "error", err)
+		return types.Ticket{}, err
+	}
+	for _, category := range []int{transferredFrom.CategoryID, categoryID} {
+		if err = enqueueAheadNotifications(tx, category); err != nil {
+			s.logger.Warnw("could not enqueue ahead notifications", "category_id", category, "error", err)
 			return types.Ticket{}, err
 		}
-		return ticket, nil
 	}
 
-	return types.Ticket{}, types.ErrnotFound
+	return ticket, tx.Commit()
 }
 
-func (s *PostgresStorage) DeleteTicket(id int) error {
-	query := `DELETE FROM ticket
-	WHERE id = $1;`
+func (s *PostgresStorage) CloseTicket(id int, staff string) (types.Ticket, error) {
+	query := `UPDATE ticket
+	SET closed = TRUE
+	WHERE id = $1
+	RETURNING ` + ticketColumns
 
-	result, err := s.db.Exec(query, id)
+	tx, err := s.db.Begin()
 	if err != nil {
-		s.logger.Tracew("error deleting ticket", "id", id, "error", err)
-		return err
+		return types.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	ticket, err := s.lockTicket(tx, id)
+	if err != nil {
+		return types.Ticket{}, err
 	}
 
-	return checkSingleRowAffected(result, id, "DeleteTicket", s.logger)
+	if ticket.Closed {
+		return types.Ticket{}, types.ErrTicketClosed
+	}
+
+	ticket, err = scanTicket(tx.QueryRow(query, id))
+	if err != nil {
+		s.logger.Tracew("error closing ticket", "id", id, "error", err)
+		return types.Ticket{}, err
+	}
+
+	if err = insertTicketEvent(tx, id, types.TicketClosed, staff); err != nil {
+		s.logger.Warnw("could not record ticket event", "id", id, "type", types.TicketClosed, "error", err)
+		return types.Ticket{}, err
+	}
+
+	// Staff may close a ticket that was still waiting.
+	if err = enqueueAheadNotifications(tx, ticket.CategoryID); err != nil {
+		s.logger.Warnw("could not enqueue ahead notifications", "category_id", ticket.CategoryID, "error", err)
+		return types.Ticket{}, err
+	}
+
+	return ticket, tx.Commit()
+}
+
+func (s *PostgresStorage) GetTicketHistory(id int) ([]types.TicketEvent, error) {
+	query := `SELECT e.id, e.ticket_id, e.type, e.category_id, e.desk_id, e.staff, e.created_at
+	FROM ticket_event e
+	JOIN ticket t ON t.id = e.ticket_id
+	WHERE e.ticket_id = $1 AND ` + locationFilter("t.location_id", 2) + `
+	ORDER BY e.id`
+
+	rows, err := s.db.Query(query, id, s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with GetTicketHistory", "id", id, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []types.TicketEvent{}
+
+	for rows.Next() {
+		event, err := scanTicketEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, types.ErrnotFound
+	}
+
+	return events, nil
+}
+
+// TicketEventsSince returns up to limit of the events recorded after the
+// event afterID in the given categories, oldest first, so that a live view
+// can catch up on what it missed while disconnected.
+func (s *PostgresStorage) TicketEventsSince(categoryIDs []int, afterID int, limit int) ([]types.TicketEvent, error) {
+	query := `SELECT id, ticket_id, type, category_id, desk_id, staff, created_at
+	FROM ticket_event
+	WHERE id > $1
+	  AND category_id = ANY($2)
+	  AND ` + inLocation("category_id", "category", 4) + `
+	ORDER BY id
+	LIMIT $3`
+
+	rows, err := s.db.Query(query, afterID, pq.Array(categoryIDs), limit, s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with TicketEventsSince", "after_id", afterID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []types.TicketEvent{}
+
+	for rows.Next() {
+		event, err := scanTicketEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
 }
 
 func (s *PostgresStorage) CreateCategory(name string) (types.Category, error) {
-	result, err := s.db.Query("INSERT INTO category (name) VALUES ($1) RETURNING id, name", name)
+	result, err := s.db.Query("INSERT INTO category (name, location_id) VALUES ($1, $2) RETURNING "+categoryColumns, name, s.locationID)
 	if err != nil {
 		s.logger.Warnw("could not create category", "error", err)
 		return types.Category{}, err
@@ -128,7 +503,7 @@ func (s *PostgresStorage) CreateCategory(name string) (types.Category, error) {
 	var category types.Category
 
 	for result.Next() {
-		if err = result.Scan(&category.ID, &category.Name); err != nil {
+		if err = result.Scan(&category.ID, &category.Name, &category.Version); err != nil {
 			return types.Category{}, err
 		}
 	}
@@ -137,7 +512,7 @@ func (s *PostgresStorage) CreateCategory(name string) (types.Category, error) {
 }
 
 func (s *PostgresStorage) GetCategory(id int) (types.Category, error) {
-	result, err := s.db.Query("SELECT * FROM category WHERE id = $1", id)
+	result, err := s.db.Query("SELECT "+categoryColumns+" FROM category WHERE id = $1 AND "+locationFilter("location_id", 2), id, s.locationID)
 	if err != nil {
 		s.logger.Warnw("error with GetCategory", "error", err)
 	}
@@ -146,7 +521,7 @@ func (s *PostgresStorage) GetCategory(id int) (types.Category, error) {
 	var category types.Category
 
 	if result.Next() {
-		if err = result.Scan(&category.ID, &category.Name); err != nil {
+		if err = result.Scan(&category.ID, &category.Name, &category.Version); err != nil {
 			return types.Category{}, err
 		}
 		return category, nil
@@ -155,40 +530,97 @@ func (s *PostgresStorage) GetCategory(id int) (types.Category, error) {
 	return types.Category{}, types.ErrnotFound
 }
 
-func (s *PostgresStorage) UpdateCategory(id int, name string) (types.Category, error) {
-	var err error
+func (s *PostgresStorage) ListCategories() ([]types.Category, error) {
+	rows, err := s.db.Query("SELECT "+categoryColumns+" FROM category WHERE "+locationFilter("location_id", 1)+" ORDER BY id", s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with ListCategories", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []types.Category{}
+
+	for rows.Next() {
+		var category types.Category
+		if err = rows.Scan(&category.ID, &category.Name, &category.Version); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
 
+// UpdateCategory renames a category if it is still at version, or whatever
+// its version when that is zero.
+func (s *PostgresStorage) UpdateCategory(id int, name string, version int) (types.Category, error) {
 	query := `UPDATE category
-	SET name = $1
-	WHERE id = $2;`
+	SET name = $1, version = version + 1
+	WHERE id = $2
+	RETURNING ` + categoryColumns
 
-	result, err := s.db.Exec(query, name, id)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Category{}, err
+	}
+	defer tx.Rollback()
+
+	category, err := s.lockCategory(tx, id)
 	if err != nil {
-		s.logger.Tracew("error updating category", "id", id, "error", err)
 		return types.Category{}, err
 	}
 
-	if err = checkSingleRowAffected(result, id, "UpdateCategory", s.logger); err != nil {
+	if err = checkVersion(category.Version, version); err != nil {
 		return types.Category{}, err
 	}
-	return types.Category{ID: id, Name: name}, nil
+
+	if err = tx.QueryRow(query, name, id).Scan(&category.ID, &category.Name, &category.Version); err != nil {
+		s.logger.Tracew("error updating category", "id", id, "error", err)
+		return types.Category{}, err
+	}
+
+	return category, tx.Commit()
 }
 
-func (s *PostgresStorage) DeleteCategory(id int) error {
-	query := `DELETE FROM category
-	WHERE id = $1;`
+// DeleteCategory deletes a category if it is still at version, or whatever
+// its version when that is zero.
+func (s *PostgresStorage) DeleteCategory(id int, version int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	result, err := s.db.Exec(query, id)
+	category, err := s.lockCategory(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = checkVersion(category.Version, version); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("DELETE FROM category WHERE id = $1", id)
 	if err != nil {
 		s.logger.Tracew("error deleting category", "id", id, "error", err)
 		return err
 	}
 
-	return checkSingleRowAffected(result, id, "DeleteCategory", s.logger)
+	if err = checkSingleRowAffected(result, id, "DeleteCategory", s.logger); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (s *PostgresStorage) CreateDesk(label string, categoryID int) (types.Desk, error) {
-	result, err := s.db.Query("INSERT INTO desk (label, category_id) VALUES ($1, $2) RETURNING id, label, category_id", label, categoryID)
+	query := `INSERT INTO desk (label, category_id, location_id)
+	SELECT $1, id, location_id
+	FROM category
+	WHERE id = $2 AND ` + locationFilter("location_id", 3) + `
+	RETURNING ` + deskColumns
+
+	result, err := s.db.Query(query, label, categoryID, s.locationID)
 	if err != nil {
 		s.logger.Warnw("could not create desk", "error", err)
 		return types.Desk{}, err
@@ -197,17 +629,19 @@ func (s *PostgresStorage) CreateDesk(label string, categoryID int) (types.Desk,
 
 	var desk types.Desk
 
-	for result.Next() {
-		if err = result.Scan(&desk.ID, &desk.Label, &desk.CategoryID); err != nil {
-			return types.Desk{}, err
-		}
+	if !result.Next() {
+		return types.Desk{}, types.ErrnotFound
+	}
+
+	if err = result.Scan(&desk.ID, &desk.CategoryID, &desk.Label, &desk.Version); err != nil {
+		return types.Desk{}, err
 	}
 
 	return desk, nil
 }
 
 func (s *PostgresStorage) GetDesk(id int) (types.Desk, error) {
-	result, err := s.db.Query("SELECT id, category_id, label FROM desk WHERE id = $1", id)
+	result, err := s.db.Query("SELECT "+deskColumns+" FROM desk WHERE id = $1 AND "+locationFilter("location_id", 2), id, s.locationID)
 	if err != nil {
 		s.logger.Warnw("error with GetDesk Query", "error", err)
 	}
@@ -216,7 +650,7 @@ func (s *PostgresStorage) GetDesk(id int) (types.Desk, error) {
 	var desk types.Desk
 
 	if result.Next() {
-		if err = result.Scan(&desk.ID, &desk.CategoryID, &desk.Label); err != nil {
+		if err = result.Scan(&desk.ID, &desk.CategoryID, &desk.Label, &desk.Version); err != nil {
 			s.logger.Tracew("error with GetDesk Scanner", "id", id, "error", err)
 			return types.Desk{}, err
 		}
@@ -226,41 +660,279 @@ func (s *PostgresStorage) GetDesk(id int) (types.Desk, error) {
 	return types.Desk{}, types.ErrnotFound
 }
 
-func (s *PostgresStorage) UpdateDesk(id int, deskUpdate struct {
-	CategoryID int
-	Label      string
-}) (types.Desk, error) {
-	var err error
+func (s *PostgresStorage) ListDesks() ([]types.Desk, error) {
+	rows, err := s.db.Query("SELECT "+deskColumns+" FROM desk WHERE "+locationFilter("location_id", 1)+" ORDER BY id", s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with ListDesks", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
 
+	desks := []types.Desk{}
+
+	for rows.Next() {
+		var desk types.Desk
+		if err = rows.Scan(&desk.ID, &desk.CategoryID, &desk.Label, &desk.Version); err != nil {
+			return nil, err
+		}
+		desks = append(desks, desk)
+	}
+
+	return desks, rows.Err()
+}
+
+// UpdateDesk changes the label or category of a desk, keeping whichever of
+// them is left zero. The desk is read and written in one transaction, and
+// only if it is still at version, or whatever its version when that is zero.
+func (s *PostgresStorage) UpdateDesk(id int, deskUpdate types.DeskUpdate, version int) (types.Desk, error) {
 	query := `UPDATE desk
-	SET category_id = $1, label = $2
-	WHERE id = $3;`
+	SET category_id = $1, label = $2, version = version + 1
+	WHERE id = $3
+	RETURNING ` + deskColumns
 
-	result, err := s.db.Exec(query, deskUpdate.CategoryID, deskUpdate.Label, id)
+	tx, err := s.db.Begin()
 	if err != nil {
-		s.logger.Tracew("error updating desk", "id", id, "category_id", deskUpdate.CategoryID, "error", err)
 		return types.Desk{}, err
 	}
+	defer tx.Rollback()
 
-	if err = checkSingleRowAffected(result, id, "UpdateDesk", s.logger); err != nil {
+	desk, err := s.lockDesk(tx, id)
+	if err != nil {
 		return types.Desk{}, err
 	}
 
-	return types.Desk{ID: id, Label: deskUpdate.Label, CategoryID: deskUpdate.CategoryID}, nil
+	if err = checkVersion(desk.Version, version); err != nil {
+		return types.Desk{}, err
+	}
+
+	if deskUpdate.Label == "" {
+		deskUpdate.Label = desk.Label
+	}
+	if deskUpdate.CategoryID == 0 {
+		deskUpdate.CategoryID = desk.CategoryID
+	} else if deskUpdate.CategoryID != desk.CategoryID {
+		if err = checkDeskCategory(tx, id, deskUpdate.CategoryID); err != nil {
+			return types.Desk{}, err
+		}
+	}
+
+	if err = tx.QueryRow(query, deskUpdate.CategoryID, deskUpdate.Label, id).Scan(&desk.ID, &desk.CategoryID, &desk.Label, &desk.Version); err != nil {
+		s.logger.Tracew("error updating desk", "id", id, "category_id", deskUpdate.CategoryID, "error", err)
+		return types.Desk{}, err
+	}
 
+	return desk, tx.Commit()
 }
 
-func (s *PostgresStorage) DeleteDesk(id int) error {
-	query := `DELETE FROM desk
-	WHERE id = $1;`
+// DeleteDesk deletes a desk if it is still at version, or whatever its
+// version when that is zero.
+func (s *PostgresStorage) DeleteDesk(id int, version int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	desk, err := s.lockDesk(tx, id)
+	if err != nil {
+		return err
+	}
 
-	result, err := s.db.Exec(query, id)
+	if err = checkVersion(desk.Version, version); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("DELETE FROM desk WHERE id = $1", id)
 	if err != nil {
 		s.logger.Tracew("error deleting desk", "id", id, "error", err)
 		return err
 	}
 
-	return checkSingleRowAffected(result, id, "DeleteDesk", s.logger)
+	if err = checkSingleRowAffected(result, id, "DeleteDesk", s.logger); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const (
+	categoryColumns = "id, name, version"
+	deskColumns     = "id, category_id, label, version"
+)
+
+// lockCategory reads a category of the location and locks it until the end
+// of the transaction.
+func (s *PostgresStorage) lockCategory(tx *sql.Tx, id int) (types.Category, error) {
+	query := "SELECT " + categoryColumns + " FROM category WHERE id = $1 AND " + locationFilter("location_id", 2) + " FOR UPDATE"
+
+	var category types.Category
+	if err := tx.QueryRow(query, id, s.locationID).Scan(&category.ID, &category.Name, &category.Version); err != nil {
+		if err == sql.ErrNoRows {
+			return types.Category{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error locking category", "id", id, "error", err)
+		return types.Category{}, err
+	}
+
+	return category, nil
+}
+
+// lockDesk reads a desk of the location and locks it until the end of the
+// transaction.
+func (s *PostgresStorage) lockDesk(tx *sql.Tx, id int) (types.Desk, error) {
+	query := "SELECT " + deskColumns + " FROM desk WHERE id = $1 AND " + locationFilter("location_id", 2) + " FOR UPDATE"
+
+	var desk types.Desk
+	if err := tx.QueryRow(query, id, s.locationID).Scan(&desk.ID, &desk.CategoryID, &desk.Label, &desk.Version); err != nil {
+		if err == sql.ErrNoRows {
+			return types.Desk{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error locking desk", "id", id, "error", err)
+		return types.Desk{}, err
+	}
+
+	return desk, nil
+}
+
+// checkDeskCategory checks that a desk may be moved to a category, which
+// must be in the same location as the desk.
+func checkDeskCategory(tx *sql.Tx, deskID int, categoryID int) error {
+	query := `SELECT EXISTS (
+	    SELECT 1
+	    FROM category c
+	    JOIN desk d ON d.location_id = c.location_id
+	    WHERE c.id = $1 AND d.id = $2
+	  )`
+
+	var exists bool
+	if err := tx.QueryRow(query, categoryID, deskID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("category %w", types.ErrnotFound)
+	}
+	return nil
+}
+
+// checkVersion compares the version of a row with the one a change was
+// based on, which is zero when the change applies whatever the version.
+func checkVersion(current int, expected int) error {
+	if expected != 0 && current != expected {
+		return types.ErrVersionMismatch
+	}
+	return nil
+}
+
+const ticketColumns = "id, category_id, sub_url, desk_id, closed, created_at, queued_at, snooze_count, called_at, served_at, recall_count, priority"
+
+// prefixColumns qualifies each of a comma separated list of columns with a
+// table alias.
+func prefixColumns(alias string, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, column := range parts {
+		parts[i] = alias + "." + column
+	}
+	return strings.Join(parts, ", ")
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// queryer runs queries either directly on the database or within a
+// transaction.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// scanTicket reads a row selected with ticketColumns. A ticket that has not
+// been assigned a desk is given a DeskID of -1.
+func scanTicket(row rowScanner) (types.Ticket, error) {
+	var ticket types.Ticket
+	var deskID sql.NullInt64
+	var calledAt, servedAt sql.NullTime
+
+	if err := row.Scan(&ticket.ID, &ticket.CategoryID, &ticket.SubURL, &deskID, &ticket.Closed, &ticket.CreatedAt, &ticket.QueuedAt, &ticket.Snoozes, &calledAt, &servedAt, &ticket.Recalls, &ticket.Priority); err != nil {
+		return types.Ticket{}, err
+	}
+
+	ticket.DeskID = -1
+	if deskID.Valid {
+		ticket.DeskID = int(deskID.Int64)
+	}
+	if calledAt.Valid {
+		ticket.CalledAt = &calledAt.Time
+	}
+	if servedAt.Valid {
+		ticket.ServedAt = &servedAt.Time
+	}
+
+	return ticket, nil
+}
+
+func scanTicketEvent(row rowScanner) (types.TicketEvent, error) {
+	var event types.TicketEvent
+	var deskID sql.NullInt64
+	var staff sql.NullString
+
+	if err := row.Scan(&event.ID, &event.TicketID, &event.Type, &event.CategoryID, &deskID, &staff, &event.CreatedAt); err != nil {
+		return types.TicketEvent{}, err
+	}
+
+	event.DeskID = -1
+	if deskID.Valid {
+		event.DeskID = int(deskID.Int64)
+	}
+	event.Staff = staff.String
+
+	return event, nil
+}
+
+// lockTicket selects a live ticket of the storage's location FOR UPDATE so
+// that the caller can check its state before mutating it within the same
+// transaction.
+func (s *PostgresStorage) lockTicket(tx *sql.Tx, id int) (types.Ticket, error) {
+	query := "SELECT " + ticketColumns + " FROM ticket WHERE id = $1 AND deleted_at IS NULL AND " + locationFilter("location_id", 2) + " FOR UPDATE"
+
+	ticket, err := scanTicket(tx.QueryRow(query, id, s.locationID))
+	if err == sql.ErrNoRows {
+		return types.Ticket{}, types.ErrnotFound
+	}
+	return ticket, err
+}
+
+// insertTicketEvent appends to the ticket history, taking the category and desk
+// from the ticket's current row, and queues the event for the webhooks that
+// subscribe to it. It must run in the same transaction as the mutation it
+// records.
+func insertTicketEvent(tx *sql.Tx, ticketID int, eventType types.TicketEventType, staff string) error {
+	return insertTicketEventAtDesk(tx, ticketID, eventType, staff, 0)
+}
+
+// insertTicketEventAtDesk records an event against the given desk instead of
+// the ticket's current one, or against the ticket's own when deskID is zero.
+func insertTicketEventAtDesk(tx *sql.Tx, ticketID int, eventType types.TicketEventType, staff string, deskID int) error {
+	query := `INSERT INTO ticket_event (ticket_id, type, category_id, desk_id, staff)
+	SELECT id, $2, category_id, COALESCE(NULLIF($4::INT, 0), desk_id), NULLIF($3, '')
+	FROM ticket
+	WHERE id = $1
+	RETURNING id, ticket_id, type, category_id, desk_id, staff, created_at`
+
+	event, err := scanTicketEvent(tx.QueryRow(query, ticketID, eventType, staff, deskID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.ErrnotFound
+		}
+		return err
+	}
+
+	var locationID int
+	if err = tx.QueryRow("SELECT location_id FROM ticket WHERE id = $1", ticketID).Scan(&locationID); err != nil {
+		return err
+	}
+
+	return enqueueWebhookDeliveries(tx, locationID, eventType.WebhookEvent(), event)
 }
 
 func checkSingleRowAffected(result sql.Result, id int, operation string, logger *types.SugarWithTrace) error {
@@ -288,7 +960,7 @@ func checkSingleRowAffected(result sql.Result, id int, operation string, logger
 func (s *PostgresStorage) createCategoryTable() error {
 	query := `CREATE TABLE IF NOT EXISTS category(
 	id SERIAL PRIMARY KEY,
-	name VARCHAR(50) NOT NULL UNIQUE
+	name VARCHAR(50) NOT NULL
 	)`
 
 	_, err := s.db.Exec(query)
@@ -320,6 +992,78 @@ func (s *PostgresStorage) createTicketTable() error {
 	return err
 }
 
+func (s *PostgresStorage) alterTicketTableSoftDelete() error {
+	query := `ALTER TABLE ticket ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`
+
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *PostgresStorage) alterTicketTableQueuedAt() error {
+	query := `ALTER TABLE ticket ADD COLUMN IF NOT EXISTS queued_at TIMESTAMP;
+	UPDATE ticket SET queued_at = created_at WHERE queued_at IS NULL;
+	ALTER TABLE ticket ALTER COLUMN queued_at SET DEFAULT NOW();
+	ALTER TABLE ticket ALTER COLUMN queued_at SET NOT NULL;
+	ALTER TABLE ticket ADD COLUMN IF NOT EXISTS snooze_count INT NOT NULL DEFAULT 0;`
+
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *PostgresStorage) alterTicketTableCalledAt() error {
+	query := `ALTER TABLE ticket ADD COLUMN IF NOT EXISTS called_at TIMESTAMP;
+	ALTER TABLE ticket ADD COLUMN IF NOT EXISTS recall_count INT NOT NULL DEFAULT 0;
+	ALTER TABLE ticket ADD COLUMN IF NOT EXISTS served_at TIMESTAMP;`
+
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *PostgresStorage) alterTicketTablePriority() error {
+	query := `ALTER TABLE ticket ADD COLUMN IF NOT EXISTS priority BOOLEAN NOT NULL DEFAULT FALSE`
+
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *PostgresStorage) alterTablesVersion() error {
+	query := `ALTER TABLE category ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+	ALTER TABLE desk ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;`
+
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *PostgresStorage) createTicketEventTable() error {
+	query := `CREATE TABLE IF NOT EXISTS ticket_event(
+	id BIGSERIAL PRIMARY KEY,
+	ticket_id INT NOT NULL REFERENCES ticket(id),
+	type VARCHAR(20) NOT NULL,
+	category_id INT NOT NULL,
+	desk_id INT,
+	staff VARCHAR(50),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS ticket_event_ticket_id_idx ON ticket_event(ticket_id);
+	CREATE INDEX IF NOT EXISTS ticket_event_created_at_idx ON ticket_event(created_at);
+
+	CREATE OR REPLACE FUNCTION prevent_ticket_event_modification()
+	RETURNS trigger AS $$
+	BEGIN
+	  RAISE EXCEPTION 'ticket_event is append-only';
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE OR REPLACE TRIGGER trg_prevent_ticket_event_modification
+	BEFORE UPDATE OR DELETE ON ticket_event
+	FOR EACH ROW
+	EXECUTE FUNCTION prevent_ticket_event_modification();`
+
+	_, err := s.db.Exec(query)
+	return err
+}
+
 func (s *PostgresStorage) createPreventCategoryDeleteOnOpenTickets() error {
 	query := `CREATE OR REPLACE FUNCTION prevent_category_delete_on_open_tickets()
 	RETURNS trigger AS $$
@@ -350,6 +1094,11 @@ func (s *PostgresStorage) createPreventCategoryDeleteOnOpenTickets() error {
 func (s *PostgresStorage) Init() error {
 	var err error
 
+	if err = s.createLocationTables(); err != nil {
+		s.logger.Errorw("unable to create `location` tables", "error", err)
+		return err
+	}
+
 	if err = s.createCategoryTable(); err != nil {
 		s.logger.Errorw("unable to create `category` table", "error", err)
 		return err
@@ -365,6 +1114,91 @@ func (s *PostgresStorage) Init() error {
 		return err
 	}
 
+	if err = s.alterTicketTableSoftDelete(); err != nil {
+		s.logger.Errorw("unable to add soft delete to `ticket` table", "error", err)
+		return err
+	}
+
+	if err = s.alterTicketTableQueuedAt(); err != nil {
+		s.logger.Errorw("unable to add queue ordering to `ticket` table", "error", err)
+		return err
+	}
+
+	if err = s.alterTicketTableCalledAt(); err != nil {
+		s.logger.Errorw("unable to add call tracking to `ticket` table", "error", err)
+		return err
+	}
+
+	if err = s.alterTicketTablePriority(); err != nil {
+		s.logger.Errorw("unable to add priority to `ticket` table", "error", err)
+		return err
+	}
+
+	if err = s.alterTablesLocation(); err != nil {
+		s.logger.Errorw("unable to add locations to `category`, `desk` and `ticket` tables", "error", err)
+		return err
+	}
+
+	if err = s.alterTablesVersion(); err != nil {
+		s.logger.Errorw("unable to add versions to `category` and `desk` tables", "error", err)
+		return err
+	}
+
+	if err = s.createTicketEventTable(); err != nil {
+		s.logger.Errorw("unable to create `ticket_event` table", "error", err)
+		return err
+	}
+
+	if err = s.createNotifyTicketEvent(); err != nil {
+		s.logger.Errorw("unable to add function/trigger `notify_ticket_event`", "error", err)
+		return err
+	}
+
+	if err = s.createDeskSessionTable(); err != nil {
+		s.logger.Errorw("unable to create `desk_session` table", "error", err)
+		return err
+	}
+
+	if err = s.alterDeskSessionTableToken(); err != nil {
+		s.logger.Errorw("unable to add token to `desk_session` table", "error", err)
+		return err
+	}
+
+	if err = s.createCategorySettingsTable(); err != nil {
+		s.logger.Errorw("unable to create `category_settings` table", "error", err)
+		return err
+	}
+
+	if err = s.createCategoryHoursTables(); err != nil {
+		s.logger.Errorw("unable to create `category_hours` tables", "error", err)
+		return err
+	}
+
+	if err = s.createAppointmentTables(); err != nil {
+		s.logger.Errorw("unable to create `appointment` tables", "error", err)
+		return err
+	}
+
+	if err = s.createNotificationTables(); err != nil {
+		s.logger.Errorw("unable to create `notification_outbox` tables", "error", err)
+		return err
+	}
+
+	if err = s.createWebhookTables(); err != nil {
+		s.logger.Errorw("unable to create `webhook` tables", "error", err)
+		return err
+	}
+
+	if err = s.alterWebhookTableLocation(); err != nil {
+		s.logger.Errorw("unable to add locations to `webhook_subscription` table", "error", err)
+		return err
+	}
+
+	if err = s.createIdempotencyKeyTable(); err != nil {
+		s.logger.Errorw("unable to create `idempotency_key` table", "error", err)
+		return err
+	}
+
 	if err = s.createPreventCategoryDeleteOnOpenTickets(); err != nil {
 		s.logger.Errorw("unable to add function/trigger `prevent_category_delete_on_open_tickets`", "error", err)
 		return err
@@ -388,5 +1222,5 @@ func NewPostgresStorage(logger *types.SugarWithTrace) (*PostgresStorage, error)
 		return nil, err
 	}
 
-	return &PostgresStorage{db: db, logger: logger}, nil
+	return &PostgresStorage{db: db, connStr: connStr, logger: logger}, nil
 }
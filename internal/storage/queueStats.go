@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"slices"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"github.com/lib/pq"
+)
+
+// waitingTicket matches the tickets of a queue that have not yet been called.
+const waitingTicket = "closed = FALSE AND desk_id IS NULL AND deleted_at IS NULL"
+
+// ServiceDurations returns how long each of the last limit tickets closed in a
+// category spent at a desk, ordered from oldest to newest.
+func (s *PostgresStorage) ServiceDurations(categoryID int, limit int) ([]time.Duration, error) {
+	query := `SELECT EXTRACT(EPOCH FROM closed.created_at - called.created_at)
+	FROM ticket_event closed
+	JOIN LATERAL (
+	    SELECT MAX(e.created_at) AS created_at
+	    FROM ticket_event e
+	    WHERE e.ticket_id = closed.ticket_id
+	      AND e.type = $2
+	      AND e.id < closed.id
+	  ) called ON called.created_at IS NOT NULL
+	WHERE closed.category_id = $1
+	  AND closed.type = $3
+	  AND ` + inLocation("closed.category_id", "category", 5) + `
+	ORDER BY closed.id DESC
+	LIMIT $4`
+
+	rows, err := s.db.Query(query, categoryID, types.TicketCalled, types.TicketClosed, limit, s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with ServiceDurations", "category_id", categoryID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var durations []time.Duration
+
+	for rows.Next() {
+		var seconds float64
+		if err = rows.Scan(&seconds); err != nil {
+			return nil, err
+		}
+		durations = append(durations, time.Duration(seconds*float64(time.Second)))
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(durations)
+
+	return durations, nil
+}
+
+// CountOpenDesks counts the desks serving a category that are staffed and not
+// paused.
+func (s *PostgresStorage) CountOpenDesks(categoryID int) (int, error) {
+	query := `SELECT COUNT(*)
+	FROM desk d
+	JOIN desk_session ds ON ds.desk_id = d.id
+	WHERE d.category_id = $1
+	  AND ds.closed_at IS NULL
+	  AND ds.state = $2
+	  AND ` + locationFilter("d.location_id", 3)
+
+	var count int
+	if err := s.db.QueryRow(query, categoryID, types.DeskOpen, s.locationID).Scan(&count); err != nil {
+		s.logger.Warnw("error with CountOpenDesks", "category_id", categoryID, "error", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (s *PostgresStorage) CountWaiting(categoryID int) (int, error) {
+	query := `SELECT COUNT(*) FROM ticket WHERE category_id = $1 AND ` + waitingTicket + ` AND ` + locationFilter("location_id", 2)
+
+	var count int
+	if err := s.db.QueryRow(query, categoryID, s.locationID).Scan(&count); err != nil {
+		s.logger.Warnw("error with CountWaiting", "category_id", categoryID, "error", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// TicketsAhead counts the tickets that will be called before the given one.
+func (s *PostgresStorage) TicketsAhead(id int) (int, error) {
+	ticket, err := s.GetTicket(id)
+	if err != nil {
+		return 0, err
+	}
+
+	if ticket.Closed || ticket.DeskID != -1 {
+		return 0, types.ErrTicketNotWaiting
+	}
+
+	query := `SELECT COUNT(*)
+	FROM ticket
+	WHERE category_id = $1
+	  AND ` + waitingTicket + `
+	  AND (queued_at, id) < (SELECT queued_at, id FROM ticket WHERE id = $2)`
+
+	var count int
+	if err = s.db.QueryRow(query, ticket.CategoryID, ticket.ID).Scan(&count); err != nil {
+		s.logger.Warnw("error with TicketsAhead", "id", id, "error", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RecentCalls returns the last limit tickets called to a desk within the past
+// twelve hours, most recent first, optionally only from the given categories.
+// A ticket that has been recalled appears once, at the time of its latest call.
+func (s *PostgresStorage) RecentCalls(categoryIDs []int, limit int) ([]types.Call, error) {
+	query := `SELECT ticket_id, category_id, desk_id, label, type = $2, created_at
+	FROM (
+	    SELECT DISTINCT ON (e.ticket_id) e.ticket_id, e.category_id, e.desk_id, d.label, e.type, e.created_at, e.id
+	    FROM ticket_event e
+	    JOIN desk d ON d.id = e.desk_id
+	    WHERE e.type IN ($1, $2)
+	      AND e.created_at > NOW() - INTERVAL '12 hours'
+	      AND (COALESCE(cardinality($3::INT[]), 0) = 0 OR e.category_id = ANY($3))
+	      AND ` + locationFilter("d.location_id", 5) + `
+	    ORDER BY e.ticket_id, e.id DESC
+	  ) calls
+	ORDER BY id DESC
+	LIMIT $4`
+
+	rows, err := s.db.Query(query, types.TicketCalled, types.TicketRecalled, pq.Array(categoryIDs), limit, s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with RecentCalls", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	calls := []types.Call{}
+
+	for rows.Next() {
+		var call types.Call
+		if err = rows.Scan(&call.TicketID, &call.CategoryID, &call.DeskID, &call.DeskLabel, &call.Recall, &call.CalledAt); err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+
+	return calls, rows.Err()
+}
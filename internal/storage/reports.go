@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"github.com/lib/pq"
+)
+
+// reportGroups are the columns, identifying and naming a category or desk, that
+// a report is grouped by and the join from the report's events, aliased e,
+// which provides them.
+var reportGroups = map[types.ReportGrouping]struct{ columns, join string }{
+	types.ReportByCategory: {"e.category_id, cat.name", "JOIN category cat ON cat.id = e.category_id"},
+	types.ReportByDesk:     {"e.desk_id, d.label", "JOIN desk d ON d.id = e.desk_id"},
+}
+
+// Report aggregates the ticket history between query.From and query.To by
+// period and by category or desk. Tickets are issued when they are created or
+// checked in for an appointment, served when they are closed and abandoned
+// when the customer cancels them. A wait runs from the ticket being issued or
+// requeued to it being called, and a service from it being last called to it
+// being closed.
+func (s *PostgresStorage) Report(query types.ReportQuery) ([]types.ReportRow, error) {
+	group, ok := reportGroups[query.GroupBy]
+	if !ok {
+		return nil, types.ErrNotImplemented
+	}
+
+	sqlQuery := `WITH events AS (
+	    SELECT e.id, e.ticket_id, e.type, e.category_id, e.desk_id, e.created_at,
+	      date_trunc($3, (e.created_at AT TIME ZONE current_setting('TimeZone')) AT TIME ZONE $4) AS period
+	    FROM ticket_event e
+	    WHERE e.created_at >= ($1::TIMESTAMPTZ AT TIME ZONE current_setting('TimeZone'))
+	      AND e.created_at < ($2::TIMESTAMPTZ AT TIME ZONE current_setting('TimeZone'))
+	      AND (COALESCE(cardinality($5::INT[]), 0) = 0 OR e.category_id = ANY($5))
+	      AND ` + inLocation("e.category_id", "category", 13) + `
+	  ), waits AS (
+	    SELECT e.id, EXTRACT(EPOCH FROM e.created_at - issued.created_at) AS seconds
+	    FROM events e
+	    JOIN LATERAL (
+	        SELECT MAX(p.created_at) AS created_at
+	        FROM ticket_event p
+	        WHERE p.ticket_id = e.ticket_id
+	          AND p.type IN ($6, $7, $8)
+	          AND p.id < e.id
+	      ) issued ON issued.created_at IS NOT NULL
+	    WHERE e.type = $9
+	  ), services AS (
+	    SELECT e.id, EXTRACT(EPOCH FROM e.created_at - called.created_at) AS seconds
+	    FROM events e
+	    JOIN LATERAL (
+	        SELECT MAX(p.created_at) AS created_at
+	        FROM ticket_event p
+	        WHERE p.ticket_id = e.ticket_id
+	          AND p.type = $9
+	          AND p.id < e.id
+	      ) called ON called.created_at IS NOT NULL
+	    WHERE e.type = $10
+	  )
+	SELECT e.period, ` + group.columns + `,
+	  COUNT(*) FILTER (WHERE e.type IN ($6, $7)),
+	  COUNT(*) FILTER (WHERE e.type = $9),
+	  COUNT(*) FILTER (WHERE e.type = $10),
+	  COUNT(*) FILTER (WHERE e.type = $11),
+	  COUNT(*) FILTER (WHERE e.type = $12),
+	  percentile_cont(0.5) WITHIN GROUP (ORDER BY w.seconds),
+	  percentile_cont(0.9) WITHIN GROUP (ORDER BY w.seconds),
+	  percentile_cont(0.5) WITHIN GROUP (ORDER BY sv.seconds),
+	  percentile_cont(0.9) WITHIN GROUP (ORDER BY sv.seconds)
+	FROM events e
+	` + group.join + `
+	LEFT JOIN waits w ON w.id = e.id
+	LEFT JOIN services sv ON sv.id = e.id
+	GROUP BY 1, 2, 3
+	ORDER BY 1, 2`
+
+	rows, err := s.db.Query(sqlQuery, query.From, query.To, query.Interval, query.Location.String(), pq.Array(query.CategoryIDs),
+		types.TicketCreated, types.TicketCheckedIn, types.TicketRequeued, types.TicketCalled, types.TicketClosed, types.TicketNoShow, types.TicketCancelled, s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with Report", "group_by", query.GroupBy, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []types.ReportRow{}
+
+	for rows.Next() {
+		row, err := scanReportRow(rows, query.GroupBy, query.Location)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
+
+func scanReportRow(row rowScanner, groupBy types.ReportGrouping, location *time.Location) (types.ReportRow, error) {
+	var report types.ReportRow
+	var period time.Time
+	var groupID int
+	var groupName string
+	var waitP50, waitP90, serviceP50, serviceP90 sql.NullFloat64
+
+	if err := row.Scan(&period, &groupID, &groupName, &report.Issued, &report.Called, &report.Served, &report.NoShows, &report.Abandoned, &waitP50, &waitP90, &serviceP50, &serviceP90); err != nil {
+		return types.ReportRow{}, err
+	}
+
+	// The period is the wall clock time in the report's timezone.
+	report.Period = time.Date(period.Year(), period.Month(), period.Day(), period.Hour(), 0, 0, 0, location)
+
+	if groupBy == types.ReportByDesk {
+		report.DeskID, report.DeskLabel = groupID, groupName
+	} else {
+		report.CategoryID, report.CategoryName = groupID, groupName
+	}
+
+	report.WaitP50Seconds = nullFloat(waitP50)
+	report.WaitP90Seconds = nullFloat(waitP90)
+	report.ServiceP50Seconds = nullFloat(serviceP50)
+	report.ServiceP90Seconds = nullFloat(serviceP90)
+
+	return report, nil
+}
+
+func nullFloat(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"github.com/lib/pq"
+)
+
+const ticketEventChannel = "ticket_event"
+
+// ListenTicketEvents calls publish with every ticket event once the
+// transaction that recorded it has committed, until ctx is cancelled. Events
+// recorded while the connection is being re-established are missed.
+func (s *PostgresStorage) ListenTicketEvents(ctx context.Context, publish func(types.TicketEvent)) error {
+	listener := pq.NewListener(s.connStr, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			s.logger.Warnw("ticket event listener problem", "event", event, "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(ticketEventChannel); err != nil {
+		s.logger.Errorw("failed to listen for ticket events", "error", err)
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		case notification := <-listener.Notify:
+			// A nil notification means the connection was re-established.
+			if notification == nil {
+				continue
+			}
+
+			event, err := decodeTicketEvent(notification.Extra)
+			if err != nil {
+				s.logger.Warnw("failed to decode ticket event notification", "payload", notification.Extra, "error", err)
+				continue
+			}
+			publish(event)
+		}
+	}
+}
+
+func decodeTicketEvent(payload string) (types.TicketEvent, error) {
+	var raw struct {
+		ID         int                   `json:"id"`
+		TicketID   int                   `json:"ticket_id"`
+		Type       types.TicketEventType `json:"type"`
+		CategoryID int                   `json:"category_id"`
+		DeskID     *int                  `json:"desk_id"`
+		Staff      *string               `json:"staff"`
+		CreatedAt  time.Time             `json:"created_at"`
+	}
+
+	if err := json.Unmarshal([]byte(payload), &raw); err != nil {
+		return types.TicketEvent{}, err
+	}
+
+	event := types.TicketEvent{
+		ID:         raw.ID,
+		TicketID:   raw.TicketID,
+		Type:       raw.Type,
+		CategoryID: raw.CategoryID,
+		DeskID:     -1,
+		CreatedAt:  raw.CreatedAt,
+	}
+	if raw.DeskID != nil {
+		event.DeskID = *raw.DeskID
+	}
+	if raw.Staff != nil {
+		event.Staff = *raw.Staff
+	}
+
+	return event, nil
+}
+
+func (s *PostgresStorage) createNotifyTicketEvent() error {
+	query := `CREATE OR REPLACE FUNCTION notify_ticket_event()
+	RETURNS trigger AS $$
+	BEGIN
+	  PERFORM pg_notify('` + ticketEventChannel + `', json_build_object(
+	    'id', NEW.id,
+	    'ticket_id', NEW.ticket_id,
+	    'type', NEW.type,
+	    'category_id', NEW.category_id,
+	    'desk_id', NEW.desk_id,
+	    'staff', NEW.staff,
+	    'created_at', NEW.created_at AT TIME ZONE current_setting('TimeZone')
+	  )::text);
+	  RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE OR REPLACE TRIGGER trg_notify_ticket_event
+	AFTER INSERT ON ticket_event
+	FOR EACH ROW
+	EXECUTE FUNCTION notify_ticket_event();`
+
+	_, err := s.db.Exec(query)
+	return err
+}
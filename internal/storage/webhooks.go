@@ -0,0 +1,388 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+	"github.com/lib/pq"
+)
+
+const webhookSubscriptionColumns = "id, location_id, url, event_types, secret, active, created_at"
+
+// webhookDeliveryColumns selects a webhook_delivery, aliased d, joined to its
+// subscription, aliased ws.
+const webhookDeliveryColumns = `d.id, d.subscription_id, ws.url, ws.secret, d.event_type, d.payload,
+	CASE WHEN d.delivered_at IS NOT NULL THEN 'delivered' WHEN d.failed_at IS NOT NULL THEN 'failed' ELSE 'pending' END,
+	d.attempts, CASE WHEN d.delivered_at IS NULL AND d.failed_at IS NULL THEN d.next_attempt_at END, d.created_at`
+
+func scanWebhookSubscription(row rowScanner) (types.WebhookSubscription, error) {
+	var subscription types.WebhookSubscription
+
+	err := row.Scan(&subscription.ID, &subscription.LocationID, &subscription.URL, pq.Array(&subscription.EventTypes), &subscription.Secret, &subscription.Active, &subscription.CreatedAt)
+	return subscription, err
+}
+
+func scanWebhookDelivery(row rowScanner) (types.WebhookDelivery, error) {
+	var delivery types.WebhookDelivery
+	var payload []byte
+	var nextAttemptAt sql.NullTime
+
+	if err := row.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.URL, &delivery.Secret, &delivery.EventType, &payload, &delivery.Status, &delivery.Attempts, &nextAttemptAt, &delivery.CreatedAt); err != nil {
+		return types.WebhookDelivery{}, err
+	}
+
+	delivery.Payload = json.RawMessage(payload)
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+
+	return delivery, nil
+}
+
+// enqueueWebhookDeliveries queues an event in a location for every active
+// subscription of that location to its type. It must run in the same
+// transaction as the mutation that caused the event, so that integrators are
+// only told about committed changes.
+func enqueueWebhookDeliveries(tx *sql.Tx, locationID int, eventType string, data any) error {
+	payload, err := json.Marshal(struct {
+		Type       string    `json:"type"`
+		LocationID int       `json:"location_id"`
+		OccurredAt time.Time `json:"occurred_at"`
+		Data       any       `json:"data"`
+	}{eventType, locationID, time.Now().UTC(), data})
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO webhook_delivery (subscription_id, event_type, payload)
+	SELECT id, $1, $2
+	FROM webhook_subscription
+	WHERE active AND $1 = ANY(event_types) AND location_id = $3`
+
+	_, err = tx.Exec(query, eventType, payload, locationID)
+	return err
+}
+
+// CreateWebhookSubscription subscribes a URL to the events of the location the
+// storage is scoped to.
+func (s *PostgresStorage) CreateWebhookSubscription(subscription types.WebhookSubscription) (types.WebhookSubscription, error) {
+	query := `INSERT INTO webhook_subscription (location_id, url, event_types, secret, active)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING ` + webhookSubscriptionColumns
+
+	created, err := scanWebhookSubscription(s.db.QueryRow(query, s.locationID, subscription.URL, pq.Array(subscription.EventTypes), subscription.Secret, subscription.Active))
+	if err != nil {
+		s.logger.Warnw("could not create webhook subscription", "error", err)
+		return types.WebhookSubscription{}, err
+	}
+
+	return created, nil
+}
+
+func (s *PostgresStorage) GetWebhookSubscription(id int) (types.WebhookSubscription, error) {
+	subscription, err := scanWebhookSubscription(s.db.QueryRow("SELECT "+webhookSubscriptionColumns+" FROM webhook_subscription WHERE id = $1 AND "+locationFilter("location_id", 2), id, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.WebhookSubscription{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with GetWebhookSubscription", "id", id, "error", err)
+		return types.WebhookSubscription{}, err
+	}
+
+	return subscription, nil
+}
+
+func (s *PostgresStorage) ListWebhookSubscriptions() ([]types.WebhookSubscription, error) {
+	rows, err := s.db.Query("SELECT "+webhookSubscriptionColumns+" FROM webhook_subscription WHERE "+locationFilter("location_id", 1)+" ORDER BY id", s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with ListWebhookSubscriptions", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []types.WebhookSubscription{}
+
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// UpdateWebhookSubscription changes the URL, event types and active flag of a
+// subscription. The secret is kept.
+func (s *PostgresStorage) UpdateWebhookSubscription(subscription types.WebhookSubscription) (types.WebhookSubscription, error) {
+	query := `UPDATE webhook_subscription
+	SET url = $2, event_types = $3, active = $4
+	WHERE id = $1 AND ` + locationFilter("location_id", 5) + `
+	RETURNING ` + webhookSubscriptionColumns
+
+	updated, err := scanWebhookSubscription(s.db.QueryRow(query, subscription.ID, subscription.URL, pq.Array(subscription.EventTypes), subscription.Active, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.WebhookSubscription{}, types.ErrnotFound
+		}
+		s.logger.Warnw("could not update webhook subscription", "id", subscription.ID, "error", err)
+		return types.WebhookSubscription{}, err
+	}
+
+	return updated, nil
+}
+
+// DeleteWebhookSubscription removes a subscription along with its deliveries
+// and their logs.
+func (s *PostgresStorage) DeleteWebhookSubscription(id int) error {
+	result, err := s.db.Exec("DELETE FROM webhook_subscription WHERE id = $1 AND "+locationFilter("location_id", 2), id, s.locationID)
+	if err != nil {
+		s.logger.Tracew("error deleting webhook subscription", "id", id, "error", err)
+		return err
+	}
+
+	return checkSingleRowAffected(result, id, "DeleteWebhookSubscription", s.logger)
+}
+
+// ListWebhookDeliveries returns the most recent deliveries of a subscription,
+// newest first.
+func (s *PostgresStorage) ListWebhookDeliveries(subscriptionID int, limit int) ([]types.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + `
+	FROM webhook_delivery d
+	JOIN webhook_subscription ws ON ws.id = d.subscription_id
+	WHERE d.subscription_id = $1 AND ` + locationFilter("ws.location_id", 3) + `
+	ORDER BY d.id DESC
+	LIMIT $2`
+
+	rows, err := s.db.Query(query, subscriptionID, limit, s.locationID)
+	if err != nil {
+		s.logger.Warnw("error with ListWebhookDeliveries", "subscription_id", subscriptionID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []types.WebhookDelivery{}
+
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// GetWebhookDelivery returns a delivery with the log of its attempts.
+func (s *PostgresStorage) GetWebhookDelivery(id int) (types.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + `
+	FROM webhook_delivery d
+	JOIN webhook_subscription ws ON ws.id = d.subscription_id
+	WHERE d.id = $1 AND ` + locationFilter("ws.location_id", 2)
+
+	delivery, err := scanWebhookDelivery(s.db.QueryRow(query, id, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.WebhookDelivery{}, types.ErrnotFound
+		}
+		s.logger.Warnw("error with GetWebhookDelivery", "id", id, "error", err)
+		return types.WebhookDelivery{}, err
+	}
+
+	attemptQuery := `SELECT id, delivery_id, COALESCE(status_code, 0), COALESCE(error, ''), duration_ms, attempted_at
+	FROM webhook_delivery_attempt
+	WHERE delivery_id = $1
+	ORDER BY id`
+
+	rows, err := s.db.Query(attemptQuery, id)
+	if err != nil {
+		s.logger.Warnw("error listing webhook delivery attempts", "id", id, "error", err)
+		return types.WebhookDelivery{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attempt types.WebhookAttempt
+		if err = rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.StatusCode, &attempt.Error, &attempt.DurationMS, &attempt.AttemptedAt); err != nil {
+			return types.WebhookDelivery{}, err
+		}
+		delivery.AttemptLog = append(delivery.AttemptLog, attempt)
+	}
+
+	return delivery, rows.Err()
+}
+
+// RedeliverWebhook queues the payload of a delivery to be sent to its
+// subscription again as a new delivery, leaving the original and its log as
+// they were.
+func (s *PostgresStorage) RedeliverWebhook(id int) (types.WebhookDelivery, error) {
+	query := `WITH d AS (
+	    INSERT INTO webhook_delivery (subscription_id, event_type, payload)
+	    SELECT wd.subscription_id, wd.event_type, wd.payload
+	    FROM webhook_delivery wd
+	    JOIN webhook_subscription ws ON ws.id = wd.subscription_id
+	    WHERE wd.id = $1 AND ` + locationFilter("ws.location_id", 2) + `
+	    RETURNING *
+	  )
+	SELECT ` + webhookDeliveryColumns + `
+	FROM d
+	JOIN webhook_subscription ws ON ws.id = d.subscription_id`
+
+	delivery, err := scanWebhookDelivery(s.db.QueryRow(query, id, s.locationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.WebhookDelivery{}, types.ErrnotFound
+		}
+		s.logger.Warnw("could not redeliver webhook", "id", id, "error", err)
+		return types.WebhookDelivery{}, err
+	}
+
+	return delivery, nil
+}
+
+// ClaimWebhookDeliveries returns up to limit deliveries to active
+// subscriptions that are due to be sent and counts the attempt. They are not
+// due again until lease has passed, so a delivery whose sender dies is retried
+// rather than lost.
+func (s *PostgresStorage) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]types.WebhookDelivery, error) {
+	query := `WITH d AS (
+	    UPDATE webhook_delivery
+	    SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2)
+	    WHERE id IN (
+	        SELECT d.id
+	        FROM webhook_delivery d
+	        JOIN webhook_subscription ws ON ws.id = d.subscription_id
+	        WHERE ws.active
+	          AND d.delivered_at IS NULL
+	          AND d.failed_at IS NULL
+	          AND d.next_attempt_at <= NOW()
+	        ORDER BY d.next_attempt_at, d.id
+	        LIMIT $1
+	        FOR UPDATE OF d SKIP LOCKED
+	      )
+	    RETURNING *
+	  )
+	SELECT ` + webhookDeliveryColumns + `
+	FROM d
+	JOIN webhook_subscription ws ON ws.id = d.subscription_id
+	ORDER BY d.id`
+
+	rows, err := s.db.Query(query, limit, lease.Seconds())
+	if err != nil {
+		s.logger.Warnw("error with ClaimWebhookDeliveries", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []types.WebhookDelivery
+
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RecordWebhookAttempt logs an attempt to send a delivery. Unless it was
+// delivered it is retried once retryAfter has passed, or given up on when
+// retryAfter is zero.
+func (s *PostgresStorage) RecordWebhookAttempt(attempt types.WebhookAttempt, delivered bool, retryAfter time.Duration) error {
+	attemptQuery := `INSERT INTO webhook_delivery_attempt (delivery_id, status_code, error, duration_ms)
+	VALUES ($1, NULLIF($2, 0), NULLIF($3, ''), $4)`
+
+	deliveryQuery := `UPDATE webhook_delivery
+	SET delivered_at = CASE WHEN $2 THEN NOW() END,
+	  failed_at = CASE WHEN NOT $2 AND $3 = 0 THEN NOW() END,
+	  next_attempt_at = NOW() + make_interval(secs => $3)
+	WHERE id = $1`
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(attemptQuery, attempt.DeliveryID, attempt.StatusCode, attempt.Error, attempt.DurationMS); err != nil {
+		s.logger.Tracew("error logging webhook attempt", "delivery_id", attempt.DeliveryID, "error", err)
+		return err
+	}
+
+	result, err := tx.Exec(deliveryQuery, attempt.DeliveryID, delivered, retryAfter.Seconds())
+	if err != nil {
+		s.logger.Tracew("error updating webhook delivery", "delivery_id", attempt.DeliveryID, "error", err)
+		return err
+	}
+
+	if err = checkSingleRowAffected(result, attempt.DeliveryID, "RecordWebhookAttempt", s.logger); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStorage) createWebhookTables() error {
+	query := `CREATE TABLE IF NOT EXISTS webhook_subscription(
+	id SERIAL PRIMARY KEY,
+	url VARCHAR(2048) NOT NULL,
+	event_types TEXT[] NOT NULL,
+	secret VARCHAR(100) NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_delivery(
+	id BIGSERIAL PRIMARY KEY,
+	subscription_id INT NOT NULL REFERENCES webhook_subscription(id) ON DELETE CASCADE,
+	event_type VARCHAR(50) NOT NULL,
+	payload JSONB NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	delivered_at TIMESTAMP,
+	failed_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS webhook_delivery_due_idx ON webhook_delivery(next_attempt_at)
+	WHERE delivered_at IS NULL AND failed_at IS NULL;
+
+	CREATE INDEX IF NOT EXISTS webhook_delivery_subscription_idx ON webhook_delivery(subscription_id, id);
+
+	CREATE TABLE IF NOT EXISTS webhook_delivery_attempt(
+	id BIGSERIAL PRIMARY KEY,
+	delivery_id BIGINT NOT NULL REFERENCES webhook_delivery(id) ON DELETE CASCADE,
+	status_code INT,
+	error TEXT,
+	duration_ms INT NOT NULL,
+	attempted_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS webhook_delivery_attempt_delivery_idx ON webhook_delivery_attempt(delivery_id);`
+
+	_, err := s.db.Exec(query)
+	return err
+}
+
+// alterWebhookTableLocation moves the existing subscriptions into the default
+// location, so that they keep receiving the events they were created for.
+func (s *PostgresStorage) alterWebhookTableLocation() error {
+	query := `ALTER TABLE webhook_subscription ADD COLUMN IF NOT EXISTS location_id INT REFERENCES location(id) ON DELETE CASCADE;
+
+	UPDATE webhook_subscription
+	SET location_id = (SELECT id FROM location WHERE slug = '` + types.DefaultLocation + `')
+	WHERE location_id IS NULL;
+
+	ALTER TABLE webhook_subscription ALTER COLUMN location_id SET NOT NULL;
+
+	CREATE INDEX IF NOT EXISTS webhook_subscription_location_idx ON webhook_subscription(location_id);`
+
+	_, err := s.db.Exec(query)
+	return err
+}
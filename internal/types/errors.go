@@ -4,3 +4,18 @@ import "errors"
 
 var ErrnotFound = errors.New("not found")
 var ErrNotImplemented = errors.New("not implemented")
+var ErrTicketClosed = errors.New("ticket is closed")
+var ErrTicketNotCalled = errors.New("ticket has not been called to a desk")
+var ErrTicketServing = errors.New("ticket is already being served")
+var ErrDeskClosed = errors.New("desk is closed")
+var ErrDeskPaused = errors.New("desk is paused")
+var ErrDeskSessionOpen = errors.New("desk already has an open session")
+var ErrTicketNotWaiting = errors.New("ticket is not waiting in the queue")
+var ErrSnoozeLimit = errors.New("ticket cannot be snoozed again")
+var ErrIntakeClosed = errors.New("the queue is not accepting new tickets")
+var ErrQueueFull = errors.New("the queue is full")
+var ErrSlotFull = errors.New("appointment slot is fully booked")
+var ErrAppointmentNotBooked = errors.New("appointment is not booked")
+var ErrTooEarly = errors.New("too early to check in for appointment")
+var ErrInUse = errors.New("still in use")
+var ErrVersionMismatch = errors.New("changed since it was read")
@@ -1,30 +1,483 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+// DefaultLocation is the slug of the location that the routes without a
+// location prefix act on.
+const DefaultLocation = "default"
+
+// Location is a site, such as a store, with its own categories, desks and
+// tickets. The rest of its settings are shown to customers on its pages.
+type Location struct {
+	ID       int      `json:"id"`
+	Slug     string   `json:"slug"`
+	Name     string   `json:"name" validate:"required,max=100"`
+	Timezone string   `json:"timezone"`
+	Branding Branding `json:"branding"`
+}
+
+// Branding customises how a location's customer facing pages look.
+type Branding struct {
+	LogoURL        string `json:"logo_url,omitempty" validate:"max=2048"`
+	AccentColor    string `json:"accent_color,omitempty"`
+	WelcomeMessage string `json:"welcome_message,omitempty" validate:"max=500"`
+}
+
 type Desk struct {
 	ID         int    `json:"id"`
 	CategoryID int    `json:"category_id"`
 	Label      string `json:"label"`
+	// Version counts the changes to the desk, and is sent as its ETag.
+	Version int `json:"version"`
+}
+
+// DeskUpdate changes the label or category of a desk, keeping whichever of
+// them is left zero.
+type DeskUpdate struct {
+	CategoryID int
+	Label      string
 }
 
 type Ticket struct {
-	ID         int       `json:"id"`
-	CategoryID int       `json:"category_id"`
-	SubURL     string    `json:"sub_url"`
-	DeskID     int       `json:"desk_id"`
-	Closed     bool      `json:"closed"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID         int        `json:"id"`
+	CategoryID int        `json:"category_id"`
+	SubURL     string     `json:"sub_url"`
+	DeskID     int        `json:"desk_id"`
+	Closed     bool       `json:"closed"`
+	CreatedAt  time.Time  `json:"created_at"`
+	QueuedAt   time.Time  `json:"queued_at"`
+	Snoozes    int        `json:"snoozes"`
+	CalledAt   *time.Time `json:"called_at,omitempty"`
+	ServedAt   *time.Time `json:"served_at,omitempty"`
+	Recalls    int        `json:"recalls"`
+	Priority   bool       `json:"priority"`
+}
+
+// DisplayNumber is the short number shown to customers and on the display
+// boards: the initial of the category followed by the last three digits of
+// the ticket's ID.
+func (t Ticket) DisplayNumber(category Category) string {
+	prefix := "#"
+	if category.Name != "" {
+		initial, _ := utf8.DecodeRuneInString(category.Name)
+		prefix = string(unicode.ToUpper(initial))
+	}
+	return fmt.Sprintf("%s%03d", prefix, t.ID%1000)
 }
 
 type Category struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
+	// Version counts the renames of the category, and is sent as its ETag.
+	Version int `json:"version"`
+}
+
+// CategorySettings are the queue policies of a category.
+type CategorySettings struct {
+	CategoryID int `json:"category_id" yaml:"-"`
+	// NoShowTimeoutSeconds is how long a called ticket waits for its customer
+	// before being recalled. Zero disables no-show handling.
+	NoShowTimeoutSeconds int `json:"no_show_timeout_seconds" yaml:"no_show_timeout_seconds" validate:"min=0"`
+	// MaxRecalls is how many times a ticket is recalled before it is marked as
+	// a no-show.
+	MaxRecalls int `json:"max_recalls" yaml:"max_recalls" validate:"min=0"`
+	// RequeueNoShows puts no-shows back at the end of the queue instead of
+	// closing them.
+	RequeueNoShows bool `json:"requeue_no_shows" yaml:"requeue_no_shows"`
+	// Timezone is the IANA name of the zone the opening hours are given in.
+	Timezone string `json:"timezone" yaml:"timezone"`
+	// IntakeClosed stops new tickets being issued regardless of the opening
+	// hours. It is left out of configuration files and ignored when the
+	// settings are replaced, being changed by staff as the day goes through
+	// the category's intake.
+	IntakeClosed bool `json:"intake_closed" yaml:"-"`
+	// MaxWaiting is the most tickets that may be waiting in the queue at once.
+	// Zero means there is no limit.
+	MaxWaiting int `json:"max_waiting" yaml:"max_waiting" validate:"min=0"`
+	// MaxTicketsPerClient is how many tickets a single client may take within
+	// ClientWindowSeconds. Zero means there is no limit.
+	MaxTicketsPerClient int `json:"max_tickets_per_client" yaml:"max_tickets_per_client" validate:"min=0"`
+	ClientWindowSeconds int `json:"client_window_seconds" yaml:"client_window_seconds" validate:"min=0"`
+}
+
+// OpeningHours is a period during which a category issues tickets on a day
+// of the week. Times are given as "15:04" in the category's timezone.
+type OpeningHours struct {
+	Weekday time.Weekday `json:"weekday" yaml:"weekday"`
+	Opens   string       `json:"opens" yaml:"opens"`
+	Closes  string       `json:"closes" yaml:"closes"`
+}
+
+// HolidayException replaces the weekly opening hours on a date, given as
+// "2006-01-02". A category is closed all day when Opens and Closes are empty.
+type HolidayException struct {
+	Date   string `json:"date" yaml:"date"`
+	Opens  string `json:"opens,omitempty" yaml:"opens,omitempty"`
+	Closes string `json:"closes,omitempty" yaml:"closes,omitempty"`
+}
+
+// OpeningSchedule is a set of weekly opening hours and the holiday
+// exceptions to them.
+type OpeningSchedule struct {
+	Weekly   []OpeningHours     `json:"weekly" yaml:"weekly"`
+	Holidays []HolidayException `json:"holidays" yaml:"holidays"`
+}
+
+// IsEmpty reports whether no hours have been set.
+func (o OpeningSchedule) IsEmpty() bool {
+	return len(o.Weekly) == 0 && len(o.Holidays) == 0
+}
+
+// CategoryHours are the opening hours of a category. A category without any
+// hours follows the hours of its location, and is always open if its location
+// has none either.
+type CategoryHours struct {
+	CategoryID int `json:"category_id"`
+	OpeningSchedule
+}
+
+// LocationHours are the opening hours shared by the categories of a location
+// that do not set their own.
+type LocationHours struct {
+	LocationID int `json:"location_id"`
+	OpeningSchedule
 }
 
 type TicketCreate struct {
 	CategoryID int
 	SubURL     string
+	Contact    *TicketContact
+}
+
+// TicketContact holds the details a customer opted in to be notified on. Any
+// of Email, Phone and WebhookURL may be left empty. NotifyAhead is how many
+// tickets ahead of theirs the customer is told they are close; zero means they
+// are only told when they are called.
+type TicketContact struct {
+	Email       string `json:"email,omitempty" validate:"max=254"`
+	Phone       string `json:"phone,omitempty" validate:"max=20"`
+	WebhookURL  string `json:"webhook_url,omitempty" validate:"max=2048"`
+	NotifyAhead int    `json:"notify_ahead,omitempty"`
+}
+
+type NotificationChannel string
+
+const (
+	ChannelEmail   NotificationChannel = "email"
+	ChannelSMS     NotificationChannel = "sms"
+	ChannelWebhook NotificationChannel = "webhook"
+)
+
+type NotificationKind string
+
+const (
+	// NotifyAhead tells a customer there are PlacesAhead tickets before theirs.
+	NotifyAhead NotificationKind = "ahead"
+	// NotifyCalled tells a customer they have been called, or recalled, to a
+	// desk.
+	NotifyCalled NotificationKind = "called"
+)
+
+// Notification is a message waiting in the outbox to be sent to a customer
+// through one of their contact channels.
+type Notification struct {
+	ID            int                 `json:"id"`
+	TicketID      int                 `json:"ticket_id"`
+	DisplayNumber string              `json:"display_number"`
+	Channel       NotificationChannel `json:"channel"`
+	Address       string              `json:"address"`
+	Kind          NotificationKind    `json:"kind"`
+	PlacesAhead   int                 `json:"places_ahead,omitempty"`
+	DeskLabel     string              `json:"desk_label,omitempty"`
+	Attempts      int                 `json:"attempts"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+// TicketSnooze pushes a waiting ticket back in its queue by either a number of
+// places or an amount of time.
+type TicketSnooze struct {
+	Places  int
+	Minutes int
+}
+
+type TicketEventType string
+
+const (
+	TicketCreated     TicketEventType = "created"
+	TicketCalled      TicketEventType = "called"
+	TicketRecalled    TicketEventType = "recalled"
+	TicketServing     TicketEventType = "serving"
+	TicketTransferred TicketEventType = "transferred"
+	TicketClosed      TicketEventType = "closed"
+	TicketDeleted     TicketEventType = "deleted"
+	TicketCancelled   TicketEventType = "cancelled"
+	TicketSnoozed     TicketEventType = "snoozed"
+	TicketNoShow      TicketEventType = "no_show"
+	TicketRequeued    TicketEventType = "requeued"
+	TicketCheckedIn   TicketEventType = "checked_in"
+)
+
+// WebhookEvent is the name webhook subscriptions use for the event type.
+func (t TicketEventType) WebhookEvent() string {
+	return "ticket." + string(t)
+}
+
+// TicketEvent is a single entry of the append-only ticket history. DeskID is
+// -1 when the ticket was not assigned to a desk at the time of the event.
+type TicketEvent struct {
+	ID         int             `json:"id"`
+	TicketID   int             `json:"ticket_id"`
+	Type       TicketEventType `json:"type"`
+	CategoryID int             `json:"category_id"`
+	DeskID     int             `json:"desk_id"`
+	Staff      string          `json:"staff,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// Call is a ticket being called, or recalled, to a desk.
+type Call struct {
+	TicketID   int       `json:"ticket_id"`
+	CategoryID int       `json:"category_id"`
+	DeskID     int       `json:"desk_id"`
+	DeskLabel  string    `json:"desk_label"`
+	Recall     bool      `json:"recall"`
+	CalledAt   time.Time `json:"called_at"`
+}
+
+type DeskSessionState string
+
+const (
+	DeskOpen   DeskSessionState = "open"
+	DeskPaused DeskSessionState = "paused"
+	DeskClosed DeskSessionState = "closed"
+)
+
+// WebhookEvent is the name webhook subscriptions use for a desk session
+// entering the state.
+func (s DeskSessionState) WebhookEvent() string {
+	return "desk_session." + string(s)
+}
+
+// DeskSession records a staff member signing in to a desk. A desk without a
+// session that has not been closed is not staffed.
+type DeskSession struct {
+	ID             int              `json:"id"`
+	DeskID         int              `json:"desk_id"`
+	Staff          string           `json:"staff"`
+	State          DeskSessionState `json:"state"`
+	OpenedAt       time.Time        `json:"opened_at"`
+	StateChangedAt time.Time        `json:"state_changed_at"`
+	ClosedAt       *time.Time       `json:"closed_at,omitempty"`
+	// Token authenticates the desk's WebSocket connection for as long as the
+	// session is open. It is only given out when the session is opened.
+	Token string `json:"token,omitempty"`
+}
+
+// AppointmentSlot is a period customers can book to be seen in a category,
+// shared by at most Capacity appointments.
+type AppointmentSlot struct {
+	ID         int       `json:"id"`
+	CategoryID int       `json:"category_id"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Capacity   int       `json:"capacity"`
+	Booked     int       `json:"booked"`
+}
+
+type AppointmentStatus string
+
+const (
+	AppointmentBooked    AppointmentStatus = "booked"
+	AppointmentCheckedIn AppointmentStatus = "checked_in"
+	AppointmentCancelled AppointmentStatus = "cancelled"
+)
+
+// Appointment is a booking of a slot. When the customer checks in it is turned
+// into a ticket which shares its SubURL. TicketID is -1 until then.
+type Appointment struct {
+	ID         int               `json:"id"`
+	SlotID     int               `json:"slot_id"`
+	CategoryID int               `json:"category_id"`
+	StartsAt   time.Time         `json:"starts_at"`
+	Name       string            `json:"name"`
+	SubURL     string            `json:"sub_url"`
+	Status     AppointmentStatus `json:"status"`
+	TicketID   int               `json:"ticket_id"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// CheckInWindow is how early and how late customers may check in for an
+// appointment and still be given priority.
+type CheckInWindow struct {
+	Early time.Duration
+	Grace time.Duration
+}
+
+// WebhookSubscription sends the events of EventTypes to URL, signed with
+// Secret. The secret is only shown when the subscription is created.
+type WebhookSubscription struct {
+	ID         int       `json:"id"`
+	LocationID int       `json:"location_id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	WebhookFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an event queued to be sent to a subscription, along with
+// the log of its attempts when it is fetched on its own.
+type WebhookDelivery struct {
+	ID             int                   `json:"id"`
+	SubscriptionID int                   `json:"subscription_id"`
+	URL            string                `json:"url"`
+	Secret         string                `json:"-"`
+	EventType      string                `json:"event_type"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	AttemptLog     []WebhookAttempt      `json:"attempt_log,omitempty"`
+}
+
+// WebhookAttempt is the outcome of trying to send a delivery. StatusCode is
+// zero when no response was received.
+type WebhookAttempt struct {
+	ID          int       `json:"id"`
+	DeliveryID  int       `json:"delivery_id"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int       `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type ReportInterval string
+
+const (
+	ReportByHour ReportInterval = "hour"
+	ReportByDay  ReportInterval = "day"
+)
+
+type ReportGrouping string
+
+const (
+	ReportByCategory ReportGrouping = "category"
+	ReportByDesk     ReportGrouping = "desk"
+)
+
+// ReportQuery selects the ticket history a report is computed over. Periods
+// start at the beginning of each hour or day in Location.
+type ReportQuery struct {
+	From        time.Time
+	To          time.Time
+	Interval    ReportInterval
+	GroupBy     ReportGrouping
+	Location    *time.Location
+	CategoryIDs []int
+}
+
+// ReportRow aggregates the ticket history of a category or desk over a
+// period. Issued and Abandoned are only counted for categories, as tickets
+// are not at a desk when they are taken or cancelled. The wait and service
+// percentiles are nil when nobody was called or served.
+type ReportRow struct {
+	Period            time.Time `json:"period"`
+	CategoryID        int       `json:"category_id,omitempty"`
+	CategoryName      string    `json:"category_name,omitempty"`
+	DeskID            int       `json:"desk_id,omitempty"`
+	DeskLabel         string    `json:"desk_label,omitempty"`
+	Issued            int       `json:"issued"`
+	Called            int       `json:"called"`
+	Served            int       `json:"served"`
+	NoShows           int       `json:"no_shows"`
+	Abandoned         int       `json:"abandoned"`
+	WaitP50Seconds    *float64  `json:"wait_p50_seconds"`
+	WaitP90Seconds    *float64  `json:"wait_p90_seconds"`
+	ServiceP50Seconds *float64  `json:"service_p50_seconds"`
+	ServiceP90Seconds *float64  `json:"service_p90_seconds"`
+}
+
+// LocationConfig describes the categories and desks of a location, with
+// their queue policies and opening hours, as kept in a YAML file and applied
+// to the location as a whole. Desks refer to their category by name, and
+// categories and desks are matched to the existing ones by name and label,
+// so that the same file can set up any number of locations.
+//
+// Hours and settings left out are not managed by the file, and are kept as
+// they are when it is applied. Those given replace the category's or
+// location's as a whole, as their PUT routes do.
+type LocationConfig struct {
+	Hours      *OpeningSchedule `json:"hours,omitempty" yaml:"hours,omitempty"`
+	Categories []CategoryConfig `json:"categories" yaml:"categories"`
+	Desks      []DeskConfig     `json:"desks" yaml:"desks"`
+}
+
+type CategoryConfig struct {
+	Name     string            `json:"name" yaml:"name"`
+	Settings *CategorySettings `json:"settings,omitempty" yaml:"settings,omitempty"`
+	Hours    *OpeningSchedule  `json:"hours,omitempty" yaml:"hours,omitempty"`
+}
+
+type DeskConfig struct {
+	Label    string `json:"label" yaml:"label"`
+	Category string `json:"category" yaml:"category"`
+}
+
+type ConfigAction string
+
+const (
+	ConfigCreate ConfigAction = "create"
+	ConfigUpdate ConfigAction = "update"
+	ConfigDelete ConfigAction = "delete"
+)
+
+type ConfigKind string
+
+const (
+	ConfigLocation ConfigKind = "location"
+	ConfigCategory ConfigKind = "category"
+	ConfigDesk     ConfigKind = "desk"
+)
+
+// ConfigChange is a change made, or that would be made on a dry run, by
+// applying a LocationConfig. Fields lists what an update changes.
+type ConfigChange struct {
+	Action ConfigAction `json:"action"`
+	Kind   ConfigKind   `json:"kind"`
+	Name   string       `json:"name"`
+	Fields []string     `json:"fields,omitempty"`
+}
+
+// ConfigPlan is the outcome of applying a LocationConfig. Nothing is changed
+// on a dry run.
+type ConfigPlan struct {
+	DryRun  bool           `json:"dry_run"`
+	Changes []ConfigChange `json:"changes"`
+}
+
+// IdempotentRequest is a request made with an Idempotency-Key header, kept so
+// that retries of it get its response instead of running it again.
+// Fingerprint identifies the request the key was first used for. Status is
+// zero until the response is stored.
+type IdempotentRequest struct {
+	Key         string
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
 }
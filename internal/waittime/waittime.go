@@ -0,0 +1,103 @@
+// Package waittime estimates how long a ticket will wait before being called,
+// based on how long recent tickets of the same category took to serve.
+package waittime
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const DefaultServiceTime = 5 * time.Minute
+
+// Strategy derives the expected duration of the next service from the
+// durations of previous services, ordered from oldest to newest.
+type Strategy interface {
+	ServiceTime(history []time.Duration) time.Duration
+}
+
+// MovingAverage is the mean of the last Window services, or of the whole
+// history when Window is not positive.
+type MovingAverage struct {
+	Window int
+}
+
+func (m MovingAverage) ServiceTime(history []time.Duration) time.Duration {
+	if m.Window > 0 && len(history) > m.Window {
+		history = history[len(history)-m.Window:]
+	}
+
+	if len(history) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, d := range history {
+		total += d
+	}
+
+	return total / time.Duration(len(history))
+}
+
+// ExponentialSmoothing weights each service by Alpha against the running
+// estimate, so recent services count for more than older ones.
+type ExponentialSmoothing struct {
+	Alpha float64
+}
+
+func (e ExponentialSmoothing) ServiceTime(history []time.Duration) time.Duration {
+	if len(history) == 0 {
+		return 0
+	}
+
+	estimate := float64(history[0])
+	for _, d := range history[1:] {
+		estimate = e.Alpha*float64(d) + (1-e.Alpha)*estimate
+	}
+
+	return time.Duration(estimate)
+}
+
+// NewStrategy returns the named strategy with its default parameters. An empty
+// name selects the moving average.
+func NewStrategy(name string) (Strategy, error) {
+	switch name {
+	case "", "moving_average":
+		return MovingAverage{Window: 20}, nil
+	case "exponential_smoothing":
+		return ExponentialSmoothing{Alpha: 0.3}, nil
+	default:
+		return nil, fmt.Errorf("unknown wait time strategy %q", name)
+	}
+}
+
+type Estimator struct {
+	Strategy Strategy
+	// Default is the service time assumed for a category with no history.
+	Default time.Duration
+}
+
+func NewEstimator(strategy Strategy) Estimator {
+	return Estimator{Strategy: strategy, Default: DefaultServiceTime}
+}
+
+// ServiceTime returns the expected duration of the next service.
+func (e Estimator) ServiceTime(history []time.Duration) time.Duration {
+	if serviceTime := e.Strategy.ServiceTime(history); serviceTime > 0 {
+		return serviceTime
+	}
+	return e.Default
+}
+
+// Estimate returns the expected wait for a ticket with ahead tickets in front
+// of it, shared between openDesks desks. It returns false when no desks are
+// open, as the wait cannot be estimated.
+func (e Estimator) Estimate(history []time.Duration, ahead int, openDesks int) (time.Duration, bool) {
+	if openDesks < 1 {
+		return 0, false
+	}
+
+	rounds := math.Ceil(float64(ahead+1) / float64(openDesks))
+
+	return time.Duration(rounds * float64(e.ServiceTime(history))), true
+}
@@ -0,0 +1,102 @@
+package waittime
+
+import (
+	"testing"
+	"time"
+)
+
+func minutes(values ...int) []time.Duration {
+	history := make([]time.Duration, len(values))
+	for i, m := range values {
+		history[i] = time.Duration(m) * time.Minute
+	}
+	return history
+}
+
+func TestMovingAverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  int
+		history []time.Duration
+		want    time.Duration
+	}{
+		{"empty history", 20, nil, 0},
+		{"single service", 20, minutes(4), 4 * time.Minute},
+		{"whole history within the window", 20, minutes(2, 4, 6), 4 * time.Minute},
+		{"only the last services of the window", 2, minutes(30, 2, 4), 3 * time.Minute},
+		{"no window", 0, minutes(1, 2, 3, 4, 5), 3 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (MovingAverage{Window: tt.window}).ServiceTime(tt.history); got != tt.want {
+				t.Errorf("ServiceTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExponentialSmoothing(t *testing.T) {
+	tests := []struct {
+		name    string
+		alpha   float64
+		history []time.Duration
+		want    time.Duration
+	}{
+		{"empty history", 0.3, nil, 0},
+		{"single service", 0.3, minutes(4), 4 * time.Minute},
+		{"recent services count for more", 0.5, minutes(2, 4, 8), 5*time.Minute + 30*time.Second},
+		{"alpha of one follows the last service", 1, minutes(2, 4, 8), 8 * time.Minute},
+		{"alpha of zero keeps the first service", 0, minutes(2, 4, 8), 2 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (ExponentialSmoothing{Alpha: tt.alpha}).ServiceTime(tt.history); got != tt.want {
+				t.Errorf("ServiceTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  Strategy
+		history   []time.Duration
+		ahead     int
+		openDesks int
+		want      time.Duration
+		ok        bool
+	}{
+		{"first in line", MovingAverage{Window: 20}, minutes(4), 0, 1, 4 * time.Minute, true},
+		{"behind others at one desk", MovingAverage{Window: 20}, minutes(4), 3, 1, 16 * time.Minute, true},
+		{"shared between desks", MovingAverage{Window: 20}, minutes(4), 3, 2, 8 * time.Minute, true},
+		{"partial round between desks", MovingAverage{Window: 20}, minutes(4), 2, 2, 8 * time.Minute, true},
+		{"more desks than tickets", MovingAverage{Window: 20}, minutes(4), 1, 5, 4 * time.Minute, true},
+		{"empty history uses the default", MovingAverage{Window: 20}, nil, 1, 1, 2 * DefaultServiceTime, true},
+		{"exponential smoothing", ExponentialSmoothing{Alpha: 1}, minutes(2, 6), 1, 1, 12 * time.Minute, true},
+		{"no open desks", MovingAverage{Window: 20}, minutes(4), 0, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NewEstimator(tt.strategy).Estimate(tt.history, tt.ahead, tt.openDesks)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Estimate() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNewStrategy(t *testing.T) {
+	for _, name := range []string{"", "moving_average", "exponential_smoothing"} {
+		if _, err := NewStrategy(name); err != nil {
+			t.Errorf("NewStrategy(%q) returned %v", name, err)
+		}
+	}
+
+	if _, err := NewStrategy("median"); err == nil {
+		t.Error("NewStrategy accepted an unknown strategy")
+	}
+}
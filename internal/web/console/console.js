@@ -0,0 +1,216 @@
+// The staff console drives the /internal API on behalf of the operator signed
+// in to a desk, and refreshes from the event stream of the desk's category.
+(function () {
+  // The console may be served under a location's prefix, /locations/{loc}.
+  const base = window.location.pathname.replace(/\/console(\/.*)?$/, "");
+  const $ = function (id) { return document.getElementById(id); };
+
+  const state = {
+    staff: localStorage.getItem("staff") || "",
+    deskID: Number(localStorage.getItem("desk_id")) || 0,
+    desk: null,
+    current: null,
+    stream: null,
+    refreshTimer: null,
+    calling: false,
+  };
+
+  function message(text, isError) {
+    $("message").textContent = text || "";
+    $("message").className = isError ? "error" : "";
+  }
+
+  function request(method, path, body, headers) {
+    const options = { method: method, headers: Object.assign({ "X-Staff-ID": state.staff }, headers) };
+    if (body !== undefined) {
+      options.headers["Content-Type"] = "application/json";
+      options.body = JSON.stringify(body);
+    }
+    return fetch(base + path, options).then(function (response) {
+      if (response.status === 204) {
+        return null;
+      }
+      return response.json().then(function (data) {
+        if (!response.ok) {
+          const text = data && data.errors ? data.errors.join(", ") : data;
+          throw new Error(text || response.statusText);
+        }
+        return data;
+      });
+    });
+  }
+
+  function fail(err) {
+    message(err.message, true);
+  }
+
+  // idempotencyKey identifies a single action, so that the server does not
+  // repeat it if its request is sent again. crypto.randomUUID is only there in
+  // secure contexts, which a console on the local network may not be.
+  function idempotencyKey() {
+    if (crypto.randomUUID) {
+      return crypto.randomUUID();
+    }
+    return Array.from(crypto.getRandomValues(new Uint8Array(16)), function (b) {
+      return b.toString(16).padStart(2, "0");
+    }).join("");
+  }
+
+  function loadOptions() {
+    return Promise.all([request("GET", "/desk"), request("GET", "/category")]).then(function (results) {
+      const desks = results[0];
+      const categories = results[1];
+
+      $("desk").replaceChildren();
+      desks.forEach(function (desk) {
+        $("desk").append(new Option(desk.label, desk.id, false, desk.id === state.deskID));
+      });
+
+      $("transfer-category").replaceChildren();
+      categories.forEach(function (category) {
+        $("transfer-category").append(new Option(category.name, category.id));
+      });
+    });
+  }
+
+  function renderSession(session) {
+    const signedIn = session.state !== "closed";
+    $("sign-in").hidden = signedIn;
+    $("session").hidden = !signedIn;
+    $("session-state").textContent = state.desk ? state.desk.label + " - " + session.state + " (" + session.staff + ")" : "";
+    $("pause").hidden = session.state !== "open";
+    $("resume").hidden = session.state !== "paused";
+    $("call-next").disabled = session.state !== "open" || state.calling;
+  }
+
+  function renderCurrent() {
+    const hasTicket = state.current !== null;
+    $("current").textContent = hasTicket ? state.current.display_number : "No ticket";
+    const serving = hasTicket && Boolean(state.current.served_at);
+    ["complete", "transfer"].forEach(function (id) {
+      $(id).disabled = !hasTicket;
+    });
+    ["recall", "serve", "no-show"].forEach(function (id) {
+      $(id).disabled = !hasTicket || serving;
+    });
+  }
+
+  function renderQueue(queue) {
+    $("queue-count").textContent = "(" + queue.length + ")";
+    $("queue").replaceChildren();
+    queue.forEach(function (ticket) {
+      const item = document.createElement("li");
+      item.textContent = ticket.display_number;
+      if (ticket.priority) {
+        item.classList.add("priority");
+        item.textContent += " (appointment)";
+      }
+      $("queue").append(item);
+    });
+  }
+
+  function refresh() {
+    if (!state.desk) {
+      return Promise.resolve();
+    }
+    const deskPath = "/internal/desks/" + state.desk.id;
+    return Promise.all([
+      request("GET", deskPath + "/session"),
+      request("GET", deskPath + "/tickets"),
+      request("GET", "/internal/queue?category_id=" + state.desk.category_id),
+    ]).then(function (results) {
+      renderSession(results[0]);
+      state.current = results[1].length > 0 ? results[1][0] : null;
+      renderCurrent();
+      renderQueue(results[2]);
+    }).catch(fail);
+  }
+
+  function scheduleRefresh() {
+    clearTimeout(state.refreshTimer);
+    state.refreshTimer = setTimeout(refresh, 200);
+  }
+
+  function selectDesk(deskID) {
+    return request("GET", "/desk/" + deskID).then(function (desk) {
+      state.desk = desk;
+      state.deskID = desk.id;
+      localStorage.setItem("desk_id", desk.id);
+
+      if (state.stream) {
+        state.stream.close();
+      }
+      state.stream = new EventSource(base + "/events?category_id=" + desk.category_id);
+      state.stream.onmessage = scheduleRefresh;
+      ["called", "recalled", "serving", "created", "closed", "cancelled", "transferred", "no_show", "requeued", "checked_in", "snoozed", "deleted"].forEach(function (type) {
+        state.stream.addEventListener(type, scheduleRefresh);
+      });
+
+      return refresh();
+    });
+  }
+
+  function ticketAction(action, body) {
+    if (!state.current) {
+      return;
+    }
+    request("PUT", "/internal/tickets/" + state.current.id + "/" + action, body)
+      .then(function () { message(""); return refresh(); })
+      .catch(fail);
+  }
+
+  $("sign-in").addEventListener("submit", function (event) {
+    event.preventDefault();
+    state.staff = $("staff").value.trim();
+    localStorage.setItem("staff", state.staff);
+    const deskID = Number($("desk").value);
+    selectDesk(deskID)
+      .then(function () { return request("POST", "/internal/desks/" + deskID + "/session"); })
+      .then(function () { message(""); return refresh(); })
+      .catch(fail);
+  });
+
+  $("pause").addEventListener("click", function () {
+    request("PUT", "/internal/desks/" + state.desk.id + "/session", { state: "paused" }).then(refresh).catch(fail);
+  });
+
+  $("resume").addEventListener("click", function () {
+    request("PUT", "/internal/desks/" + state.desk.id + "/session", { state: "open" }).then(refresh).catch(fail);
+  });
+
+  $("close-desk").addEventListener("click", function () {
+    request("DELETE", "/internal/desks/" + state.desk.id + "/session").then(refresh).catch(fail);
+  });
+
+  // A click calls one ticket: the button stays disabled until the call has
+  // been answered, and a resent request is not taken as another call.
+  $("call-next").addEventListener("click", function () {
+    state.calling = true;
+    $("call-next").disabled = true;
+    request("PUT", "/internal/next", { desk_id: state.desk.id }, { "Idempotency-Key": idempotencyKey() })
+      .then(function () { message(""); })
+      .catch(fail)
+      .finally(function () {
+        state.calling = false;
+        return refresh();
+      });
+  });
+
+  $("recall").addEventListener("click", function () { ticketAction("recall"); });
+  $("serve").addEventListener("click", function () { ticketAction("serve"); });
+  $("complete").addEventListener("click", function () { ticketAction("close"); });
+  $("no-show").addEventListener("click", function () { ticketAction("no-show"); });
+  $("transfer").addEventListener("click", function () {
+    ticketAction("transfer", { category_id: Number($("transfer-category").value) });
+  });
+
+  $("staff").value = state.staff;
+  renderCurrent();
+  loadOptions()
+    .then(function () {
+      if (state.deskID) {
+        return selectDesk(state.deskID);
+      }
+    })
+    .catch(fail);
+})();
@@ -0,0 +1,91 @@
+// The display board shows the state of the queues listed in its own
+// ?category_id= parameter (all of them when absent), refreshing whenever a
+// ticket event arrives on the event stream.
+(function () {
+  // The board may be served under a location's prefix, /locations/{loc}.
+  const base = window.location.pathname.replace(/\/display(\/.*)?$/, "");
+  const params = new URLSearchParams(window.location.search);
+  const filter = new URLSearchParams();
+  if (params.get("category_id")) {
+    filter.set("category_id", params.get("category_id"));
+  }
+  if (params.get("limit")) {
+    filter.set("limit", params.get("limit"));
+  }
+
+  const calledBody = document.getElementById("called");
+  const categoryList = document.getElementById("categories");
+  const status = document.getElementById("status");
+
+  let lastCall = null;
+  let refreshTimer = null;
+
+  function formatWait(seconds) {
+    if (seconds === undefined || seconds === null) {
+      return "";
+    }
+    const minutes = Math.max(1, Math.round(seconds / 60));
+    return "about " + minutes + " min";
+  }
+
+  function render(state) {
+    calledBody.replaceChildren();
+    state.called.forEach(function (call, i) {
+      const row = document.createElement("tr");
+      const number = document.createElement("td");
+      const desk = document.createElement("td");
+      number.textContent = call.display_number;
+      desk.textContent = call.desk_label;
+      row.append(number, desk);
+      if (i === 0) {
+        row.classList.add("latest");
+        const key = call.ticket_id + "@" + call.called_at;
+        if (lastCall !== null && key !== lastCall) {
+          row.classList.add("flash");
+        }
+        lastCall = key;
+      }
+      calledBody.append(row);
+    });
+
+    categoryList.replaceChildren();
+    state.categories.forEach(function (category) {
+      const item = document.createElement("li");
+      const count = document.createElement("span");
+      const wait = document.createElement("span");
+      item.textContent = category.name;
+      count.className = "count";
+      count.textContent = category.waiting;
+      wait.className = "wait";
+      wait.textContent = formatWait(category.estimated_wait_seconds);
+      item.append(count, wait);
+      categoryList.append(item);
+    });
+
+    status.textContent = "Updated " + new Date().toLocaleTimeString();
+  }
+
+  function refresh() {
+    fetch(base + "/display/state?" + filter.toString())
+      .then(function (response) { return response.json(); })
+      .then(render)
+      .catch(function (err) { status.textContent = "Offline: " + err; });
+  }
+
+  // A burst of events only causes a single refresh.
+  function scheduleRefresh() {
+    clearTimeout(refreshTimer);
+    refreshTimer = setTimeout(refresh, 250);
+  }
+
+  const stream = new EventSource(base + "/events?" + filter.toString());
+  stream.onmessage = scheduleRefresh;
+  ["called", "recalled", "serving", "created", "closed", "cancelled", "transferred", "no_show", "requeued", "checked_in", "snoozed", "deleted"].forEach(function (type) {
+    stream.addEventListener(type, scheduleRefresh);
+  });
+  stream.onopen = refresh;
+
+  refresh();
+  // Waits change as time passes even when no events arrive.
+  setInterval(refresh, 60000);
+})();
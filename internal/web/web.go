@@ -0,0 +1,29 @@
+// Package web holds the pages served by the binary for the screens in the
+// waiting area and at the desks.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed display console
+var assets embed.FS
+
+// Display returns the lobby display board, rooted at its index.html.
+func Display() fs.FS {
+	display, err := fs.Sub(assets, "display")
+	if err != nil {
+		panic(err)
+	}
+	return display
+}
+
+// Console returns the staff console, rooted at its index.html.
+func Console() fs.FS {
+	console, err := fs.Sub(assets, "console")
+	if err != nil {
+		panic(err)
+	}
+	return console
+}
@@ -0,0 +1,166 @@
+// Package webhooks sends the ticket and desk session events queued for the
+// subscriptions of integrators, signed so that they can check where they came
+// from.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/khaleelsyed/codaVirtuale/internal/safehttp"
+	"github.com/khaleelsyed/codaVirtuale/internal/types"
+)
+
+const DefaultInterval = 2 * time.Second
+
+const (
+	batchSize   = 50
+	lease       = time.Minute
+	maxAttempts = 10
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Coda-Event"
+	HeaderDelivery  = "X-Coda-Delivery"
+	HeaderTimestamp = "X-Coda-Timestamp"
+	HeaderSignature = "X-Coda-Signature"
+)
+
+type Storage interface {
+	ClaimWebhookDeliveries(limit int, lease time.Duration) ([]types.WebhookDelivery, error)
+	RecordWebhookAttempt(attempt types.WebhookAttempt, delivered bool, retryAfter time.Duration) error
+}
+
+// Sign returns the signature sent in the X-Coda-Signature header: the hex
+// encoded HMAC-SHA256, keyed with the subscription's secret, of the timestamp
+// header, a full stop and the body.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher sends due deliveries, retrying failures with an exponential
+// backoff until maxAttempts have been made. Its client refuses to connect to
+// internal addresses and does not follow redirects, which count as failures.
+type Dispatcher struct {
+	storage  Storage
+	client   *http.Client
+	logger   *types.SugarWithTrace
+	interval time.Duration
+}
+
+func NewDispatcher(storage Storage, logger *types.SugarWithTrace, interval time.Duration) *Dispatcher {
+	return &Dispatcher{
+		storage:  storage,
+		client:   safehttp.NewClient(10 * time.Second),
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Run sends due deliveries every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.dispatch(ctx)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	deliveries, err := d.storage.ClaimWebhookDeliveries(batchSize, lease)
+	if err != nil {
+		d.logger.Errorw("failed to claim webhook deliveries", "error", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		attempt := d.send(ctx, delivery)
+		delivered := attempt.Error == ""
+
+		var retryAfter time.Duration
+		if !delivered && delivery.Attempts < maxAttempts {
+			retryAfter = backoff(delivery.Attempts)
+		}
+
+		if !delivered {
+			d.logger.Warnw("failed to deliver webhook", "id", delivery.ID, "subscription_id", delivery.SubscriptionID, "attempts", delivery.Attempts, "retry_after", retryAfter, "error", attempt.Error)
+		}
+
+		if err = d.storage.RecordWebhookAttempt(attempt, delivered, retryAfter); err != nil {
+			d.logger.Warnw("failed to record webhook attempt", "id", delivery.ID, "error", err)
+		}
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery types.WebhookDelivery) (attempt types.WebhookAttempt) {
+	attempt.DeliveryID = delivery.ID
+	started := time.Now()
+
+	defer func() {
+		attempt.DurationMS = int(time.Since(started).Milliseconds())
+	}()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	timestamp := strconv.FormatInt(started.Unix(), 10)
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "codaVirtuale-webhooks")
+	request.Header.Set(HeaderEvent, delivery.EventType)
+	request.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	request.Header.Set(HeaderTimestamp, timestamp)
+	request.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	attempt.StatusCode = response.StatusCode
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected response status %s", response.Status)
+	}
+
+	return attempt
+}
+
+// backoff is how long to wait before the next attempt, doubling with each one
+// made so far.
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
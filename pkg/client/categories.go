@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type categoryBody struct {
+	Name string `json:"name"`
+}
+
+func (c *Client) ListCategories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	err := c.do(ctx, request{method: http.MethodGet, path: "/category"}, &categories)
+	return categories, err
+}
+
+// GetCategory returns a category with the state of its queue.
+func (c *Client) GetCategory(ctx context.Context, id int) (CategoryStatus, error) {
+	var category CategoryStatus
+	err := c.do(ctx, request{method: http.MethodGet, path: fmt.Sprintf("/category/%d", id)}, &category)
+	return category, err
+}
+
+func (c *Client) CreateCategory(ctx context.Context, name string) (Category, error) {
+	var category Category
+	err := c.do(ctx, request{method: http.MethodPost, path: "/category", body: categoryBody{name}}, &category)
+	return category, err
+}
+
+// RenameCategory renames a category if it is still at version, as read with
+// GetCategory, failing with ErrPreconditionFailed if someone else has changed
+// it since. A version of zero renames it whatever its version.
+func (c *Client) RenameCategory(ctx context.Context, id int, name string, version int) (Category, error) {
+	var category Category
+	err := c.do(ctx, request{method: http.MethodPut, path: fmt.Sprintf("/category/%d", id), body: categoryBody{name}, ifMatch: ifMatch(version)}, &category)
+	return category, err
+}
+
+// DeleteCategory deletes a category if it is still at version, or whatever
+// its version when that is zero.
+func (c *Client) DeleteCategory(ctx context.Context, id int, version int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: fmt.Sprintf("/category/%d", id), ifMatch: ifMatch(version)}, nil)
+}
+
+func (c *Client) GetCategorySettings(ctx context.Context, id int) (CategorySettings, error) {
+	var settings CategorySettings
+	err := c.do(ctx, request{method: http.MethodGet, path: fmt.Sprintf("/category/%d/settings", id)}, &settings)
+	return settings, err
+}
+
+// SetCategorySettings replaces the queue policies of a category. The
+// category's ID is taken from settings.
+func (c *Client) SetCategorySettings(ctx context.Context, settings CategorySettings) (CategorySettings, error) {
+	var updated CategorySettings
+	err := c.do(ctx, request{method: http.MethodPut, path: fmt.Sprintf("/category/%d/settings", settings.CategoryID), body: settings}, &updated)
+	return updated, err
+}
+
+func (c *Client) GetCategoryHours(ctx context.Context, id int) (CategoryHours, error) {
+	var hours CategoryHours
+	err := c.do(ctx, request{method: http.MethodGet, path: fmt.Sprintf("/category/%d/hours", id)}, &hours)
+	return hours, err
+}
+
+// SetCategoryHours replaces the opening hours of a category. The category's
+// ID is taken from hours.
+func (c *Client) SetCategoryHours(ctx context.Context, hours CategoryHours) (CategoryHours, error) {
+	var updated CategoryHours
+	err := c.do(ctx, request{method: http.MethodPut, path: fmt.Sprintf("/category/%d/hours", hours.CategoryID), body: hours}, &updated)
+	return updated, err
+}
+
+// SetIntakeClosed stops or resumes the issuing of tickets for a category.
+func (c *Client) SetIntakeClosed(ctx context.Context, id int, closed bool) (CategorySettings, error) {
+	body := struct {
+		Closed bool `json:"closed"`
+	}{closed}
+
+	var settings CategorySettings
+	err := c.do(ctx, request{method: http.MethodPut, path: fmt.Sprintf("/category/%d/intake", id), body: body}, &settings)
+	return settings, err
+}
@@ -0,0 +1,263 @@
+// Package client is a Go client for the Coda Virtuale REST API, for the kiosk,
+// signage and other services which drive the queue.
+package client
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryWait  = 250 * time.Millisecond
+	maxRetryWait      = 10 * time.Second
+)
+
+// Headers the server reads besides the body of a request.
+const (
+	HeaderStaffID        = "X-Staff-ID"
+	HeaderDeviceToken    = "X-Device-Token"
+	HeaderIdempotencyKey = "Idempotency-Key"
+	HeaderIfMatch        = "If-Match"
+)
+
+// Client calls the API of a single location. Its fields may be changed until
+// it is first used.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Location is the slug of the location acted on, the default location
+	// when empty.
+	Location string
+	// StaffID is recorded in the ticket history against staff actions.
+	StaffID string
+	// DeviceToken identifies a kiosk for the limit on how many tickets a
+	// single client may take.
+	DeviceToken string
+	// MaxRetries is how many times a request is retried after a connection
+	// error, a 429 or a 502, 503 or 504 response, or while the server is
+	// still running an earlier attempt.
+	MaxRetries int
+	// RetryWait is how long to wait before the first retry. It doubles for
+	// each one after, unless the server asks for longer with Retry-After.
+	RetryWait time.Duration
+}
+
+// New returns a client for the server at baseURL, such as
+// "https://queue.example.com".
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: DefaultMaxRetries,
+		RetryWait:  DefaultRetryWait,
+	}
+}
+
+// ForLocation returns a copy of the client acting on the location with the
+// given slug.
+func (c *Client) ForLocation(slug string) *Client {
+	copy := *c
+	copy.Location = slug
+	return &copy
+}
+
+// url returns the URL of a path of the client's location. Paths outside of
+// any location, such as those of the locations themselves, are marked root.
+func (c *Client) url(path string, query url.Values, root bool) string {
+	prefix := ""
+	if c.Location != "" && !root {
+		prefix = "/locations/" + url.PathEscape(c.Location)
+	}
+
+	u := c.BaseURL + prefix + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// request describes a call to the API. Bodies are encoded as JSON unless
+// given as a []byte, and sent as contentType, JSON by default. Responses are
+// asked for as accept, also JSON by default. ifMatch is the If-Match header
+// of changes to versioned resources.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        any
+	contentType string
+	accept      string
+	ifMatch     string
+	root        bool
+}
+
+// do sends a request, retrying it when it may succeed later, and decodes the
+// response into out unless it is nil, or reads it whole into out if it is a
+// *[]byte. Requests other than GETs carry an idempotency key, the same for
+// every attempt, so that the server replays the response of a retry rather
+// than acting twice, as when calling the next ticket.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	body, raw := req.body.([]byte)
+	if req.body != nil && !raw {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("encoding request body: %w", err)
+		}
+	}
+
+	idempotencyKey := ""
+	if req.method != http.MethodGet {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req, body, idempotencyKey)
+
+		wait, retry := c.retryAfter(ctx, attempt, resp, err)
+		if !retry {
+			if err != nil {
+				return err
+			}
+			return decodeResponse(resp, out)
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, body []byte, idempotencyKey string) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.url(req.path, req.query, req.root), bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Accept", cmp.Or(req.accept, "application/json"))
+	if body != nil {
+		httpReq.Header.Set("Content-Type", cmp.Or(req.contentType, "application/json"))
+	}
+	if idempotencyKey != "" {
+		httpReq.Header.Set(HeaderIdempotencyKey, idempotencyKey)
+	}
+	if req.ifMatch != "" {
+		httpReq.Header.Set(HeaderIfMatch, req.ifMatch)
+	}
+	if c.StaffID != "" {
+		httpReq.Header.Set(HeaderStaffID, c.StaffID)
+	}
+	if c.DeviceToken != "" {
+		httpReq.Header.Set(HeaderDeviceToken, c.DeviceToken)
+	}
+
+	return c.HTTPClient.Do(httpReq)
+}
+
+// retryAfter reports whether an attempt should be retried, and how long to
+// wait before doing so.
+func (c *Client) retryAfter(ctx context.Context, attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= c.MaxRetries || ctx.Err() != nil {
+		return 0, false
+	}
+
+	if err == nil {
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		case http.StatusConflict:
+			// The server asks for a retry of a request whose earlier attempt
+			// it is still running. Other conflicts are final.
+			if resp.Header.Get("Retry-After") == "" {
+				return 0, false
+			}
+		default:
+			return 0, false
+		}
+	}
+
+	wait := c.RetryWait << attempt
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && time.Duration(seconds)*time.Second > wait {
+			wait = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return min(wait, maxRetryWait), true
+}
+
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newError(resp)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	if raw, ok := out.(*[]byte); ok {
+		var err error
+		if *raw, err = io.ReadAll(resp.Body); err != nil {
+			return fmt.Errorf("reading response: %w", err)
+		}
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// ifMatch is the If-Match header of a change to a category or desk based on
+// the given version, or of one made whatever the version when it is zero.
+func ifMatch(version int) string {
+	if version == 0 {
+		return "*"
+	}
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// categoryQuery is the 'category_id' filter of the queue and the live views.
+func categoryQuery(categoryIDs []int) url.Values {
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(categoryIDs))
+	for i, id := range categoryIDs {
+		ids[i] = strconv.Itoa(id)
+	}
+	return url.Values{"category_id": {strings.Join(ids, ",")}}
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ExportConfig returns the categories and desks of the client's location as
+// a YAML config.
+func (c *Client) ExportConfig(ctx context.Context) ([]byte, error) {
+	var config []byte
+	err := c.do(ctx, request{method: http.MethodGet, path: "/internal/config", accept: "application/yaml"}, &config)
+	return config, err
+}
+
+// ApplyConfig creates and updates the categories and desks of the client's
+// location to match a YAML or JSON config, deleting those it leaves out when
+// prune is set. A dry run lists the changes without making them.
+func (c *Client) ApplyConfig(ctx context.Context, config []byte, prune bool, dryRun bool) (ConfigPlan, error) {
+	query := url.Values{"prune": {strconv.FormatBool(prune)}, "dry_run": {strconv.FormatBool(dryRun)}}
+
+	var plan ConfigPlan
+	err := c.do(ctx, request{method: http.MethodPut, path: "/internal/config", query: query, body: config, contentType: "application/yaml"}, &plan)
+	return plan, err
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+func (c *Client) ListDesks(ctx context.Context) ([]Desk, error) {
+	var desks []Desk
+	err := c.do(ctx, request{method: http.MethodGet, path: "/desk"}, &desks)
+	return desks, err
+}
+
+func (c *Client) GetDesk(ctx context.Context, id int) (Desk, error) {
+	var desk Desk
+	err := c.do(ctx, request{method: http.MethodGet, path: fmt.Sprintf("/desk/%d", id)}, &desk)
+	return desk, err
+}
+
+func (c *Client) CreateDesk(ctx context.Context, label string, categoryID int) (Desk, error) {
+	body := struct {
+		Label      string `json:"label"`
+		CategoryID int    `json:"category_id"`
+	}{label, categoryID}
+
+	var desk Desk
+	err := c.do(ctx, request{method: http.MethodPost, path: "/desk", body: body}, &desk)
+	return desk, err
+}
+
+// UpdateDesk changes a desk if it is still at version, as read with GetDesk,
+// failing with ErrPreconditionFailed if someone else has changed it since. A
+// version of zero changes the desk whatever its version. The update is sent
+// as a merge patch, so the fields left empty keep their value.
+func (c *Client) UpdateDesk(ctx context.Context, id int, update DeskUpdate, version int) (Desk, error) {
+	var desk Desk
+	err := c.do(ctx, request{
+		method:      http.MethodPatch,
+		path:        fmt.Sprintf("/desk/%d", id),
+		body:        update,
+		contentType: "application/merge-patch+json",
+		ifMatch:     ifMatch(version),
+	}, &desk)
+	return desk, err
+}
+
+// DeleteDesk deletes a desk if it is still at version, or whatever its
+// version when that is zero.
+func (c *Client) DeleteDesk(ctx context.Context, id int, version int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: fmt.Sprintf("/desk/%d", id), ifMatch: ifMatch(version)}, nil)
+}
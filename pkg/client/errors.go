@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors to compare an *Error with using errors.Is, which matches any error
+// response of the same status.
+var (
+	ErrBadRequest         = &Error{StatusCode: http.StatusBadRequest}
+	ErrNotFound           = &Error{StatusCode: http.StatusNotFound}
+	ErrConflict           = &Error{StatusCode: http.StatusConflict}
+	ErrPreconditionFailed = &Error{StatusCode: http.StatusPreconditionFailed}
+	ErrTooManyRequests    = &Error{StatusCode: http.StatusTooManyRequests}
+)
+
+// Error is an error response from the server. The server sends a single
+// message, or a list of every problem with a request that failed validation.
+type Error struct {
+	StatusCode int
+	Messages   []string
+	// RetryAfter is how long the server asked the client to wait before trying
+	// again, if it did.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("coda: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("coda: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), strings.Join(e.Messages, "; "))
+}
+
+// Is reports whether target is one of the status errors, such as
+// ErrNotFound, of the same status.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && len(t.Messages) == 0 && t.StatusCode == e.StatusCode
+}
+
+// newError reads the error envelope of a response: a JSON string, or an
+// object listing the errors.
+func newError(resp *http.Response) *Error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil || len(body) == 0 {
+		return apiErr
+	}
+
+	var message string
+	if err = json.Unmarshal(body, &message); err == nil {
+		apiErr.Messages = []string{message}
+		return apiErr
+	}
+
+	var list struct {
+		Errors []string `json:"errors"`
+	}
+	if err = json.Unmarshal(body, &list); err == nil && len(list.Errors) > 0 {
+		apiErr.Messages = list.Errors
+		return apiErr
+	}
+
+	apiErr.Messages = []string{strings.TrimSpace(string(body))}
+	return apiErr
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventSubscription receives the ticket events of a location as they happen.
+// C is closed when the stream ends, after which Err reports why.
+type EventSubscription struct {
+	C <-chan TicketEvent
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// SubscribeEvents streams the ticket events of the given categories, or of
+// every category of the location when none are given, until ctx is cancelled
+// or the subscription is closed. Staff identifiers are left out of the
+// events.
+func (c *Client) SubscribeEvents(ctx context.Context, categoryIDs ...int) (*EventSubscription, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// The stream stays open for as long as the subscription, so the client's
+	// timeout must not apply to it.
+	streamClient := *c
+	httpClient := *c.HTTPClient
+	httpClient.Timeout = 0
+	streamClient.HTTPClient = &httpClient
+
+	req := request{method: http.MethodGet, path: "/events", query: categoryQuery(categoryIDs)}
+
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		var err error
+		resp, err = streamClient.send(ctx, req, nil, "")
+
+		wait, retry := c.retryAfter(ctx, attempt, resp, err)
+		if !retry {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			break
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			cancel()
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		cancel()
+		return nil, decodeResponse(resp, nil)
+	}
+
+	events := make(chan TicketEvent)
+	subscription := &EventSubscription{C: events, cancel: cancel, done: make(chan struct{})}
+
+	go subscription.read(ctx, resp.Body, events)
+
+	return subscription, nil
+}
+
+// read decodes the server-sent events of body until it ends. Only the data
+// of each event is read, as it holds the event's ID and type as well.
+func (s *EventSubscription) read(ctx context.Context, body io.ReadCloser, events chan<- TicketEvent) {
+	defer close(s.done)
+	defer close(events)
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	var data strings.Builder
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data.WriteString(strings.TrimPrefix(value, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		var event TicketEvent
+		err := json.Unmarshal([]byte(data.String()), &event)
+		data.Reset()
+		if err != nil {
+			s.setErr(err)
+			return
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		s.setErr(err)
+		return
+	}
+	if ctx.Err() == nil {
+		s.setErr(io.ErrUnexpectedEOF)
+	}
+}
+
+func (s *EventSubscription) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Err returns the error that ended the stream, or nil if it was closed or its
+// context cancelled.
+func (s *EventSubscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription and waits for C to be closed.
+func (s *EventSubscription) Close() {
+	s.cancel()
+	<-s.done
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListLocations lists every location. Locations are managed outside of any one
+// of them, whatever the client's Location.
+func (c *Client) ListLocations(ctx context.Context) ([]Location, error) {
+	var locations []Location
+	err := c.do(ctx, request{method: http.MethodGet, path: "/locations", root: true}, &locations)
+	return locations, err
+}
+
+func (c *Client) GetLocation(ctx context.Context, slug string) (Location, error) {
+	var location Location
+	err := c.do(ctx, request{method: http.MethodGet, path: "/locations/" + url.PathEscape(slug), root: true}, &location)
+	return location, err
+}
+
+func (c *Client) CreateLocation(ctx context.Context, location Location) (Location, error) {
+	var created Location
+	err := c.do(ctx, request{method: http.MethodPost, path: "/locations", body: location, root: true}, &created)
+	return created, err
+}
+
+// UpdateLocation replaces the settings of the location with location's slug,
+// which cannot itself be changed.
+func (c *Client) UpdateLocation(ctx context.Context, location Location) (Location, error) {
+	var updated Location
+	err := c.do(ctx, request{method: http.MethodPut, path: "/locations/" + url.PathEscape(location.Slug), body: location, root: true}, &updated)
+	return updated, err
+}
+
+func (c *Client) GetLocationHours(ctx context.Context, slug string) (LocationHours, error) {
+	var hours LocationHours
+	err := c.do(ctx, request{method: http.MethodGet, path: "/locations/" + url.PathEscape(slug) + "/hours", root: true}, &hours)
+	return hours, err
+}
+
+func (c *Client) SetLocationHours(ctx context.Context, slug string, schedule OpeningSchedule) (LocationHours, error) {
+	var hours LocationHours
+	err := c.do(ctx, request{method: http.MethodPut, path: "/locations/" + url.PathEscape(slug) + "/hours", body: LocationHours{OpeningSchedule: schedule}, root: true}, &hours)
+	return hours, err
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CreateTicket takes a ticket in a category. Contact may be nil when the
+// customer has not asked to be notified.
+func (c *Client) CreateTicket(ctx context.Context, categoryID int, contact *TicketContact) (TicketStatus, error) {
+	body := struct {
+		CategoryID int            `json:"category_id"`
+		Contact    *TicketContact `json:"contact,omitempty"`
+	}{categoryID, contact}
+
+	var ticket TicketStatus
+	err := c.do(ctx, request{method: http.MethodPost, path: "/ticket", body: body}, &ticket)
+	return ticket, err
+}
+
+func (c *Client) GetTicket(ctx context.Context, id int) (TicketStatus, error) {
+	var ticket TicketStatus
+	err := c.do(ctx, request{method: http.MethodGet, path: fmt.Sprintf("/ticket/%d", id)}, &ticket)
+	return ticket, err
+}
+
+func (c *Client) DeleteTicket(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: fmt.Sprintf("/ticket/%d", id)}, nil)
+}
+
+// PeekNext returns the ticket that would be called next in a category.
+func (c *Client) PeekNext(ctx context.Context, categoryID int) (Ticket, error) {
+	var ticket Ticket
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/internal/next",
+		query:  url.Values{"category_id": {strconv.Itoa(categoryID)}},
+	}, &ticket)
+	return ticket, err
+}
+
+// CallNext calls the longest waiting ticket of the desk's category to it.
+func (c *Client) CallNext(ctx context.Context, deskID int) (Ticket, error) {
+	body := struct {
+		DeskID int `json:"desk_id"`
+	}{deskID}
+
+	var ticket Ticket
+	err := c.do(ctx, request{method: http.MethodPut, path: "/internal/next", body: body}, &ticket)
+	return ticket, err
+}
+
+// Queue lists the waiting tickets of the given categories, or of every
+// category when none are given, in the order they will be called.
+func (c *Client) Queue(ctx context.Context, categoryIDs ...int) ([]QueueEntry, error) {
+	var queue []QueueEntry
+	err := c.do(ctx, request{method: http.MethodGet, path: "/internal/queue", query: categoryQuery(categoryIDs)}, &queue)
+	return queue, err
+}
+
+// DeskTickets lists the open tickets called to a desk.
+func (c *Client) DeskTickets(ctx context.Context, deskID int) ([]QueueEntry, error) {
+	var tickets []QueueEntry
+	err := c.do(ctx, request{method: http.MethodGet, path: fmt.Sprintf("/internal/desks/%d/tickets", deskID)}, &tickets)
+	return tickets, err
+}
+
+// TicketHistory lists the events of a ticket, oldest first.
+func (c *Client) TicketHistory(ctx context.Context, id int) ([]TicketEvent, error) {
+	var history []TicketEvent
+	err := c.do(ctx, request{method: http.MethodGet, path: fmt.Sprintf("/internal/tickets/%d/history", id)}, &history)
+	return history, err
+}
+
+// RecallTicket calls a ticket to its desk again.
+func (c *Client) RecallTicket(ctx context.Context, id int) (Ticket, error) {
+	return c.ticketAction(ctx, id, "recall", nil)
+}
+
+// ServeTicket records that the customer of a called ticket has turned up, so
+// that they are no longer recalled or marked as a no-show.
+func (c *Client) ServeTicket(ctx context.Context, id int) (Ticket, error) {
+	return c.ticketAction(ctx, id, "serve", nil)
+}
+
+// TransferTicket moves a ticket to the queue of another category.
+func (c *Client) TransferTicket(ctx context.Context, id int, categoryID int) (Ticket, error) {
+	body := struct {
+		CategoryID int `json:"category_id"`
+	}{categoryID}
+
+	return c.ticketAction(ctx, id, "transfer", body)
+}
+
+// CloseTicket closes a ticket once it has been served.
+func (c *Client) CloseTicket(ctx context.Context, id int) (Ticket, error) {
+	return c.ticketAction(ctx, id, "close", nil)
+}
+
+// MarkNoShow gives up on the customer of a called ticket.
+func (c *Client) MarkNoShow(ctx context.Context, id int) (Ticket, error) {
+	return c.ticketAction(ctx, id, "no-show", nil)
+}
+
+func (c *Client) ticketAction(ctx context.Context, id int, action string, body any) (Ticket, error) {
+	var ticket Ticket
+	err := c.do(ctx, request{method: http.MethodPut, path: fmt.Sprintf("/internal/tickets/%d/%s", id, action), body: body}, &ticket)
+	return ticket, err
+}
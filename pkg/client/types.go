@@ -0,0 +1,55 @@
+package client
+
+import "github.com/khaleelsyed/codaVirtuale/internal/types"
+
+// The resources of the API, as the server encodes them.
+type (
+	Location            = types.Location
+	Branding            = types.Branding
+	LocationHours       = types.LocationHours
+	Category            = types.Category
+	CategorySettings    = types.CategorySettings
+	CategoryHours       = types.CategoryHours
+	OpeningSchedule     = types.OpeningSchedule
+	OpeningHours        = types.OpeningHours
+	HolidayException    = types.HolidayException
+	Desk                = types.Desk
+	Ticket              = types.Ticket
+	TicketContact       = types.TicketContact
+	TicketEvent         = types.TicketEvent
+	TicketEventType     = types.TicketEventType
+	WebhookSubscription = types.WebhookSubscription
+	LocationConfig      = types.LocationConfig
+	CategoryConfig      = types.CategoryConfig
+	DeskConfig          = types.DeskConfig
+	ConfigChange        = types.ConfigChange
+	ConfigPlan          = types.ConfigPlan
+)
+
+// TicketStatus is a ticket with its place in the queue while it is waiting.
+type TicketStatus struct {
+	Ticket
+	Position             *int `json:"position,omitempty"`
+	EstimatedWaitSeconds *int `json:"estimated_wait_seconds,omitempty"`
+}
+
+// CategoryStatus is a category with the state of its queue.
+type CategoryStatus struct {
+	Category
+	Waiting              int  `json:"waiting"`
+	OpenDesks            int  `json:"open_desks"`
+	EstimatedWaitSeconds *int `json:"estimated_wait_seconds,omitempty"`
+}
+
+// QueueEntry is a ticket in a queue with the number shown to its customer.
+type QueueEntry struct {
+	Ticket
+	DisplayNumber string `json:"display_number"`
+}
+
+// DeskUpdate changes the label or category of a desk, keeping whichever of
+// them is left empty.
+type DeskUpdate struct {
+	CategoryID int    `json:"category_id,omitempty"`
+	Label      string `json:"label,omitempty"`
+}